@@ -0,0 +1,294 @@
+package cluster
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cluster implements model.Cluster with a consistent hash ring
+// of member base URLs, e.g. `http://10.0.0.1:3000`
+type Cluster struct {
+	ctx          *model.CacheAppContext
+	self         string
+	virtualNodes int
+	client       *http.Client
+	// selfAddresses are the IPs of this node, a member on one of them
+	// with the port of self is self under another name
+	selfAddresses map[string]bool
+
+	mu          sync.RWMutex
+	ring        *Ring
+	rebalancing bool
+	// rebalance is signaled whenever the ring changes
+	rebalance chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// New creates a cluster where self is the base URL other members reach this node on
+func New(ctx *model.CacheAppContext, self string, members []string, virtualNodes int) *Cluster {
+	self = normalizeMember(self)
+	cluster := &Cluster{
+		ctx:           ctx,
+		self:          self,
+		selfAddresses: localAddresses(self),
+		virtualNodes:  virtualNodes,
+		client:        &http.Client{Timeout: 30 * time.Second, Transport: ctx.NodeTransport()},
+		rebalance:     make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	cluster.ring = NewRing(virtualNodes, cluster.withSelf(members))
+
+	go cluster.rebalanceLoop()
+	return cluster
+}
+
+func (cluster *Cluster) Self() string {
+	return cluster.self
+}
+
+func (cluster *Cluster) Owner(key string) string {
+	cluster.mu.RLock()
+	defer cluster.mu.RUnlock()
+
+	return cluster.ring.Owner(key)
+}
+
+func (cluster *Cluster) Info() model.ClusterInfo {
+	cluster.mu.RLock()
+	defer cluster.mu.RUnlock()
+
+	return model.ClusterInfo{
+		Self:         cluster.self,
+		Members:      cluster.ring.Members(),
+		VirtualNodes: cluster.virtualNodes,
+		Rebalancing:  cluster.rebalancing,
+	}
+}
+
+func (cluster *Cluster) SetMembers(members []string) {
+	ring := NewRing(cluster.virtualNodes, cluster.withSelf(members))
+
+	cluster.mu.Lock()
+	changed := strings.Join(ring.Members(), ",") != strings.Join(cluster.ring.Members(), ",")
+	cluster.ring = ring
+	cluster.mu.Unlock()
+
+	if changed {
+		select {
+		case cluster.rebalance <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Rebalance moves every local key owned by another member to that member
+// and returns how many keys were moved
+func (cluster *Cluster) Rebalance() (int, error) {
+	cluster.mu.Lock()
+	cluster.rebalancing = true
+	ring := cluster.ring
+	cluster.mu.Unlock()
+
+	defer func() {
+		cluster.mu.Lock()
+		cluster.rebalancing = false
+		cluster.mu.Unlock()
+	}()
+
+	moved := 0
+	for _, member := range ring.Members() {
+		if member == cluster.self {
+			continue
+		}
+
+		count, err := cluster.moveKeys(ring, member)
+		moved += count
+		if err != nil {
+			return moved, err
+		}
+	}
+
+	return moved, nil
+}
+
+// Close stops the background rebalancing
+func (cluster *Cluster) Close() {
+	close(cluster.stop)
+	<-cluster.done
+}
+
+func (cluster *Cluster) rebalanceLoop() {
+	defer close(cluster.done)
+
+	for {
+		select {
+		case <-cluster.stop:
+			return
+		case <-cluster.rebalance:
+			moved, err := cluster.Rebalance()
+			if err != nil {
+				log.Printf("Error when rebalancing cluster : %v", err.Error())
+			}
+			if moved > 0 {
+				log.Printf("Rebalanced %d keys to other members", moved)
+			}
+		}
+	}
+}
+
+// moveKeys sends the keys owned by member through its import endpoint and
+// deletes the ones it imported. Keys the member already holds are kept on both
+// sides, they were written after the membership change. A key written here
+// since the export is kept too, the next rebalance moves it.
+func (cluster *Cluster) moveKeys(ring *Ring, member string) (int, error) {
+	exported := map[string][]byte{}
+	body := new(bytes.Buffer)
+	_, err := persistence.WriteEntriesMatching(cluster.ctx, body, func(key string, data []byte, entry model.CacheEntry) bool {
+		// Every member hands out its own fencing tokens, a moved lock raises
+		// the token of its new owner when imported
+		if key == model.FencingTokenKey || ring.Owner(key) != member {
+			return false
+		}
+
+		exported[key] = data
+		return true
+	})
+	if err != nil || len(exported) == 0 {
+		return 0, err
+	}
+
+	target := member + "/" + config.BASE_URL_NAME + "/admin/import?format=ndjson&conflict=skip&keys=true"
+	resp, err := cluster.client.Post(target, "application/x-ndjson", body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	imported := struct {
+		Status  string                   `json:"status"`
+		Message string                   `json:"message"`
+		Cache   persistence.ImportResult `json:"cache"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&imported); err != nil {
+		return 0, err
+	}
+
+	if imported.Status != "OK" {
+		return 0, fmt.Errorf("member `%s` rejected the import : %s", member, imported.Message)
+	}
+
+	moved := 0
+	for _, key := range imported.Cache.Keys {
+		data, ok := exported[key]
+		if !ok {
+			continue
+		}
+
+		deleted, err := cluster.ctx.DeleteIfUnchanged(key, data)
+		if err != nil {
+			return moved, err
+		}
+		if deleted {
+			moved++
+		}
+	}
+
+	return moved, nil
+}
+
+// withSelf puts self first and leaves out members that are self under another
+// address, DNS discovery for example lists this node by IP
+func (cluster *Cluster) withSelf(members []string) []string {
+	result := []string{cluster.self}
+	for _, member := range members {
+		member = normalizeMember(member)
+		if member == "" || cluster.isSelf(member) {
+			continue
+		}
+		result = append(result, member)
+	}
+
+	return result
+}
+
+func (cluster *Cluster) isSelf(member string) bool {
+	if member == cluster.self {
+		return true
+	}
+
+	memberURL, err := url.Parse(member)
+	if err != nil || memberURL.Port() != port(cluster.self) {
+		return false
+	}
+
+	for _, address := range resolve(memberURL.Hostname()) {
+		if cluster.selfAddresses[address] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// localAddresses returns the IPs the host of self resolves to
+// and the IPs of the local interfaces
+func localAddresses(self string) map[string]bool {
+	addresses := map[string]bool{}
+	if selfURL, err := url.Parse(self); err == nil {
+		for _, address := range resolve(selfURL.Hostname()) {
+			addresses[address] = true
+		}
+	}
+
+	interfaceAddresses, err := net.InterfaceAddrs()
+	if err != nil {
+		return addresses
+	}
+	for _, address := range interfaceAddresses {
+		if network, ok := address.(*net.IPNet); ok {
+			addresses[network.IP.String()] = true
+		}
+	}
+
+	return addresses
+}
+
+// resolve returns host when it is an IP and the IPs it resolves to otherwise
+func resolve(host string) []string {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{ip.String()}
+	}
+
+	addresses, err := net.LookupHost(host)
+	if err != nil {
+		return nil
+	}
+
+	return addresses
+}
+
+func port(member string) string {
+	memberURL, err := url.Parse(member)
+	if err != nil {
+		return ""
+	}
+
+	return memberURL.Port()
+}
+
+func normalizeMember(member string) string {
+	return strings.TrimRight(strings.TrimSpace(member), "/")
+}
@@ -0,0 +1,283 @@
+package cluster
+
+import (
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/router"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	ctx     *model.CacheAppContext
+	cluster *Cluster
+	url     string
+}
+
+// startNode serves the whole API on a loopback port, the listener is opened
+// first so the node knows the URL the other members reach it on
+func startNode(t *testing.T, members ...string) *node {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	url := "http://" + listener.Addr().String()
+
+	cluster := New(ctx, url, members, 160)
+	ctx.Cluster = cluster
+
+	app := fiber.New()
+	app.Use(middleware.ClusterRoutingMiddleware(ctx))
+	router.HandleRoute(app, ctx)
+	go app.Listener(listener, fiber.ListenConfig{DisableStartupMessage: true})
+
+	t.Cleanup(func() {
+		app.ShutdownWithTimeout(time.Second)
+	})
+	t.Cleanup(cluster.Close)
+
+	return &node{ctx: ctx, cluster: cluster, url: url}
+}
+
+func (node *node) request(t *testing.T, method string, path string, body string) map[string]any {
+	req, _ := http.NewRequest(method, node.url+"/cache-engine-api"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return response
+}
+
+func (node *node) value(t *testing.T, key string) any {
+	response := node.request(t, http.MethodGet, "/get?key="+key, "")
+	cache, ok := response["cache"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return cache["value"]
+}
+
+func (node *node) hasLocal(key string) bool {
+	_, err := node.ctx.GetEntry(key)
+	return err == nil
+}
+
+func startCluster(t *testing.T, size int) []*node {
+	nodes := []*node{}
+	for i := 0; i < size; i++ {
+		nodes = append(nodes, startNode(t))
+	}
+
+	urls := []string{}
+	for _, node := range nodes {
+		urls = append(urls, node.url)
+	}
+	for _, node := range nodes {
+		node.cluster.SetMembers(urls)
+	}
+
+	return nodes
+}
+
+func TestClusterForwarding(t *testing.T) {
+	nodes := startCluster(t, 3)
+
+	for i := 0; i < 30; i++ {
+		key := "key-" + strconv.Itoa(i)
+		writer := nodes[i%len(nodes)]
+
+		response := writer.request(t, http.MethodPost, "/create", `{"key":"`+key+`","value":"`+key+`","duration_in_seconds":60}`)
+		assert.Equal(t, "OK", response["status"], key)
+
+		// Readable from every member, stored only on the owner
+		for _, reader := range nodes {
+			assert.Equal(t, key, reader.value(t, key))
+			assert.Equal(t, reader.url == writer.cluster.Owner(key), reader.hasLocal(key), key)
+		}
+	}
+
+	response := nodes[1].request(t, http.MethodDelete, "/delete/key-0", "")
+	assert.Equal(t, "OK", response["status"])
+	for _, reader := range nodes {
+		assert.Nil(t, reader.value(t, "key-0"))
+	}
+
+	response = nodes[0].request(t, http.MethodGet, "/cluster/owner?key=key-1", "")
+	owner := response["cache"].(map[string]any)["owner"]
+	assert.Equal(t, nodes[0].cluster.Owner("key-1"), owner)
+}
+
+func TestClusterRebalance(t *testing.T) {
+	nodes := startCluster(t, 2)
+
+	for i := 0; i < 200; i++ {
+		key := "key-" + strconv.Itoa(i)
+		nodes[0].request(t, http.MethodPost, "/create", `{"key":"`+key+`","value":"`+key+`","duration_in_seconds":60}`)
+	}
+	lists := []string{}
+	for i := 0; i < 20; i++ {
+		key := "list-" + strconv.Itoa(i)
+		nodes[0].request(t, http.MethodPost, "/list/rpush", `{"key":"`+key+`","values":["a"]}`)
+		lists = append(lists, key)
+	}
+
+	joined := startNode(t)
+	members := []string{nodes[0].url, nodes[1].url, joined.url}
+	nodes = append(nodes, joined)
+	for _, node := range nodes {
+		node.request(t, http.MethodPost, "/cluster/members", `{"members":["`+strings.Join(members, `","`)+`"]}`)
+	}
+
+	assert.Eventually(t, func() bool {
+		for _, node := range nodes {
+			if node.cluster.Info().Rebalancing {
+				return false
+			}
+		}
+
+		keys := lists
+		for i := 0; i < 200; i++ {
+			keys = append(keys, "key-"+strconv.Itoa(i))
+		}
+		for _, key := range keys {
+			for _, node := range nodes {
+				if node.hasLocal(key) != (node.url == joined.cluster.Owner(key)) {
+					return false
+				}
+			}
+		}
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+
+	assert.NotZero(t, joined.ctx.Cache.Len())
+	for _, key := range lists {
+		response := nodes[0].request(t, http.MethodGet, "/list/llen?key="+key, "")
+		assert.Equal(t, float64(1), response["cache"].(map[string]any)["length"], key)
+	}
+	for i := 0; i < 200; i++ {
+		key := "key-" + strconv.Itoa(i)
+		assert.Equal(t, key, nodes[i%len(nodes)].value(t, key))
+	}
+}
+
+func TestClusterRebalanceKeepsSkippedKeys(t *testing.T) {
+	source, target := startNode(t), startNode(t)
+	members := []string{source.url, target.url}
+	ring := NewRing(160, members)
+
+	owned := []string{}
+	for i := 0; len(owned) < 10; i++ {
+		if key := "key-" + strconv.Itoa(i); ring.Owner(key) == target.url {
+			owned = append(owned, key)
+		}
+	}
+
+	// The target already holds the first key, it skips the copy of the source
+	for _, key := range owned {
+		source.request(t, http.MethodPost, "/create", `{"key":"`+key+`","value":"source","duration_in_seconds":60}`)
+	}
+	target.request(t, http.MethodPost, "/create", `{"key":"`+owned[0]+`","value":"target","duration_in_seconds":60}`)
+
+	source.cluster.SetMembers(members)
+
+	assert.Eventually(t, func() bool {
+		for _, key := range owned[1:] {
+			if source.hasLocal(key) {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+
+	for _, key := range owned[1:] {
+		assert.True(t, target.hasLocal(key), key)
+	}
+	assert.True(t, source.hasLocal(owned[0]))
+	entry, err := target.ctx.GetEntry(owned[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "target", entry.Value)
+}
+
+func TestClusterIgnoresSelfUnderAnotherAddress(t *testing.T) {
+	node := startNode(t)
+	port := node.url[strings.LastIndex(node.url, ":"):]
+
+	node.cluster.SetMembers([]string{node.url, "http://localhost" + port, "http://127.0.0.1" + port + "/"})
+	assert.Equal(t, []string{node.url}, node.cluster.Info().Members)
+
+	node.cluster.SetMembers([]string{"http://localhost:1"})
+	assert.Equal(t, []string{node.url, "http://localhost:1"}, node.cluster.Info().Members)
+}
+
+func TestClusterRoutesDataTypes(t *testing.T) {
+	nodes := startCluster(t, 3)
+
+	// A lock is held on its owner whichever member is asked
+	response := nodes[0].request(t, http.MethodPost, "/lock/acquire", `{"key":"job","owner":"first","ttl_in_seconds":60}`)
+	assert.Equal(t, "OK", response["status"])
+	for _, node := range nodes {
+		response := node.request(t, http.MethodPost, "/lock/acquire", `{"key":"job","owner":"second","ttl_in_seconds":60}`)
+		assert.Equal(t, "Lock is held by another owner", response["message"])
+		assert.Equal(t, node.url == nodes[0].cluster.Owner("job"), node.hasLocal("job"))
+	}
+
+	// Commands on several keys are served when one member owns all of them
+	first, second := "", ""
+	for i := 0; second == ""; i++ {
+		key := "set-" + strconv.Itoa(i)
+		switch {
+		case first == "":
+			first = key
+		case nodes[0].cluster.Owner(key) != nodes[0].cluster.Owner(first):
+			second = key
+		}
+	}
+	response = nodes[0].request(t, http.MethodGet, "/set/sinter?keys="+first+","+second, "")
+	assert.Equal(t, "Keys of a command must be owned by the same member", response["message"])
+	response = nodes[1].request(t, http.MethodGet, "/set/sinter?keys="+first+","+first, "")
+	assert.Equal(t, "OK", response["status"])
+}
+
+func TestClusterIgnoresUnsignedForwardedHeader(t *testing.T) {
+	nodes := startCluster(t, 2)
+	for _, node := range nodes {
+		node.ctx.SetAuthKeys([]string{"secret"})
+	}
+
+	key := ""
+	for i := 0; key == ""; i++ {
+		if candidate := "key-" + strconv.Itoa(i); nodes[0].cluster.Owner(candidate) == nodes[1].url {
+			key = candidate
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, nodes[0].url+"/cache-engine-api/create",
+		strings.NewReader(`{"key":"`+key+`","value":"forged","duration_in_seconds":60}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(middleware.ForwardedHeader, nodes[1].url)
+	req.Header.Set(middleware.ForwardedSignatureHeader, "00")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.False(t, nodes[0].hasLocal(key))
+	assert.True(t, nodes[1].hasLocal(key))
+}
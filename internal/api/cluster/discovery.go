@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"log"
+	"net"
+	"time"
+)
+
+// StartDNSDiscovery resolves name every interval and uses every address as a
+// member reachable on scheme://address:port. Meant for headless services
+// where the DNS name returns one record per node.
+func (cluster *Cluster) StartDNSDiscovery(name string, scheme string, port string, interval time.Duration) {
+	discover := func() {
+		addresses, err := net.LookupHost(name)
+		if err != nil {
+			log.Printf("Error when discovering cluster members from `%s` : %v", name, err.Error())
+			return
+		}
+
+		members := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			members = append(members, scheme+"://"+net.JoinHostPort(address, port))
+		}
+		cluster.SetMembers(members)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		discover()
+		for {
+			select {
+			case <-cluster.stop:
+				return
+			case <-ticker.C:
+				discover()
+			}
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// DefaultVirtualNodes is the number of ring points per member when none is configured
+const DefaultVirtualNodes = 160
+
+// Ring is a consistent hash ring. Every member is placed on the ring
+// `virtualNodes` times so keys spread evenly, and adding or removing a
+// member only moves the keys of the ring segments it takes or releases.
+type Ring struct {
+	virtualNodes int
+	hashes       []uint64
+	owners       map[uint64]string
+	members      []string
+}
+
+func NewRing(virtualNodes int, members []string) *Ring {
+	ring := &Ring{
+		virtualNodes: virtualNodes,
+		owners:       make(map[uint64]string),
+	}
+
+	for _, member := range members {
+		if _, exists := ring.indexOf(member); exists {
+			continue
+		}
+		ring.members = append(ring.members, member)
+
+		for i := 0; i < virtualNodes; i++ {
+			hash := hashKey(member + "#" + strconv.Itoa(i))
+			ring.hashes = append(ring.hashes, hash)
+			ring.owners[hash] = member
+		}
+	}
+
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+	sort.Strings(ring.members)
+
+	return ring
+}
+
+// Owner returns the member responsible for key, empty when the ring is empty
+func (ring *Ring) Owner(key string) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+
+	hash := hashKey(key)
+	index := sort.Search(len(ring.hashes), func(i int) bool {
+		return ring.hashes[i] >= hash
+	})
+
+	if index == len(ring.hashes) {
+		index = 0
+	}
+
+	return ring.owners[ring.hashes[index]]
+}
+
+// Members returns members sorted
+func (ring *Ring) Members() []string {
+	return append([]string(nil), ring.members...)
+}
+
+func (ring *Ring) indexOf(member string) (int, bool) {
+	for i, existing := range ring.members {
+		if existing == member {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
+
+// hashKey is FNV-1a followed by a splitmix64 finalizer so that
+// similar member names land far apart on the ring
+func hashKey(key string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+
+	hash := hasher.Sum64()
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return hash
+}
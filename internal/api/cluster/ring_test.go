@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingDistribution(t *testing.T) {
+	members := []string{"http://a", "http://b", "http://c", "http://d"}
+	ring := NewRing(160, members)
+
+	counts := map[string]int{}
+	for i := 0; i < 40000; i++ {
+		counts[ring.Owner("key:"+strconv.Itoa(i))]++
+	}
+
+	assert.Len(t, counts, len(members))
+	for _, member := range members {
+		// Within 20% of a perfect split
+		assert.InDelta(t, 10000, counts[member], 2000, member)
+	}
+}
+
+func TestRingMinimalMovement(t *testing.T) {
+	before := NewRing(160, []string{"http://a", "http://b", "http://c"})
+	after := NewRing(160, []string{"http://a", "http://b", "http://c", "http://d"})
+
+	moved := 0
+	for i := 0; i < 30000; i++ {
+		key := "key:" + strconv.Itoa(i)
+		if before.Owner(key) != after.Owner(key) {
+			// Keys only move to the new member
+			assert.Equal(t, "http://d", after.Owner(key))
+			moved++
+		}
+	}
+
+	// About a quarter of the keys move to the fourth member
+	assert.InDelta(t, 7500, moved, 1500)
+}
+
+func TestRingDeduplicatesMembers(t *testing.T) {
+	ring := NewRing(10, []string{"http://b", "http://a", "http://b"})
+
+	assert.Equal(t, []string{"http://a", "http://b"}, ring.Members())
+	assert.Equal(t, "", NewRing(10, nil).Owner("key"))
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"cache_engine_httpserver/internal/api/eviction"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+// Config holds every setting of the server. Each setting is read from the
+// config file under its `key`, from the environment under its `env` name and
+// from the command line as `--<section>.<key>`, in increasing precedence.
+// Settings tagged `reload` are applied again by Reloader without a restart,
+// `secret` ones are redacted from Effective.
+type Config struct {
+	// File is the config file the settings were loaded from, empty without one
+	File string `key:"-"`
+
+	Port                          string `key:"port" env:"PORT" usage:"address or port to listen on"`
+	DefaultCacheDurationInSeconds int    `key:"default_cache_duration_in_seconds" env:"DEFAULT_CACHE_DURATION_IN_SECONDS" reload:"true" usage:"TTL of entries created without one"`
+	LogLevel                      string `key:"log_level" env:"LOG_LEVEL" reload:"true" usage:"debug, info, warn or error"`
+	LogFormat                     string `key:"log_format" env:"LOG_FORMAT" usage:"text or json"`
+	ShutdownTimeoutInSeconds      int    `key:"shutdown_timeout_in_seconds" env:"SHUTDOWN_TIMEOUT_IN_SECONDS" usage:"time in-flight requests get to finish on SIGINT or SIGTERM"`
+	ShutdownDelayInSeconds        int    `key:"shutdown_delay_in_seconds" env:"SHUTDOWN_DELAY_IN_SECONDS" usage:"time /readyz fails before the server stops accepting requests on SIGINT or SIGTERM"`
+
+	RateLimit    RateLimitConfig    `key:"rate_limit"`
+	Auth         AuthConfig         `key:"auth"`
+	Cache        CacheConfig        `key:"cache"`
+	MaxMemory    MaxMemoryConfig    `key:"max_memory"`
+	SecondTier   SecondTierConfig   `key:"l2"`
+	Snapshot     SnapshotConfig     `key:"snapshot"`
+	AppendOnly   AppendOnlyConfig   `key:"append_only"`
+	Replication  ReplicationConfig  `key:"replication"`
+	Cluster      ClusterConfig      `key:"cluster"`
+	Peers        PeersConfig        `key:"peers"`
+	Invalidation InvalidationConfig `key:"invalidation"`
+	Gossip       GossipConfig       `key:"gossip"`
+	Metrics      MetricsConfig      `key:"metrics"`
+	Tracing      TracingConfig      `key:"tracing"`
+	SlowLog      SlowLogConfig      `key:"slowlog"`
+}
+
+type RateLimitConfig struct {
+	Max             int `key:"max" env:"RATE_LIMIT_MAX" reload:"true" usage:"requests allowed per client in a window"`
+	WindowInSeconds int `key:"window_in_seconds" env:"RATE_LIMIT_WINDOW_IN_SECONDS" reload:"true" usage:"rate limit window"`
+}
+
+type AuthConfig struct {
+	Keys []string `key:"keys" env:"AUTH_KEYS" reload:"true" secret:"true" usage:"keys accepted in the X-Api-Key header, none disables auth"`
+}
+
+// CacheConfig is passed on to BigCache
+type CacheConfig struct {
+	LifeWindowInSeconds  int  `key:"life_window_in_seconds" env:"CACHE_LIFE_WINDOW_IN_SECONDS" usage:"time after which BigCache drops an entry whatever its TTL, 0 uses default_cache_duration_in_seconds"`
+	Shards               int  `key:"shards" env:"CACHE_SHARDS" usage:"number of BigCache shards, a power of two"`
+	CleanWindowInSeconds int  `key:"clean_window_in_seconds" env:"CACHE_CLEAN_WINDOW_IN_SECONDS" usage:"interval between removals of expired entries, 0 disables them"`
+	MaxEntriesInWindow   int  `key:"max_entries_in_window" env:"CACHE_MAX_ENTRIES_IN_WINDOW" usage:"expected entries in the life window, sizes the initial shards"`
+	MaxEntrySizeInBytes  int  `key:"max_entry_size_in_bytes" env:"CACHE_MAX_ENTRY_SIZE_IN_BYTES" usage:"expected entry size, sizes the initial shards"`
+	HardMaxCacheSizeInMB int  `key:"hard_max_cache_size_in_mb" env:"CACHE_HARD_MAX_CACHE_SIZE_IN_MB" usage:"memory BigCache may allocate, 0 means unlimited"`
+	Verbose              bool `key:"verbose" env:"CACHE_VERBOSE" usage:"log BigCache memory allocations"`
+}
+
+type MaxMemoryConfig struct {
+	InMB           int    `key:"in_mb" env:"MAX_MEMORY_IN_MB" usage:"memory ceiling of the entries, 0 means unlimited"`
+	Policy         string `key:"policy" env:"MAX_MEMORY_POLICY" usage:"evict, reject or noeviction"`
+	EvictionPolicy string `key:"eviction_policy" env:"EVICTION_POLICY" usage:"lru, lfu or wtinylfu"`
+}
+
+type SecondTierConfig struct {
+	Dir         string `key:"dir" env:"L2_DIR" usage:"directory keeping the entries BigCache evicts, empty disables it"`
+	MaxSizeInMB int    `key:"max_size_in_mb" env:"L2_MAX_SIZE_IN_MB" usage:"size of the disk tier"`
+}
+
+type SnapshotConfig struct {
+	Enabled           bool   `key:"enabled" env:"SNAPSHOT" usage:"load and write snapshots"`
+	File              string `key:"file" env:"SNAPSHOT_FILE" usage:"snapshot path"`
+	IntervalInSeconds int    `key:"interval_in_seconds" env:"SNAPSHOT_INTERVAL_IN_SECONDS" usage:"interval between snapshots, 0 disables periodic snapshots"`
+}
+
+type AppendOnlyConfig struct {
+	Enabled            bool   `key:"enabled" env:"APPEND_ONLY" usage:"record mutations in an append-only file"`
+	File               string `key:"file" env:"APPEND_ONLY_FILE" usage:"append-only file path"`
+	Fsync              string `key:"fsync" env:"APPEND_FSYNC" usage:"always, everysec or no"`
+	RewriteMinSizeInMB int    `key:"rewrite_min_size_in_mb" env:"APPEND_REWRITE_MIN_SIZE_IN_MB" usage:"size before the file is compacted"`
+}
+
+type ReplicationConfig struct {
+	Role        string `key:"role" env:"REPLICATION_ROLE" usage:"primary or replica, empty disables replication unless replica_of is set"`
+	BacklogSize int    `key:"backlog_size" env:"REPLICATION_BACKLOG_SIZE" usage:"mutations kept for partial resyncs"`
+	ReplicaOf   string `key:"replica_of" env:"REPLICA_OF" usage:"URL of the primary to follow"`
+	ReplicaID   string `key:"replica_id" env:"REPLICA_ID" usage:"name of this replica on the primary"`
+	MaxLag      int    `key:"max_lag" env:"REPLICATION_MAX_LAG" usage:"records a replica may be behind its primary and still be ready"`
+}
+
+type ClusterConfig struct {
+	Self                       string   `key:"self" env:"CLUSTER_SELF" usage:"URL of this member, empty disables cluster mode"`
+	Members                    []string `key:"members" env:"CLUSTER_MEMBERS" usage:"URLs of the members"`
+	VirtualNodes               int      `key:"virtual_nodes" env:"CLUSTER_VIRTUAL_NODES" usage:"virtual nodes per member on the hash ring"`
+	DiscoveryDNS               string   `key:"discovery_dns" env:"CLUSTER_DISCOVERY_DNS" usage:"DNS name resolving to the members"`
+	DiscoveryPort              string   `key:"discovery_port" env:"CLUSTER_DISCOVERY_PORT" usage:"port of the discovered members"`
+	DiscoveryIntervalInSeconds int      `key:"discovery_interval_in_seconds" env:"CLUSTER_DISCOVERY_INTERVAL_IN_SECONDS" usage:"interval between DNS lookups"`
+}
+
+type PeersConfig struct {
+	Self            string   `key:"self" env:"PEER_SELF" usage:"URL of this peer, empty disables peer fill"`
+	Peers           []string `key:"peers" env:"PEERS" usage:"URLs of the peers"`
+	HotTTLInSeconds int      `key:"hot_ttl_in_seconds" env:"PEER_HOT_TTL_IN_SECONDS" usage:"TTL of local copies of remote keys"`
+}
+
+type InvalidationConfig struct {
+	Peers     []string `key:"peers" env:"INVALIDATION_PEERS" usage:"URLs receiving invalidations"`
+	Multicast string   `key:"multicast" env:"INVALIDATION_MULTICAST" usage:"UDP multicast group receiving invalidations"`
+	NodeID    string   `key:"node_id" env:"INVALIDATION_NODE_ID" usage:"origin of the invalidations of this node, defaults to the hostname with a random suffix"`
+	Retries   int      `key:"retries" env:"INVALIDATION_RETRIES" usage:"attempts per peer"`
+}
+
+type GossipConfig struct {
+	Bind      string   `key:"bind" env:"GOSSIP_BIND" usage:"UDP address of the gossip protocol, empty disables gossip"`
+	Advertise string   `key:"advertise" env:"GOSSIP_ADVERTISE" usage:"address other members reach this one at"`
+	Name      string   `key:"name" env:"GOSSIP_NAME" usage:"unique member name"`
+	URL       string   `key:"url" env:"GOSSIP_URL" usage:"HTTP URL of this member, defaults to cluster.self"`
+	Seeds     []string `key:"seeds" env:"GOSSIP_SEEDS" usage:"gossip addresses to join"`
+}
+
+type MetricsConfig struct {
+	Enabled       bool `key:"enabled" env:"METRICS" usage:"export Prometheus metrics on /metrics"`
+	MaxNamespaces int  `key:"max_namespaces" env:"METRICS_MAX_NAMESPACES" usage:"key namespaces with their own label, the following ones are counted as other"`
+}
+
+// TracingConfig only switches tracing on, the exporter reads the standard
+// OTEL_EXPORTER_OTLP_* and OTEL_TRACES_SAMPLER variables
+type TracingConfig struct {
+	Enabled     bool   `key:"enabled" env:"TRACING" usage:"export OpenTelemetry spans over OTLP/HTTP"`
+	ServiceName string `key:"service_name" env:"TRACING_SERVICE_NAME" usage:"service.name of the spans, OTEL_SERVICE_NAME takes precedence"`
+}
+
+type SlowLogConfig struct {
+	ThresholdInMicroseconds int `key:"threshold_in_microseconds" env:"SLOWLOG_THRESHOLD_IN_MICROSECONDS" reload:"true" usage:"requests slower than this are logged, 0 logs every request and a negative value none"`
+	MaxLen                  int `key:"max_len" env:"SLOWLOG_MAX_LEN" usage:"slow requests kept, the oldest ones are dropped"`
+}
+
+// Default returns the settings used when nothing overrides them,
+// BigCache settings are those of bigcache.DefaultConfig
+func Default() *Config {
+	hostname, _ := os.Hostname()
+	cacheConfig := bigcache.DefaultConfig(0)
+
+	return &Config{
+		Port:                          ":3000",
+		DefaultCacheDurationInSeconds: 60,
+		LogLevel:                      "info",
+		LogFormat:                     "text",
+		ShutdownTimeoutInSeconds:      30,
+		RateLimit:                     RateLimitConfig{Max: 1020, WindowInSeconds: 30},
+		Cache: CacheConfig{
+			Shards:               cacheConfig.Shards,
+			CleanWindowInSeconds: int(cacheConfig.CleanWindow / time.Second),
+			MaxEntriesInWindow:   cacheConfig.MaxEntriesInWindow,
+			MaxEntrySizeInBytes:  cacheConfig.MaxEntrySize,
+			HardMaxCacheSizeInMB: cacheConfig.HardMaxCacheSize,
+			Verbose:              cacheConfig.Verbose,
+		},
+		MaxMemory: MaxMemoryConfig{
+			Policy:         model.MaxMemoryEvict,
+			EvictionPolicy: eviction.PolicyLRU,
+		},
+		SecondTier: SecondTierConfig{MaxSizeInMB: 1024},
+		Snapshot:   SnapshotConfig{File: "dump.ndjson.gz", IntervalInSeconds: 300},
+		AppendOnly: AppendOnlyConfig{
+			File:               "appendonly.aof",
+			Fsync:              persistence.FsyncEverySec,
+			RewriteMinSizeInMB: 64,
+		},
+		Replication: ReplicationConfig{BacklogSize: 10000, ReplicaID: hostname, MaxLag: 1000},
+		// VirtualNodes matches cluster.DefaultVirtualNodes, cluster imports this package
+		Cluster: ClusterConfig{
+			VirtualNodes:               160,
+			DiscoveryPort:              "3000",
+			DiscoveryIntervalInSeconds: 10,
+		},
+		Peers:        PeersConfig{HotTTLInSeconds: 10},
+		Invalidation: InvalidationConfig{Retries: 5},
+		Gossip:       GossipConfig{Name: hostname},
+		Metrics:      MetricsConfig{Enabled: true, MaxNamespaces: 100},
+		Tracing:      TracingConfig{ServiceName: "cache-engine"},
+		SlowLog:      SlowLogConfig{ThresholdInMicroseconds: 10000, MaxLen: 128},
+	}
+}
+
+// ListenAddress accepts a bare port like the `PORT=3000` of older .env files
+func (config *Config) ListenAddress() string {
+	if !strings.Contains(config.Port, ":") {
+		return ":" + config.Port
+	}
+
+	return config.Port
+}
+
+// DefaultExpiration is the TTL of entries created without one
+func (config *Config) DefaultExpiration() time.Duration {
+	return time.Duration(config.DefaultCacheDurationInSeconds) * time.Second
+}
+
+// ShutdownTimeout is how long in-flight requests get to finish on shutdown
+func (config *Config) ShutdownTimeout() time.Duration {
+	return time.Duration(config.ShutdownTimeoutInSeconds) * time.Second
+}
+
+// ShutdownDelay is how long /readyz fails before requests are drained
+func (config *Config) ShutdownDelay() time.Duration {
+	return time.Duration(config.ShutdownDelayInSeconds) * time.Second
+}
+
+// SlowLogThreshold is negative when nothing is logged
+func (config *Config) SlowLogThreshold() time.Duration {
+	return time.Duration(config.SlowLog.ThresholdInMicroseconds) * time.Microsecond
+}
+
+// BigCache returns the BigCache config, callbacks are left to the caller
+func (config *Config) BigCache() bigcache.Config {
+	cacheConfig := bigcache.DefaultConfig(time.Duration(config.Cache.LifeWindowInSeconds) * time.Second)
+	cacheConfig.Shards = config.Cache.Shards
+	cacheConfig.CleanWindow = time.Duration(config.Cache.CleanWindowInSeconds) * time.Second
+	cacheConfig.MaxEntriesInWindow = config.Cache.MaxEntriesInWindow
+	cacheConfig.MaxEntrySize = config.Cache.MaxEntrySizeInBytes
+	cacheConfig.HardMaxCacheSize = config.Cache.HardMaxCacheSizeInMB
+	cacheConfig.Verbose = config.Cache.Verbose
+
+	return cacheConfig
+}
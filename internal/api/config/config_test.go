@@ -0,0 +1,128 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestDefaults(t *testing.T) {
+	config, err := Load(nil)
+	assert.NoError(t, err)
+
+	cacheConfig := config.BigCache()
+	assert.Equal(t, 1024, cacheConfig.Shards)
+	assert.Equal(t, time.Second, cacheConfig.CleanWindow)
+	assert.Equal(t, 0, cacheConfig.HardMaxCacheSize)
+	assert.Equal(t, time.Minute, cacheConfig.LifeWindow)
+	assert.Equal(t, ":3000", config.ListenAddress())
+}
+
+func TestPrecedence(t *testing.T) {
+	for _, file := range []struct{ name, content string }{
+		{"config.yaml", `
+port: ":4000"
+cache:
+  shards: 64
+  max_entry_size_in_bytes: 1024
+  verbose: false
+cluster:
+  members: ["http://a:3000", "http://b:3000"]
+snapshot:
+  enabled: true
+`},
+		{"config.toml", `
+port = ":4000"
+
+[cache]
+shards = 64
+max_entry_size_in_bytes = 1024
+verbose = false
+
+[cluster]
+members = ["http://a:3000", "http://b:3000"]
+
+[snapshot]
+enabled = true
+`},
+	} {
+		t.Run(file.name, func(t *testing.T) {
+			path := writeFile(t, file.name, file.content)
+			t.Setenv(FileEnv, path)
+			t.Setenv("CACHE_SHARDS", "128")
+			t.Setenv("CACHE_HARD_MAX_CACHE_SIZE_IN_MB", "256")
+
+			config, err := Load([]string{"--cache.shards", "256", "--snapshot.interval_in_seconds=10"})
+			assert.NoError(t, err)
+
+			assert.Equal(t, path, config.Source())
+			// File only
+			assert.Equal(t, ":4000", config.Port)
+			assert.Equal(t, 1024, config.Cache.MaxEntrySizeInBytes)
+			assert.False(t, config.Cache.Verbose)
+			assert.True(t, config.Snapshot.Enabled)
+			assert.Equal(t, []string{"http://a:3000", "http://b:3000"}, config.Cluster.Members)
+			// Env over the default
+			assert.Equal(t, 256, config.Cache.HardMaxCacheSizeInMB)
+			// Flags over the env and the file
+			assert.Equal(t, 256, config.Cache.Shards)
+			assert.Equal(t, 10, config.Snapshot.IntervalInSeconds)
+
+			settings := config.Effective()["cache"].(map[string]any)
+			assert.Equal(t, 256, settings["shards"])
+		})
+	}
+}
+
+func TestListsFromEnv(t *testing.T) {
+	t.Setenv("PEERS", "http://a:3000, http://b:3000,")
+
+	config, err := Load(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://a:3000", "http://b:3000"}, config.Peers.Peers)
+}
+
+func TestInvalidSettings(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+cache:
+  shards: 100
+  shard: 64
+max_memory:
+  policy: allkeys-lru
+`)
+	t.Setenv("DEFAULT_CACHE_DURATION_IN_SECONDS", "ten")
+
+	_, err := Load([]string{"--config", path})
+	assert.EqualError(t, err, "Key `cache.shard` in `"+path+"` is not a setting\n"+
+		"Env `DEFAULT_CACHE_DURATION_IN_SECONDS` should be an integer, got `ten`")
+
+	// Values are only validated once every one of them could be parsed
+	t.Setenv("DEFAULT_CACHE_DURATION_IN_SECONDS", "0")
+	path = writeFile(t, "config.yaml", `
+cache:
+  shards: 100
+max_memory:
+  policy: allkeys-lru
+`)
+	_, err = Load([]string{"--config", path, "--append_only.fsync", "sometimes"})
+	assert.EqualError(t, err, "Value `default_cache_duration_in_seconds` (`DEFAULT_CACHE_DURATION_IN_SECONDS`) should be greater than 0\n"+
+		"Value `cache.shards` (`CACHE_SHARDS`) should be a power of two\n"+
+		"Value `max_memory.policy` (`MAX_MEMORY_POLICY`) should be one of `evict`, `reject` or `noeviction`\n"+
+		"Value `append_only.fsync` (`APPEND_FSYNC`) should be one of `always`, `everysec` or `no`")
+}
+
+func TestUnknownFileExtension(t *testing.T) {
+	path := writeFile(t, "config.json", `{}`)
+
+	_, err := Load([]string{"--config", path})
+	assert.EqualError(t, err, "config file `"+path+"` : extension should be .yaml, .yml or .toml")
+}
@@ -2,4 +2,16 @@ package config
 
 const (
 	BASE_URL_NAME string = "cache-engine-api"
+
+	// MAX_BLOCKING_TIMEOUT_IN_SECONDS caps how long a blocking command may long-poll
+	MAX_BLOCKING_TIMEOUT_IN_SECONDS = 60
+
+	// MAX_PSYNC_WAIT_IN_MILLISECONDS caps how long a partial sync may long-poll
+	MAX_PSYNC_WAIT_IN_MILLISECONDS = 30000
+)
+
+// Defaults used when a bloom filter is created implicitly by `add`
+const (
+	DEFAULT_BLOOM_ERROR_RATE float64 = 0.01
+	DEFAULT_BLOOM_CAPACITY   int     = 1000
 )
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv and FileFlag name the config file, the flag wins over the env
+const (
+	FileEnv  = "CONFIG_FILE"
+	FileFlag = "config"
+)
+
+// setting is a single leaf of Config
+type setting struct {
+	key    string
+	env    string
+	usage  string
+	reload bool
+	secret bool
+	value  reflect.Value
+}
+
+// settings walks config and returns every setting in declaration order
+func settings(config *Config) []setting {
+	return walk(reflect.ValueOf(config).Elem(), "")
+}
+
+func walk(value reflect.Value, prefix string) []setting {
+	found := []setting{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := field.Tag.Get("key")
+		if key == "-" {
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			found = append(found, walk(value.Field(i), prefix+key+".")...)
+			continue
+		}
+
+		found = append(found, setting{
+			key:    prefix + key,
+			env:    field.Tag.Get("env"),
+			usage:  field.Tag.Get("usage"),
+			reload: field.Tag.Get("reload") == "true",
+			secret: field.Tag.Get("secret") == "true",
+			value:  value.Field(i),
+		})
+	}
+
+	return found
+}
+
+// set parses raw into the setting according to its type
+func (setting setting) set(raw string) error {
+	switch setting.value.Kind() {
+	case reflect.String:
+		setting.value.SetString(raw)
+	case reflect.Int:
+		number, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("should be an integer, got `%s`", raw)
+		}
+		setting.value.SetInt(int64(number))
+	case reflect.Bool:
+		enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("should be true or false, got `%s`", raw)
+		}
+		setting.value.SetBool(enabled)
+	case reflect.Slice:
+		values := []string{}
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		setting.value.Set(reflect.ValueOf(values))
+	}
+
+	return nil
+}
+
+// Load builds the config from the defaults, the config file, the environment
+// and args, each overriding the previous ones, then validates it.
+// The returned error lists every invalid setting.
+func Load(args []string) (*Config, error) {
+	config := Default()
+	settings := settings(config)
+
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	file := flags.String(FileFlag, os.Getenv(FileEnv), "YAML or TOML config `file`, env "+FileEnv)
+	flagValues := map[string]string{}
+	for _, setting := range settings {
+		flags.Func(setting.key, setting.usage+", env "+setting.env, func(value string) error {
+			flagValues[setting.key] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			flags.SetOutput(os.Stderr)
+			flags.PrintDefaults()
+		}
+		return nil, err
+	}
+
+	problems := []string{}
+	if *file != "" {
+		values, err := readFile(*file)
+		if err != nil {
+			return nil, fmt.Errorf("config file `%s` : %w", *file, err)
+		}
+
+		known := map[string]bool{}
+		for _, setting := range settings {
+			known[setting.key] = true
+			if raw, ok := values[setting.key]; ok {
+				if err := setting.set(raw); err != nil {
+					problems = append(problems, fmt.Sprintf("Value `%s` in `%s` %s", setting.key, *file, err))
+				}
+			}
+		}
+
+		unknown := []string{}
+		for key := range values {
+			if !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			problems = append(problems, fmt.Sprintf("Key `%s` in `%s` is not a setting", key, *file))
+		}
+		config.File = *file
+	}
+
+	for _, setting := range settings {
+		if raw := os.Getenv(setting.env); raw != "" {
+			if err := setting.set(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("Env `%s` %s", setting.env, err))
+			}
+		}
+	}
+
+	for _, setting := range settings {
+		if raw, ok := flagValues[setting.key]; ok {
+			if err := setting.set(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("Flag `--%s` %s", setting.key, err))
+			}
+		}
+	}
+
+	// Resolved here so a reload that only changes the default TTL reports the
+	// life window, which BigCache cannot change, as requiring a restart
+	if config.Cache.LifeWindowInSeconds == 0 {
+		config.Cache.LifeWindowInSeconds = config.DefaultCacheDurationInSeconds
+	}
+
+	if len(problems) == 0 {
+		problems = config.validate()
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+
+	return config, nil
+}
+
+// readFile decodes a YAML or TOML file, by extension, into settings keyed
+// by their dotted key with values formatted the way setting.set parses them
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	document := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &document)
+	case ".toml":
+		err = toml.Unmarshal(data, &document)
+	default:
+		return nil, errors.New("extension should be .yaml, .yml or .toml")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	flatten(document, "", values)
+	return values, nil
+}
+
+func flatten(document map[string]any, prefix string, values map[string]string) {
+	for key, value := range document {
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(value, prefix+key+".", values)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[prefix+key] = strings.Join(items, ",")
+		case nil:
+			values[prefix+key] = ""
+		default:
+			values[prefix+key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// Effective returns every setting nested by section, as loaded
+func (config *Config) Effective() map[string]any {
+	effective := map[string]any{}
+	for _, setting := range settings(config) {
+		section := effective
+		path := strings.Split(setting.key, ".")
+		for _, key := range path[:len(path)-1] {
+			if _, ok := section[key]; !ok {
+				section[key] = map[string]any{}
+			}
+			section = section[key].(map[string]any)
+		}
+		section[path[len(path)-1]] = setting.effective()
+	}
+
+	return effective
+}
+
+// effective returns the value of the setting, or how many values a secret has
+func (setting setting) effective() any {
+	if !setting.secret {
+		return setting.value.Interface()
+	}
+
+	if setting.value.Kind() == reflect.Slice {
+		return fmt.Sprintf("<%d redacted>", setting.value.Len())
+	}
+	return "<redacted>"
+}
+
+// Source returns the config file the settings were loaded from
+func (config *Config) Source() string {
+	return config.File
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Reloader holds the config the server runs with and loads it again on
+// demand. Only the settings tagged `reload` are applied, the others are
+// reported as needing a restart.
+type Reloader struct {
+	args  []string
+	apply func(config *Config)
+
+	// mu serializes reloads so apply sees them in order
+	mu      sync.Mutex
+	current atomic.Pointer[Config]
+}
+
+// NewReloader wraps the config loaded from args, apply is called with every
+// reloaded config and must switch all the reloadable settings it uses
+func NewReloader(config *Config, args []string, apply func(config *Config)) *Reloader {
+	reloader := &Reloader{args: args, apply: apply}
+	reloader.current.Store(config)
+	return reloader
+}
+
+// Current returns the config the server runs with
+func (reloader *Reloader) Current() *Config {
+	return reloader.current.Load()
+}
+
+func (reloader *Reloader) Source() string {
+	return reloader.Current().Source()
+}
+
+func (reloader *Reloader) Effective() map[string]any {
+	return reloader.Current().Effective()
+}
+
+// Reload loads the config file, environment and flags again. Nothing is
+// applied when any setting is invalid.
+func (reloader *Reloader) Reload() (model.ReloadResult, error) {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	loaded, err := Load(reloader.args)
+	if err != nil {
+		return model.ReloadResult{}, err
+	}
+
+	current := reloader.Current()
+	next := *current
+	result := model.ReloadResult{Applied: []string{}, RequiresRestart: []string{}}
+
+	nextSettings := settings(&next)
+	for i, setting := range settings(loaded) {
+		if reflect.DeepEqual(setting.value.Interface(), nextSettings[i].value.Interface()) {
+			continue
+		}
+
+		if !setting.reload {
+			result.RequiresRestart = append(result.RequiresRestart, setting.key)
+			continue
+		}
+
+		nextSettings[i].value.Set(setting.value)
+		result.Applied = append(result.Applied, setting.key)
+	}
+
+	if len(result.Applied) > 0 {
+		reloader.apply(&next)
+		reloader.current.Store(&next)
+	}
+
+	return result, nil
+}
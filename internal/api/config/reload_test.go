@@ -0,0 +1,94 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+log_level: info
+rate_limit:
+  max: 100
+auth:
+  keys: [first]
+cache:
+  shards: 64
+`)
+	args := []string{"--config", path}
+
+	config, err := Load(args)
+	assert.NoError(t, err)
+
+	applied := []*Config{}
+	reloader := NewReloader(config, args, func(config *Config) {
+		applied = append(applied, config)
+	})
+
+	// Unchanged settings are neither applied nor reported
+	result, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.RequiresRestart)
+	assert.Empty(t, applied)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`
+log_level: debug
+rate_limit:
+  max: 200
+auth:
+  keys: [first, second]
+cache:
+  shards: 128
+`), 0o644))
+
+	result, err = reloader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"log_level", "rate_limit.max", "auth.keys"}, result.Applied)
+	assert.Equal(t, []string{"cache.shards"}, result.RequiresRestart)
+
+	assert.Len(t, applied, 1)
+	assert.Same(t, reloader.Current(), applied[0])
+	assert.Equal(t, "debug", reloader.Current().LogLevel)
+	assert.Equal(t, 200, reloader.Current().RateLimit.Max)
+	assert.Equal(t, []string{"first", "second"}, reloader.Current().Auth.Keys)
+	// The running value is kept until a restart
+	assert.Equal(t, 64, reloader.Current().Cache.Shards)
+	assert.Equal(t, "<2 redacted>", reloader.Effective()["auth"].(map[string]any)["keys"])
+
+	// Nothing is applied from an invalid file
+	assert.NoError(t, os.WriteFile(path, []byte(`
+log_level: verbose
+rate_limit:
+  max: 300
+`), 0o644))
+
+	_, err = reloader.Reload()
+	assert.EqualError(t, err, "Value `log_level` (`LOG_LEVEL`) should be one of `debug`, `info`, `warn` or `error`")
+	assert.Len(t, applied, 1)
+	assert.Equal(t, 200, reloader.Current().RateLimit.Max)
+}
+
+func TestReloadDefaultDuration(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+default_cache_duration_in_seconds: 60
+`)
+	args := []string{"--config", path}
+
+	config, err := Load(args)
+	assert.NoError(t, err)
+	reloader := NewReloader(config, args, func(config *Config) {})
+
+	// New entries get the new TTL, BigCache keeps its life window until a restart
+	assert.NoError(t, os.WriteFile(path, []byte(`
+default_cache_duration_in_seconds: 120
+`), 0o644))
+
+	result, err := reloader.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default_cache_duration_in_seconds"}, result.Applied)
+	assert.Equal(t, []string{"cache.life_window_in_seconds"}, result.RequiresRestart)
+	assert.Equal(t, 60, reloader.Current().Cache.LifeWindowInSeconds)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"cache_engine_httpserver/internal/api/eviction"
+	"cache_engine_httpserver/internal/api/logging"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"fmt"
+	"slices"
+)
+
+// validate returns a message for every setting with an unusable value
+func (config *Config) validate() []string {
+	envs := map[string]string{}
+	for _, setting := range settings(config) {
+		envs[setting.key] = setting.env
+	}
+
+	problems := []string{}
+	check := func(ok bool, key string, message string) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf("Value `%s` (`%s`) %s", key, envs[key], message))
+		}
+	}
+
+	check(config.Port != "", "port", "cannot be empty")
+	check(config.DefaultCacheDurationInSeconds > 0, "default_cache_duration_in_seconds", "should be greater than 0")
+	_, err := logging.ParseLevel(config.LogLevel)
+	check(err == nil, "log_level", "should be one of `debug`, `info`, `warn` or `error`")
+	check(logging.ValidFormat(config.LogFormat), "log_format", "should be `text` or `json`")
+	check(config.ShutdownTimeoutInSeconds > 0, "shutdown_timeout_in_seconds", "should be greater than 0")
+	check(config.ShutdownDelayInSeconds >= 0, "shutdown_delay_in_seconds", "should be 0 or greater")
+
+	check(config.RateLimit.Max > 0, "rate_limit.max", "should be greater than 0")
+	check(config.RateLimit.WindowInSeconds > 0, "rate_limit.window_in_seconds", "should be greater than 0")
+
+	shards := config.Cache.Shards
+	check(shards > 0 && shards&(shards-1) == 0, "cache.shards", "should be a power of two")
+	check(config.Cache.LifeWindowInSeconds >= 0, "cache.life_window_in_seconds", "should be 0 or greater")
+	check(config.Cache.CleanWindowInSeconds >= 0, "cache.clean_window_in_seconds", "should be 0 or greater")
+	check(config.Cache.MaxEntriesInWindow > 0, "cache.max_entries_in_window", "should be greater than 0")
+	check(config.Cache.MaxEntrySizeInBytes > 0, "cache.max_entry_size_in_bytes", "should be greater than 0")
+	check(config.Cache.HardMaxCacheSizeInMB >= 0, "cache.hard_max_cache_size_in_mb", "should be 0 or greater")
+
+	check(config.MaxMemory.InMB >= 0, "max_memory.in_mb", "should be 0 or greater")
+	check(slices.Contains([]string{model.MaxMemoryEvict, model.MaxMemoryReject, model.MaxMemoryNoEviction}, config.MaxMemory.Policy),
+		"max_memory.policy", "should be one of `evict`, `reject` or `noeviction`")
+	check(slices.Contains([]string{eviction.PolicyLRU, eviction.PolicyLFU, eviction.PolicyWTinyLFU}, config.MaxMemory.EvictionPolicy),
+		"max_memory.eviction_policy", "should be one of `lru`, `lfu` or `wtinylfu`")
+
+	check(config.SecondTier.MaxSizeInMB >= 0, "l2.max_size_in_mb", "should be 0 or greater")
+
+	check(!config.Snapshot.Enabled || config.Snapshot.File != "", "snapshot.file", "cannot be empty when snapshots are enabled")
+	check(config.Snapshot.IntervalInSeconds >= 0, "snapshot.interval_in_seconds", "should be 0 or greater")
+
+	check(!config.AppendOnly.Enabled || config.AppendOnly.File != "", "append_only.file", "cannot be empty when the append-only file is enabled")
+	check(slices.Contains([]string{persistence.FsyncAlways, persistence.FsyncEverySec, persistence.FsyncNo}, config.AppendOnly.Fsync),
+		"append_only.fsync", "should be one of `always`, `everysec` or `no`")
+	check(config.AppendOnly.RewriteMinSizeInMB >= 0, "append_only.rewrite_min_size_in_mb", "should be 0 or greater")
+
+	check(slices.Contains([]string{"", model.RolePrimary, model.RoleReplica}, config.Replication.Role),
+		"replication.role", "should be empty, `primary` or `replica`")
+	check(config.Replication.Role != model.RoleReplica || config.Replication.ReplicaOf != "", "replication.replica_of", "cannot be empty for a replica")
+	check(config.Replication.Role != model.RolePrimary || config.Replication.ReplicaOf == "", "replication.replica_of", "should be empty for a primary")
+	check(config.Replication.BacklogSize > 0, "replication.backlog_size", "should be greater than 0")
+	check(config.Replication.MaxLag >= 0, "replication.max_lag", "should be 0 or greater")
+
+	check(config.Cluster.VirtualNodes > 0, "cluster.virtual_nodes", "should be greater than 0")
+	check(config.Cluster.DiscoveryIntervalInSeconds > 0, "cluster.discovery_interval_in_seconds", "should be greater than 0")
+
+	check(config.Peers.HotTTLInSeconds > 0, "peers.hot_ttl_in_seconds", "should be greater than 0")
+
+	check(config.Invalidation.Retries >= 0, "invalidation.retries", "should be 0 or greater")
+
+	check(config.Metrics.MaxNamespaces >= 0, "metrics.max_namespaces", "should be 0 or greater")
+
+	check(!config.Tracing.Enabled || config.Tracing.ServiceName != "", "tracing.service_name", "should be set when tracing is enabled")
+
+	check(config.SlowLog.MaxLen > 0, "slowlog.max_len", "should be greater than 0")
+
+	return problems
+}
@@ -0,0 +1,5 @@
+package config
+
+// Version is set when building a release with
+// go build -ldflags "-X cache_engine_httpserver/internal/api/config.Version=1.2.0"
+var Version = "dev"
@@ -0,0 +1,157 @@
+package eviction
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	PolicyLRU      = "lru"
+	PolicyLFU      = "lfu"
+	PolicyWTinyLFU = "wtinylfu"
+)
+
+// ErrUnknownPolicy is returned by New for a policy name it does not know
+var ErrUnknownPolicy = errors.New("eviction policy must be one of `lru`, `lfu` or `wtinylfu`")
+
+// ErrUnknownMaxMemoryPolicy is returned by New for a max memory policy it does not know
+var ErrUnknownMaxMemoryPolicy = errors.New("max memory policy must be one of `evict`, `reject` or `noeviction`")
+
+// ErrInvalidMaxBytes is returned by New for a budget that is not positive
+var ErrInvalidMaxBytes = errors.New("max memory must be positive")
+
+// policy keeps track of the resident keys and their size.
+// Implementations are not safe for concurrent use.
+type policy interface {
+	// touch records a read of key and reports whether it is resident
+	touch(key string) bool
+	// admit records key being stored and returns the keys to evict
+	admit(key string, size int) []string
+	forget(key string)
+	// size returns the size of key, 0 when it is not resident
+	size(key string) int
+	len() int
+	bytes() int64
+}
+
+// Manager implements model.EvictionPolicy by serializing a policy
+type Manager struct {
+	maxMemoryPolicy string
+	name            string
+	maxBytes        int64
+
+	mu        sync.Mutex
+	policy    policy
+	evictions atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+// New creates the policy called name keeping entries under maxBytes.
+// Only the `evict` max memory policy evicts, the others merely account
+// the entries with an unbounded policy.
+func New(maxMemoryPolicy string, name string, maxBytes int64) (*Manager, error) {
+	if maxBytes <= 0 {
+		return nil, ErrInvalidMaxBytes
+	}
+
+	manager := &Manager{maxMemoryPolicy: maxMemoryPolicy, name: name, maxBytes: maxBytes}
+	switch maxMemoryPolicy {
+	case model.MaxMemoryEvict:
+	case model.MaxMemoryReject, model.MaxMemoryNoEviction:
+		manager.name = ""
+	default:
+		return nil, ErrUnknownMaxMemoryPolicy
+	}
+
+	policy, err := manager.newPolicy()
+	if err != nil {
+		return nil, err
+	}
+	manager.policy = policy
+
+	return manager, nil
+}
+
+func (manager *Manager) newPolicy() (policy, error) {
+	if manager.maxMemoryPolicy != model.MaxMemoryEvict {
+		return newLRU(math.MaxInt64), nil
+	}
+
+	switch manager.name {
+	case PolicyLRU:
+		return newLRU(manager.maxBytes), nil
+	case PolicyLFU:
+		return newLFU(manager.maxBytes), nil
+	case PolicyWTinyLFU:
+		return newWTinyLFU(manager.maxBytes), nil
+	}
+
+	return nil, ErrUnknownPolicy
+}
+
+func (manager *Manager) Info() model.EvictionInfo {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return model.EvictionInfo{
+		MaxMemoryPolicy: manager.maxMemoryPolicy,
+		Policy:          manager.name,
+		MaxBytes:        manager.maxBytes,
+		UsedBytes:       manager.policy.bytes(),
+		Entries:         manager.policy.len(),
+		Evictions:       manager.evictions.Load(),
+		Rejected:        manager.rejected.Load(),
+	}
+}
+
+func (manager *Manager) Reserve(key string, size int) error {
+	if manager.maxMemoryPolicy != model.MaxMemoryReject {
+		return nil
+	}
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	// Overwriting key frees the bytes of its current entry
+	if manager.policy.bytes()-int64(manager.policy.size(key))+int64(size) > manager.maxBytes {
+		manager.rejected.Add(1)
+		return model.ErrOutOfMemory
+	}
+
+	return nil
+}
+
+func (manager *Manager) Touch(key string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	manager.policy.touch(key)
+}
+
+func (manager *Manager) Admit(key string, size int) []string {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	// Keys may come straight from Fiber's reused buffers
+	victims := manager.policy.admit(strings.Clone(key), size)
+	manager.evictions.Add(uint64(len(victims)))
+	return victims
+}
+
+func (manager *Manager) Forget(key string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	manager.policy.forget(key)
+}
+
+func (manager *Manager) Reset() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	manager.policy, _ = manager.newPolicy()
+}
@@ -0,0 +1,151 @@
+package eviction
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	simulatedKeys     = 100_000
+	simulatedRequests = 500_000
+	simulatedMaxBytes = 1000 * 512
+)
+
+// simulate replays keys as a read-through cache: a miss stores the key
+// with a size between 256 and 768 bytes, and returns the hit ratio
+func simulate(t *testing.T, name string, keys []uint64) float64 {
+	manager, err := New(model.MaxMemoryEvict, name, simulatedMaxBytes)
+	assert.NoError(t, err)
+
+	sizes := rand.New(rand.NewSource(2))
+	resident := map[string]bool{}
+	hits := 0
+	for _, k := range keys {
+		key := "key-" + strconv.FormatUint(k, 10)
+		manager.Touch(key)
+		if resident[key] {
+			hits++
+			continue
+		}
+
+		resident[key] = true
+		for _, victim := range manager.Admit(key, 256+sizes.Intn(512)) {
+			delete(resident, victim)
+		}
+	}
+
+	info := manager.Info()
+	assert.LessOrEqual(t, info.UsedBytes, int64(simulatedMaxBytes), name)
+	assert.Equal(t, len(resident), info.Entries, name)
+
+	return float64(hits) / float64(len(keys))
+}
+
+func zipfKeys(seed int64, count int) []uint64 {
+	random := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(random, 1.01, 1, simulatedKeys-1)
+
+	keys := make([]uint64, count)
+	for i := range keys {
+		keys[i] = zipf.Uint64()
+	}
+	return keys
+}
+
+func TestZipfHitRatios(t *testing.T) {
+	keys := zipfKeys(1, simulatedRequests)
+
+	ratios := map[string]float64{}
+	for _, name := range []string{PolicyLRU, PolicyLFU, PolicyWTinyLFU} {
+		ratios[name] = simulate(t, name, keys)
+		t.Logf("%-8s hit ratio %.2f%%", name, ratios[name]*100)
+	}
+
+	assert.Greater(t, ratios[PolicyLFU], ratios[PolicyLRU])
+	assert.Greater(t, ratios[PolicyWTinyLFU], ratios[PolicyLRU])
+}
+
+// A scan of keys read once must not flush the popular keys out
+func TestScanResistance(t *testing.T) {
+	keys := zipfKeys(3, simulatedRequests)
+	for i := range keys {
+		if i%2 == 0 {
+			keys[i] = simulatedKeys + uint64(i)
+		}
+	}
+
+	ratios := map[string]float64{}
+	for _, name := range []string{PolicyLRU, PolicyWTinyLFU} {
+		ratios[name] = simulate(t, name, keys)
+		t.Logf("%-8s hit ratio %.2f%% with scans", name, ratios[name]*100)
+	}
+
+	assert.Greater(t, ratios[PolicyWTinyLFU], ratios[PolicyLRU])
+}
+
+func TestUnknownPolicy(t *testing.T) {
+	_, err := New(model.MaxMemoryEvict, "fifo", 1024)
+	assert.Equal(t, ErrUnknownPolicy, err)
+
+	_, err = New("allkeys-lru", PolicyLRU, 1024)
+	assert.Equal(t, ErrUnknownMaxMemoryPolicy, err)
+
+	_, err = New(model.MaxMemoryEvict, PolicyLRU, 0)
+	assert.Equal(t, ErrInvalidMaxBytes, err)
+}
+
+type recorder struct {
+	mutations []model.Mutation
+}
+
+func (recorder *recorder) OnMutation(mutation model.Mutation) {
+	recorder.mutations = append(recorder.mutations, mutation)
+}
+
+func TestCacheStaysUnderBudget(t *testing.T) {
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(time.Hour))
+	assert.NoError(t, err)
+
+	manager, err := New(model.MaxMemoryEvict, PolicyLRU, 4*1024)
+	assert.NoError(t, err)
+
+	ctx := &model.CacheAppContext{Cache: cache, Eviction: manager}
+	recorder := &recorder{}
+	ctx.AddObserver(recorder)
+
+	for i := 0; i < 100; i++ {
+		key := "key-" + strconv.Itoa(i)
+		assert.NoError(t, ctx.SetEntry(key, model.CacheEntry{Value: key, Expiration: time.Now().Add(time.Hour)}))
+		// Reading key-0 keeps it the most recently used
+		_, err := ctx.GetEntry("key-0")
+		assert.NoError(t, err)
+	}
+
+	info := manager.Info()
+	assert.LessOrEqual(t, info.UsedBytes, int64(4*1024))
+	assert.Equal(t, info.Entries, cache.Len())
+	assert.NotZero(t, info.Evictions)
+
+	_, err = ctx.GetEntry("key-1")
+	assert.Equal(t, bigcache.ErrEntryNotFound, err)
+	_, err = ctx.GetEntry("key-99")
+	assert.NoError(t, err)
+
+	evicted := 0
+	for _, mutation := range recorder.mutations {
+		if mutation.Op == model.MutationEvict {
+			evicted++
+		}
+	}
+	assert.Equal(t, int(info.Evictions), evicted)
+
+	assert.NoError(t, ctx.DeleteEntry("key-99"))
+	assert.Equal(t, info.Entries-1, manager.Info().Entries)
+}
@@ -0,0 +1,122 @@
+package eviction
+
+import "container/heap"
+
+type lfuItem struct {
+	key       string
+	size      int
+	frequency uint64
+	// seq breaks frequency ties in favour of the most recently used
+	seq   uint64
+	index int
+}
+
+// lfuHeap is a min-heap of the least frequently used items
+type lfuHeap []*lfuItem
+
+func (h lfuHeap) Len() int {
+	return len(h)
+}
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].frequency != h[j].frequency {
+		return h[i].frequency < h[j].frequency
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	item := x.(*lfuItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// lfu evicts the least frequently used keys. Frequencies are only kept for
+// resident keys, a key coming back after being evicted starts over.
+type lfu struct {
+	maxBytes  int64
+	usedBytes int64
+	seq       uint64
+	heap      lfuHeap
+	items     map[string]*lfuItem
+}
+
+func newLFU(maxBytes int64) *lfu {
+	return &lfu{maxBytes: maxBytes, items: make(map[string]*lfuItem)}
+}
+
+func (lfu *lfu) touch(key string) bool {
+	item, ok := lfu.items[key]
+	if !ok {
+		return false
+	}
+
+	lfu.seq++
+	item.frequency++
+	item.seq = lfu.seq
+	heap.Fix(&lfu.heap, item.index)
+	return true
+}
+
+func (lfu *lfu) admit(key string, size int) []string {
+	if item, ok := lfu.items[key]; ok {
+		lfu.usedBytes += int64(size - item.size)
+		item.size = size
+		lfu.touch(key)
+	} else {
+		lfu.seq++
+		item := &lfuItem{key: key, size: size, frequency: 1, seq: lfu.seq}
+		heap.Push(&lfu.heap, item)
+		lfu.items[key] = item
+		lfu.usedBytes += int64(size)
+	}
+
+	victims := []string{}
+	for lfu.usedBytes > lfu.maxBytes {
+		victim := heap.Pop(&lfu.heap).(*lfuItem)
+		delete(lfu.items, victim.key)
+		lfu.usedBytes -= int64(victim.size)
+		victims = append(victims, victim.key)
+	}
+
+	return victims
+}
+
+func (lfu *lfu) forget(key string) {
+	item, ok := lfu.items[key]
+	if !ok {
+		return
+	}
+
+	heap.Remove(&lfu.heap, item.index)
+	delete(lfu.items, key)
+	lfu.usedBytes -= int64(item.size)
+}
+
+func (lfu *lfu) size(key string) int {
+	if item, ok := lfu.items[key]; ok {
+		return item.size
+	}
+	return 0
+}
+
+func (lfu *lfu) len() int {
+	return len(lfu.items)
+}
+
+func (lfu *lfu) bytes() int64 {
+	return lfu.usedBytes
+}
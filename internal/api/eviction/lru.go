@@ -0,0 +1,123 @@
+package eviction
+
+import "container/list"
+
+type lruItem struct {
+	key  string
+	size int
+}
+
+// lruList is a byte-bounded recency list, the front is the most recently used
+type lruList struct {
+	items     *list.List
+	elements  map[string]*list.Element
+	usedBytes int64
+}
+
+func newLRUList() *lruList {
+	return &lruList{items: list.New(), elements: make(map[string]*list.Element)}
+}
+
+func (lru *lruList) contains(key string) bool {
+	_, ok := lru.elements[key]
+	return ok
+}
+
+func (lru *lruList) pushFront(key string, size int) {
+	lru.elements[key] = lru.items.PushFront(&lruItem{key: key, size: size})
+	lru.usedBytes += int64(size)
+}
+
+func (lru *lruList) moveToFront(key string) {
+	lru.items.MoveToFront(lru.elements[key])
+}
+
+// back returns the least recently used key
+func (lru *lruList) back() (string, bool) {
+	element := lru.items.Back()
+	if element == nil {
+		return "", false
+	}
+	return element.Value.(*lruItem).key, true
+}
+
+// size returns the size of key, 0 when it is not in the list
+func (lru *lruList) size(key string) int {
+	element, ok := lru.elements[key]
+	if !ok {
+		return 0
+	}
+	return element.Value.(*lruItem).size
+}
+
+func (lru *lruList) resize(key string, size int) {
+	item := lru.elements[key].Value.(*lruItem)
+	lru.usedBytes += int64(size - item.size)
+	item.size = size
+}
+
+// remove returns the size key occupied
+func (lru *lruList) remove(key string) int {
+	element, ok := lru.elements[key]
+	if !ok {
+		return 0
+	}
+
+	item := lru.items.Remove(element).(*lruItem)
+	delete(lru.elements, key)
+	lru.usedBytes -= int64(item.size)
+	return item.size
+}
+
+// lru evicts the least recently used keys
+type lru struct {
+	maxBytes int64
+	list     *lruList
+}
+
+func newLRU(maxBytes int64) *lru {
+	return &lru{maxBytes: maxBytes, list: newLRUList()}
+}
+
+func (lru *lru) touch(key string) bool {
+	if !lru.list.contains(key) {
+		return false
+	}
+
+	lru.list.moveToFront(key)
+	return true
+}
+
+func (lru *lru) admit(key string, size int) []string {
+	if lru.list.contains(key) {
+		lru.list.resize(key, size)
+		lru.list.moveToFront(key)
+	} else {
+		lru.list.pushFront(key, size)
+	}
+
+	victims := []string{}
+	for lru.list.usedBytes > lru.maxBytes {
+		victim, _ := lru.list.back()
+		lru.list.remove(victim)
+		victims = append(victims, victim)
+	}
+
+	return victims
+}
+
+func (lru *lru) forget(key string) {
+	lru.list.remove(key)
+}
+
+func (lru *lru) size(key string) int {
+	return lru.list.size(key)
+}
+
+func (lru *lru) len() int {
+	return len(lru.list.elements)
+}
+
+func (lru *lru) bytes() int64 {
+	return lru.list.usedBytes
+}
@@ -0,0 +1,215 @@
+package eviction
+
+import "hash/fnv"
+
+// sketch is a count-min sketch of 4-bit counters estimating how often keys
+// were accessed. Counters are halved every `resetAfter` increments so old
+// popularity fades away.
+type sketch struct {
+	counters   [4][]uint8
+	mask       uint64
+	additions  int
+	resetAfter int
+}
+
+func newSketch(width int) *sketch {
+	size := 1
+	for size < width {
+		size <<= 1
+	}
+
+	sketch := &sketch{mask: uint64(size - 1), resetAfter: 10 * size}
+	for i := range sketch.counters {
+		sketch.counters[i] = make([]uint8, size)
+	}
+
+	return sketch
+}
+
+func (sketch *sketch) indexes(key string) [4]uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+	hash := hasher.Sum64()
+
+	// Double hashing derives the row indexes from two halves of one hash
+	low, high := hash&0xffffffff, hash>>32|1
+	indexes := [4]uint64{}
+	for i := range indexes {
+		indexes[i] = (low + uint64(i)*high) & sketch.mask
+	}
+
+	return indexes
+}
+
+func (sketch *sketch) increment(key string) {
+	for i, index := range sketch.indexes(key) {
+		if sketch.counters[i][index] < 15 {
+			sketch.counters[i][index]++
+		}
+	}
+
+	sketch.additions++
+	if sketch.additions >= sketch.resetAfter {
+		for i := range sketch.counters {
+			for j := range sketch.counters[i] {
+				sketch.counters[i][j] >>= 1
+			}
+		}
+		sketch.additions /= 2
+	}
+}
+
+func (sketch *sketch) estimate(key string) uint8 {
+	estimate := uint8(15)
+	for i, index := range sketch.indexes(key) {
+		estimate = min(estimate, sketch.counters[i][index])
+	}
+
+	return estimate
+}
+
+// wTinyLFU is Window TinyLFU: new keys enter a small LRU window, keys leaving
+// the window are only admitted to the main segmented LRU when the sketch
+// says they are accessed more often than the main victim they would replace.
+// One-hit wonders and scans therefore never push popular keys out.
+type wTinyLFU struct {
+	windowBytes    int64
+	protectedBytes int64
+	mainBytes      int64
+
+	window    *lruList
+	probation *lruList
+	protected *lruList
+	sketch    *sketch
+}
+
+// Window gets 1% of the budget, the protected segment 80% of the rest
+func newWTinyLFU(maxBytes int64) *wTinyLFU {
+	windowBytes := max(maxBytes/100, 1)
+	mainBytes := maxBytes - windowBytes
+
+	return &wTinyLFU{
+		windowBytes:    windowBytes,
+		protectedBytes: mainBytes * 8 / 10,
+		mainBytes:      mainBytes,
+		window:         newLRUList(),
+		probation:      newLRUList(),
+		protected:      newLRUList(),
+		// Sized for entries of about 64 bytes, a bigger sketch only costs memory
+		sketch: newSketch(int(min(max(maxBytes/64, 1024), 1<<24))),
+	}
+}
+
+func (tiny *wTinyLFU) touch(key string) bool {
+	tiny.sketch.increment(key)
+
+	switch {
+	case tiny.window.contains(key):
+		tiny.window.moveToFront(key)
+	case tiny.probation.contains(key):
+		size := tiny.probation.remove(key)
+		tiny.protected.pushFront(key, size)
+		tiny.demoteProtected()
+	case tiny.protected.contains(key):
+		tiny.protected.moveToFront(key)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func (tiny *wTinyLFU) admit(key string, size int) []string {
+	for _, segment := range []*lruList{tiny.window, tiny.probation, tiny.protected} {
+		if segment.contains(key) {
+			segment.resize(key, size)
+			tiny.touch(key)
+			return tiny.evict()
+		}
+	}
+
+	tiny.window.pushFront(key, size)
+	return tiny.evict()
+}
+
+// evict moves keys out of the window and lets each of them compete with
+// the main victims for a place in the main segment
+func (tiny *wTinyLFU) evict() []string {
+	victims := []string{}
+
+	for tiny.window.usedBytes > tiny.windowBytes {
+		candidate, _ := tiny.window.back()
+		size := tiny.window.remove(candidate)
+		victims = append(victims, tiny.admitToMain(candidate, size)...)
+	}
+
+	// An entry of the main segment growing in place can still overflow it
+	for tiny.mainUsedBytes() > tiny.mainBytes {
+		victim, segment := tiny.mainVictim()
+		segment.remove(victim)
+		victims = append(victims, victim)
+	}
+
+	return victims
+}
+
+func (tiny *wTinyLFU) admitToMain(candidate string, size int) []string {
+	victims := []string{}
+
+	for tiny.mainUsedBytes()+int64(size) > tiny.mainBytes {
+		victim, segment := tiny.mainVictim()
+		if segment == nil || tiny.sketch.estimate(candidate) <= tiny.sketch.estimate(victim) {
+			return append(victims, candidate)
+		}
+
+		segment.remove(victim)
+		victims = append(victims, victim)
+	}
+
+	tiny.probation.pushFront(candidate, size)
+	return victims
+}
+
+// mainVictim returns the oldest probation key,
+// or the oldest protected key when probation is empty
+func (tiny *wTinyLFU) mainVictim() (string, *lruList) {
+	if victim, ok := tiny.probation.back(); ok {
+		return victim, tiny.probation
+	}
+	if victim, ok := tiny.protected.back(); ok {
+		return victim, tiny.protected
+	}
+
+	return "", nil
+}
+
+func (tiny *wTinyLFU) mainUsedBytes() int64 {
+	return tiny.probation.usedBytes + tiny.protected.usedBytes
+}
+
+// demoteProtected moves the oldest protected keys back to probation
+func (tiny *wTinyLFU) demoteProtected() {
+	for tiny.protected.usedBytes > tiny.protectedBytes {
+		key, _ := tiny.protected.back()
+		size := tiny.protected.remove(key)
+		tiny.probation.pushFront(key, size)
+	}
+}
+
+func (tiny *wTinyLFU) forget(key string) {
+	tiny.window.remove(key)
+	tiny.probation.remove(key)
+	tiny.protected.remove(key)
+}
+
+func (tiny *wTinyLFU) size(key string) int {
+	return tiny.window.size(key) + tiny.probation.size(key) + tiny.protected.size(key)
+}
+
+func (tiny *wTinyLFU) len() int {
+	return len(tiny.window.elements) + len(tiny.probation.elements) + len(tiny.protected.elements)
+}
+
+func (tiny *wTinyLFU) bytes() int64 {
+	return tiny.window.usedBytes + tiny.mainUsedBytes()
+}
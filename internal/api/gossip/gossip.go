@@ -0,0 +1,265 @@
+package gossip
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"errors"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoSeedAnswered is returned by Join when none of the addresses answered
+var ErrNoSeedAnswered = errors.New("no member answered the join request")
+
+// Config tunes the protocol, DefaultConfig suits members on the same LAN
+type Config struct {
+	// Name identifies the member and must be unique in the cluster
+	Name string
+	// BindAddress is the UDP address to gossip on, e.g. `0.0.0.0:7946`
+	BindAddress string
+	// AdvertiseAddress is the UDP address other members reach this one on,
+	// defaults to the bound address
+	AdvertiseAddress string
+	// URL is the HTTP base URL of this member handed to the routing layer
+	URL string
+
+	// ProbeInterval is how often a member is probed, ProbeTimeout how long
+	// a direct probe waits for an ack before asking IndirectChecks other
+	// members to probe it too
+	ProbeInterval  time.Duration
+	ProbeTimeout   time.Duration
+	IndirectChecks int
+	// SuspectTimeout is how long a member stays suspect before being declared dead
+	SuspectTimeout time.Duration
+
+	// OnChange is called with the URLs of the alive and suspect members,
+	// this one included, every time they change
+	OnChange func(urls []string)
+}
+
+func DefaultConfig(name string, bindAddress string, url string) Config {
+	return Config{
+		Name:           name,
+		BindAddress:    bindAddress,
+		URL:            url,
+		ProbeInterval:  time.Second,
+		ProbeTimeout:   300 * time.Millisecond,
+		IndirectChecks: 3,
+		SuspectTimeout: 5 * time.Second,
+	}
+}
+
+// Gossip implements model.Membership with the SWIM protocol: every probe
+// interval a member pings another one, falls back to indirect pings through
+// other members and marks it suspect when nobody got an ack. A suspect
+// member refutes by gossiping a higher incarnation, otherwise it is declared
+// dead after SuspectTimeout. Membership updates are piggybacked on probes.
+type Gossip struct {
+	config Config
+	conn   *net.UDPConn
+
+	mu         sync.Mutex
+	self       *model.MemberInfo
+	members    map[string]*model.MemberInfo
+	queue      []*broadcast
+	pending    map[uint64]func()
+	seq        uint64
+	probeOrder []string
+
+	changed chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New starts gossiping on config.BindAddress, the member is alone until Join is called
+func New(config Config) (*Gossip, error) {
+	address, err := net.ResolveUDPAddr("udp", config.BindAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.AdvertiseAddress == "" {
+		config.AdvertiseAddress = conn.LocalAddr().String()
+	}
+
+	self := &model.MemberInfo{
+		Name:           config.Name,
+		Address:        config.AdvertiseAddress,
+		URL:            strings.TrimRight(config.URL, "/"),
+		State:          model.MemberAlive,
+		StateChangedAt: time.Now(),
+	}
+
+	gossip := &Gossip{
+		config:  config,
+		conn:    conn,
+		self:    self,
+		members: map[string]*model.MemberInfo{self.Name: self},
+		pending: make(map[uint64]func()),
+		changed: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+
+	gossip.wg.Add(3)
+	go gossip.readLoop()
+	go gossip.probeLoop()
+	go gossip.notifyLoop()
+
+	gossip.signalChange()
+	return gossip, nil
+}
+
+// Address returns the UDP address other members reach this one on
+func (gossip *Gossip) Address() string {
+	return gossip.config.AdvertiseAddress
+}
+
+func (gossip *Gossip) Members() []model.MemberInfo {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	members := make([]model.MemberInfo, 0, len(gossip.members))
+	for _, member := range gossip.members {
+		members = append(members, *member)
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+func (gossip *Gossip) Join(addresses []string) (int, error) {
+	answered := make(chan struct{}, len(addresses))
+	sent := 0
+	for _, address := range addresses {
+		if address = strings.TrimSpace(address); address == "" || address == gossip.Address() {
+			continue
+		}
+
+		seq := gossip.expect(func() {
+			answered <- struct{}{}
+		})
+		defer gossip.cancel(seq)
+
+		gossip.mu.Lock()
+		self := toUpdate(gossip.self)
+		gossip.mu.Unlock()
+
+		if err := gossip.sendRaw(address, message{Type: messageJoin, Seq: seq, Updates: []update{self}}); err == nil {
+			sent++
+		}
+	}
+
+	count := 0
+	timeout := time.After(5 * gossip.config.ProbeTimeout)
+wait:
+	for count < sent {
+		select {
+		case <-answered:
+			count++
+		case <-timeout:
+			break wait
+		}
+	}
+
+	if count == 0 {
+		return 0, ErrNoSeedAnswered
+	}
+
+	return count, nil
+}
+
+// Leave tells the alive members this one is leaving and stops gossiping
+func (gossip *Gossip) Leave() {
+	gossip.mu.Lock()
+	gossip.self.Incarnation++
+	gossip.self.State = model.MemberLeft
+	gossip.self.StateChangedAt = time.Now()
+	left := message{Type: messageGossip, Updates: []update{toUpdate(gossip.self)}}
+
+	addresses := []string{}
+	for _, member := range gossip.members {
+		if member != gossip.self && isActive(member) {
+			addresses = append(addresses, member.Address)
+		}
+	}
+	gossip.mu.Unlock()
+
+	for _, address := range addresses {
+		gossip.sendRaw(address, left)
+	}
+
+	gossip.Close()
+}
+
+// Close stops gossiping without telling the other members,
+// they will detect this member as dead
+func (gossip *Gossip) Close() {
+	select {
+	case <-gossip.stop:
+		return
+	default:
+	}
+
+	close(gossip.stop)
+	gossip.conn.Close()
+	gossip.wg.Wait()
+}
+
+// URLs returns the URLs of the alive and suspect members sorted
+func (gossip *Gossip) URLs() []string {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	urls := []string{}
+	for _, member := range gossip.members {
+		if isActive(member) && member.URL != "" {
+			urls = append(urls, member.URL)
+		}
+	}
+
+	sort.Strings(urls)
+	return urls
+}
+
+func (gossip *Gossip) signalChange() {
+	select {
+	case gossip.changed <- struct{}{}:
+	default:
+	}
+}
+
+// notifyLoop calls OnChange outside of the lock, consecutive changes
+// are coalesced and a call is only made when the URLs differ
+func (gossip *Gossip) notifyLoop() {
+	defer gossip.wg.Done()
+
+	var last []string
+	for {
+		select {
+		case <-gossip.stop:
+			return
+		case <-gossip.changed:
+			urls := gossip.URLs()
+			if gossip.config.OnChange != nil && !slices.Equal(urls, last) {
+				gossip.config.OnChange(urls)
+			}
+			last = urls
+		}
+	}
+}
+
+// isActive reports whether member is still part of the cluster,
+// suspect members are until they are declared dead
+func isActive(member *model.MemberInfo) bool {
+	return member.State == model.MemberAlive || member.State == model.MemberSuspect
+}
@@ -0,0 +1,182 @@
+package gossip
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(name string) Config {
+	return Config{
+		Name:           name,
+		BindAddress:    "127.0.0.1:0",
+		URL:            "http://" + name,
+		ProbeInterval:  50 * time.Millisecond,
+		ProbeTimeout:   20 * time.Millisecond,
+		IndirectChecks: 2,
+		SuspectTimeout: 200 * time.Millisecond,
+	}
+}
+
+// startMembers starts size members gossiping over loopback UDP,
+// every member joins through the first one
+func startMembers(t *testing.T, size int) []*Gossip {
+	members := []*Gossip{}
+	for i := 0; i < size; i++ {
+		member, err := New(testConfig("node-" + strconv.Itoa(i)))
+		assert.NoError(t, err)
+		t.Cleanup(member.Close)
+
+		if i > 0 {
+			_, err := member.Join([]string{members[0].Address()})
+			assert.NoError(t, err)
+		}
+		members = append(members, member)
+	}
+
+	return members
+}
+
+// stateOf returns the state observer has for name, empty when unknown
+func stateOf(observer *Gossip, name string) string {
+	for _, member := range observer.Members() {
+		if member.Name == name {
+			return member.State
+		}
+	}
+	return ""
+}
+
+func converged(members []*Gossip, name string, state string) bool {
+	for _, observer := range members {
+		if stateOf(observer, name) != state {
+			return false
+		}
+	}
+	return true
+}
+
+func TestJoin(t *testing.T) {
+	members := startMembers(t, 4)
+
+	assert.Eventually(t, func() bool {
+		for i := range members {
+			if !converged(members, "node-"+strconv.Itoa(i), model.MemberAlive) {
+				return false
+			}
+		}
+		return true
+	}, 3*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"http://node-0", "http://node-1", "http://node-2", "http://node-3"}, members[3].URLs())
+
+	lonely, err := New(testConfig("lonely"))
+	assert.NoError(t, err)
+	defer lonely.Close()
+
+	_, err = lonely.Join([]string{"127.0.0.1:1"})
+	assert.Equal(t, ErrNoSeedAnswered, err)
+}
+
+func TestFailureDetection(t *testing.T) {
+	members := startMembers(t, 4)
+	assert.Eventually(t, func() bool {
+		return converged(members, "node-3", model.MemberAlive)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Stops answering without telling anyone
+	members[3].Close()
+	survivors := members[:3]
+
+	assert.Eventually(t, func() bool {
+		for _, observer := range survivors {
+			if state := stateOf(observer, "node-3"); state != model.MemberSuspect && state != model.MemberDead {
+				return false
+			}
+		}
+		return true
+	}, 3*time.Second, 5*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return converged(survivors, "node-3", model.MemberDead)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// The survivors never suspected each other for good
+	assert.True(t, converged(survivors, "node-0", model.MemberAlive))
+	assert.NotContains(t, members[0].URLs(), "http://node-3")
+}
+
+func TestLeave(t *testing.T) {
+	members := startMembers(t, 3)
+	assert.Eventually(t, func() bool {
+		return converged(members, "node-2", model.MemberAlive)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	members[2].Leave()
+
+	// Left is gossiped directly, well before a probe could suspect it
+	assert.Eventually(t, func() bool {
+		return converged(members[:2], "node-2", model.MemberLeft)
+	}, 100*time.Millisecond, 5*time.Millisecond)
+}
+
+func TestRejoinAfterDeath(t *testing.T) {
+	members := startMembers(t, 3)
+	assert.Eventually(t, func() bool {
+		return converged(members, "node-2", model.MemberAlive)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	members[2].Close()
+	assert.Eventually(t, func() bool {
+		return converged(members[:2], "node-2", model.MemberDead)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// A restarted member refutes its death with a higher incarnation
+	restarted, err := New(testConfig("node-2"))
+	assert.NoError(t, err)
+	defer restarted.Close()
+
+	_, err = restarted.Join([]string{members[0].Address()})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return converged(members[:2], "node-2", model.MemberAlive)
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestOnChange(t *testing.T) {
+	var mu sync.Mutex
+	var last []string
+
+	config := testConfig("observer")
+	config.OnChange = func(urls []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		last = urls
+	}
+	observer, err := New(config)
+	assert.NoError(t, err)
+	defer observer.Close()
+
+	other, err := New(testConfig("other"))
+	assert.NoError(t, err)
+	_, err = other.Join([]string{observer.Address()})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(last) == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	other.Leave()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(last) == 1 && last[0] == "http://observer"
+	}, 3*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,116 @@
+package gossip
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"math/rand/v2"
+	"time"
+)
+
+func (gossip *Gossip) probeLoop() {
+	defer gossip.wg.Done()
+
+	ticker := time.NewTicker(gossip.config.ProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-gossip.stop:
+			return
+		case <-ticker.C:
+			gossip.probe()
+			gossip.reapSuspects()
+		}
+	}
+}
+
+// probe pings the next member, then asks other members to ping it
+// and suspects it when no ack arrived within the probe interval
+func (gossip *Gossip) probe() {
+	target, ok := gossip.nextTarget()
+	if !ok {
+		return
+	}
+
+	acked := make(chan struct{}, 1)
+	seq := gossip.expect(func() {
+		acked <- struct{}{}
+	})
+	defer gossip.cancel(seq)
+
+	gossip.send(target.Address, message{Type: messagePing, Seq: seq, Target: target.Name})
+	if gossip.waitAck(acked, gossip.config.ProbeTimeout) {
+		return
+	}
+
+	for _, relay := range gossip.randomMembers(gossip.config.IndirectChecks, target.Name) {
+		gossip.send(relay.Address, message{Type: messagePingReq, Seq: seq, Target: target.Name, TargetAddress: target.Address})
+	}
+	if gossip.waitAck(acked, gossip.config.ProbeInterval-gossip.config.ProbeTimeout) {
+		return
+	}
+
+	gossip.suspect(target.Name, target.Incarnation)
+}
+
+func (gossip *Gossip) waitAck(acked chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-acked:
+		return true
+	case <-gossip.stop:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// nextTarget walks the active members in a random order that is
+// reshuffled after every round so each member is probed once per round
+func (gossip *Gossip) nextTarget() (model.MemberInfo, bool) {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	for {
+		if len(gossip.probeOrder) == 0 {
+			for name, member := range gossip.members {
+				if member != gossip.self && isActive(member) {
+					gossip.probeOrder = append(gossip.probeOrder, name)
+				}
+			}
+			if len(gossip.probeOrder) == 0 {
+				return model.MemberInfo{}, false
+			}
+
+			rand.Shuffle(len(gossip.probeOrder), func(i, j int) {
+				gossip.probeOrder[i], gossip.probeOrder[j] = gossip.probeOrder[j], gossip.probeOrder[i]
+			})
+		}
+
+		name := gossip.probeOrder[0]
+		gossip.probeOrder = gossip.probeOrder[1:]
+		if member, known := gossip.members[name]; known && isActive(member) {
+			return *member, true
+		}
+	}
+}
+
+// randomMembers returns up to count alive members other than this one and exclude
+func (gossip *Gossip) randomMembers(count int, exclude string) []model.MemberInfo {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	candidates := []model.MemberInfo{}
+	for name, member := range gossip.members {
+		if member != gossip.self && name != exclude && member.State == model.MemberAlive {
+			candidates = append(candidates, *member)
+		}
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	return candidates
+}
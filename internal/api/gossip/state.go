@@ -0,0 +1,171 @@
+package gossip
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"math"
+	"time"
+)
+
+// maxPiggyback is the number of updates carried by a single message
+const maxPiggyback = 8
+
+// update is the state of a member as gossiped on the wire
+type update struct {
+	Name        string `json:"name"`
+	Address     string `json:"address"`
+	URL         string `json:"url,omitempty"`
+	State       string `json:"state"`
+	Incarnation uint64 `json:"incarnation"`
+}
+
+// broadcast is an update waiting to be piggybacked `transmits` more times
+type broadcast struct {
+	update    update
+	transmits int
+}
+
+func toUpdate(member *model.MemberInfo) update {
+	return update{
+		Name:        member.Name,
+		Address:     member.Address,
+		URL:         member.URL,
+		State:       member.State,
+		Incarnation: member.Incarnation,
+	}
+}
+
+// apply merges an update into the member list and queues it for
+// dissemination when it changed anything. Must be called under mu.
+func (gossip *Gossip) apply(received update) {
+	if received.Name == gossip.self.Name {
+		gossip.refute(received)
+		return
+	}
+
+	member, known := gossip.members[received.Name]
+	if !known {
+		// Suspect or dead members nobody told us about are not worth tracking
+		if received.State != model.MemberAlive {
+			return
+		}
+
+		member = &model.MemberInfo{Name: received.Name}
+		gossip.members[received.Name] = member
+	} else if !supersedes(member, received) {
+		return
+	}
+
+	if received.State == model.MemberAlive {
+		member.Address = received.Address
+		member.URL = received.URL
+	}
+	member.Incarnation = received.Incarnation
+	if member.State != received.State {
+		member.State = received.State
+		member.StateChangedAt = time.Now()
+	}
+
+	gossip.enqueue(received)
+	gossip.signalChange()
+}
+
+// refute answers gossip claiming this member is suspect or dead, or carrying
+// an incarnation of a previous run, by gossiping a higher incarnation
+func (gossip *Gossip) refute(received update) {
+	if gossip.self.State == model.MemberLeft || received.Incarnation < gossip.self.Incarnation {
+		return
+	}
+
+	if received.State == model.MemberAlive && received.Incarnation == gossip.self.Incarnation {
+		return
+	}
+
+	gossip.self.Incarnation = received.Incarnation + 1
+	gossip.enqueue(toUpdate(gossip.self))
+}
+
+// supersedes implements the SWIM precedence: a higher incarnation wins, and
+// for the same incarnation dead and left win over suspect which wins over alive
+func supersedes(member *model.MemberInfo, received update) bool {
+	switch received.State {
+	case model.MemberAlive:
+		return received.Incarnation > member.Incarnation
+	case model.MemberSuspect:
+		if member.State == model.MemberAlive {
+			return received.Incarnation >= member.Incarnation
+		}
+		return member.State == model.MemberSuspect && received.Incarnation > member.Incarnation
+	case model.MemberDead, model.MemberLeft:
+		return isActive(member) && received.Incarnation >= member.Incarnation
+	}
+
+	return false
+}
+
+// suspect marks a member that did not answer a probe
+func (gossip *Gossip) suspect(name string, incarnation uint64) {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	if member, known := gossip.members[name]; known {
+		gossip.apply(update{Name: name, Address: member.Address, URL: member.URL, State: model.MemberSuspect, Incarnation: incarnation})
+	}
+}
+
+// reapSuspects declares dead the members suspect for longer than SuspectTimeout
+func (gossip *Gossip) reapSuspects() {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	for _, member := range gossip.members {
+		if member.State == model.MemberSuspect && time.Since(member.StateChangedAt) > gossip.config.SuspectTimeout {
+			gossip.apply(update{Name: member.Name, Address: member.Address, URL: member.URL, State: model.MemberDead, Incarnation: member.Incarnation})
+		}
+	}
+}
+
+// enqueue queues an update for piggybacking, replacing any older update
+// about the same member. Must be called under mu.
+func (gossip *Gossip) enqueue(received update) {
+	// Every update is sent about log(n) times so it reaches all the members
+	transmits := 3 * int(math.Ceil(math.Log2(float64(len(gossip.members)+1))))
+
+	for _, queued := range gossip.queue {
+		if queued.update.Name == received.Name {
+			queued.update = received
+			queued.transmits = transmits
+			return
+		}
+	}
+
+	gossip.queue = append(gossip.queue, &broadcast{update: received, transmits: transmits})
+}
+
+// piggyback takes the updates to attach to the next message. Must be called under mu.
+func (gossip *Gossip) piggyback() []update {
+	updates := []update{}
+	remaining := gossip.queue[:0]
+	for _, queued := range gossip.queue {
+		if len(updates) < maxPiggyback {
+			updates = append(updates, queued.update)
+			queued.transmits--
+		}
+
+		if queued.transmits > 0 {
+			remaining = append(remaining, queued)
+		}
+	}
+
+	gossip.queue = remaining
+	return updates
+}
+
+// snapshot returns the whole member list, sent to joining members. Must be called under mu.
+func (gossip *Gossip) snapshot() []update {
+	updates := make([]update, 0, len(gossip.members))
+	for _, member := range gossip.members {
+		updates = append(updates, toUpdate(member))
+	}
+
+	return updates
+}
@@ -0,0 +1,144 @@
+package gossip
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	messagePing    = "ping"
+	messageAck     = "ack"
+	messagePingReq = "ping-req"
+	messageJoin    = "join"
+	messageSync    = "sync"
+	// messageGossip only carries updates
+	messageGossip = "gossip"
+)
+
+type message struct {
+	Type string `json:"type"`
+	Seq  uint64 `json:"seq,omitempty"`
+	// Target is the member a ping or ping-req is meant for
+	Target        string   `json:"target,omitempty"`
+	TargetAddress string   `json:"target_address,omitempty"`
+	Updates       []update `json:"updates,omitempty"`
+}
+
+// reply is a message to send once mu is released
+type reply struct {
+	address string
+	message message
+}
+
+func (gossip *Gossip) readLoop() {
+	defer gossip.wg.Done()
+
+	buffer := make([]byte, 64*1024)
+	for {
+		n, from, err := gossip.conn.ReadFromUDP(buffer)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+
+		received := message{}
+		if err := json.Unmarshal(buffer[:n], &received); err != nil {
+			continue
+		}
+
+		gossip.handle(received, from.String())
+	}
+}
+
+func (gossip *Gossip) handle(received message, from string) {
+	replies := []reply{}
+	var acked func()
+
+	gossip.mu.Lock()
+	for _, update := range received.Updates {
+		gossip.apply(update)
+	}
+
+	switch received.Type {
+	case messagePing:
+		// A ping for the previous member bound to this address is not answered
+		if received.Target == "" || received.Target == gossip.self.Name {
+			replies = append(replies, reply{from, message{Type: messageAck, Seq: received.Seq}})
+		}
+	case messageAck, messageSync:
+		acked = gossip.pending[received.Seq]
+		delete(gossip.pending, received.Seq)
+	case messagePingReq:
+		// Probe the target on behalf of the requester and forward its ack
+		gossip.seq++
+		seq := gossip.seq
+		requesterSeq := received.Seq
+		gossip.pending[seq] = func() {
+			gossip.send(from, message{Type: messageAck, Seq: requesterSeq})
+		}
+		time.AfterFunc(gossip.config.ProbeInterval, func() {
+			gossip.cancel(seq)
+		})
+		replies = append(replies, reply{received.TargetAddress, message{Type: messagePing, Seq: seq, Target: received.Target}})
+	case messageJoin:
+		replies = append(replies, reply{from, message{Type: messageSync, Seq: received.Seq, Updates: gossip.snapshot()}})
+	}
+	gossip.mu.Unlock()
+
+	if acked != nil {
+		acked()
+	}
+
+	for _, reply := range replies {
+		if reply.message.Type == messageSync {
+			gossip.sendRaw(reply.address, reply.message)
+			continue
+		}
+		gossip.send(reply.address, reply.message)
+	}
+}
+
+// expect registers fn to be called when the ack for the returned sequence number arrives
+func (gossip *Gossip) expect(fn func()) uint64 {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	gossip.seq++
+	gossip.pending[gossip.seq] = fn
+	return gossip.seq
+}
+
+func (gossip *Gossip) cancel(seq uint64) {
+	gossip.mu.Lock()
+	defer gossip.mu.Unlock()
+
+	delete(gossip.pending, seq)
+}
+
+// send piggybacks queued updates on msg
+func (gossip *Gossip) send(address string, msg message) error {
+	gossip.mu.Lock()
+	msg.Updates = append(msg.Updates, gossip.piggyback()...)
+	gossip.mu.Unlock()
+
+	return gossip.sendRaw(address, msg)
+}
+
+func (gossip *Gossip) sendRaw(address string, msg message) error {
+	target, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = gossip.conn.WriteToUDP(data, target)
+	return err
+}
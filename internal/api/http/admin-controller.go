@@ -0,0 +1,146 @@
+package http
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"io"
+	"log"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// TriggerSnapshot starts a background snapshot, poll GetSnapshotStatus for the result
+func TriggerSnapshot(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Snapshotter == nil {
+		return sendError(c, "Snapshots are disabled")
+	}
+
+	if !ctx.Snapshotter.Trigger() {
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Snapshot already in progress",
+			"cache":   ctx.Snapshotter.Status(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Snapshot started",
+		"cache":   ctx.Snapshotter.Status(),
+	})
+}
+
+func GetSnapshotStatus(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Snapshotter == nil {
+		return sendError(c, "Snapshots are disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.Snapshotter.Status(),
+	})
+}
+
+// ExportCache streams live entries whose key starts with `prefix`
+// as `ndjson` (default) or `binary`
+func ExportCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	format := c.Query("format", persistence.FormatNDJSON)
+	prefix := c.Query("prefix")
+	if format != persistence.FormatNDJSON && format != persistence.FormatBinary {
+		return sendError(c, "Query `format` should be ndjson or binary")
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := persistence.Export(ctx, writer, format, prefix)
+		writer.CloseWithError(err)
+	}()
+
+	if format == persistence.FormatBinary {
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+	} else {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	}
+	c.Attachment("cache-export." + format)
+
+	return c.SendStream(reader)
+}
+
+// ImportCache loads a dump produced by ExportCache, `conflict` decides
+// whether existing keys are overwritten (default) or skipped, `keys`
+// lists the imported keys in the response
+func ImportCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	format := c.Query("format", persistence.FormatNDJSON)
+	conflict := c.Query("conflict", persistence.ConflictOverwrite)
+	listKeys := fiber.Query[bool](c, "keys", false)
+
+	var body io.Reader = c.Request().BodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	result, err := persistence.Import(ctx, body, format, conflict, listKeys)
+	if err != nil {
+		log.Printf("Error occured when `ImportCache` : %v", err.Error())
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Import failed : " + err.Error(),
+			"cache":   result,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Import finished",
+		"cache":   result,
+	})
+}
+
+// GetConfig reports the effective configuration and the file it was loaded from
+func GetConfig(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Config == nil {
+		return sendError(c, "Configuration was not loaded")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"file":     ctx.Config.Source(),
+			"settings": ctx.Config.Effective(),
+		},
+	})
+}
+
+// ReloadConfig applies the settings that can change without a restart
+// and lists those that changed but need one
+func ReloadConfig(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Config == nil {
+		return sendError(c, "Configuration was not loaded")
+	}
+
+	result, err := ctx.Config.Reload()
+	if err != nil {
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Invalid configuration, nothing was applied : " + err.Error(),
+			"cache":   nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  result,
+	})
+}
+
+func GetTierInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.SecondTier == nil {
+		return sendError(c, "Disk tier is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.SecondTier.Info(),
+	})
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpAdminApp() (*fiber.App, *model.CacheAppContext) {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Get("/admin/export", func(c fiber.Ctx) error { return ExportCache(c, ctx) })
+	app.Post("/admin/import", func(c fiber.Ctx) error { return ImportCache(c, ctx) })
+	app.Post("/admin/snapshot", func(c fiber.Ctx) error { return TriggerSnapshot(c, ctx) })
+
+	return app, ctx
+}
+
+func exportDump(t *testing.T, app *fiber.App, target string) []byte {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	assert.NoError(t, err)
+
+	dump, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return dump
+}
+
+func importDump(t *testing.T, app *fiber.App, target string, dump []byte) map[string]any {
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, target, bytes.NewReader(dump)))
+	assert.NoError(t, err)
+
+	response := map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+func TestExportImport(t *testing.T) {
+	for _, format := range []string{"ndjson", "binary"} {
+		t.Run(format, func(t *testing.T) {
+			source, sourceCtx := setUpAdminApp()
+			sourceCtx.SetEntry("user:1", model.CacheEntry{Value: "alice", Expiration: time.Now().Add(time.Minute)})
+			sourceCtx.SetEntry("user:2", model.CacheEntry{Value: "bob", Expiration: time.Now().Add(time.Minute)})
+			sourceCtx.SetEntry("session:1", model.CacheEntry{Value: "token", Expiration: time.Now().Add(time.Minute)})
+
+			dump := exportDump(t, source, "/admin/export?prefix=user:&format="+format)
+
+			target, targetCtx := setUpAdminApp()
+			targetCtx.SetEntry("user:2", model.CacheEntry{Value: "existing", Expiration: time.Now().Add(time.Minute)})
+
+			response := importDump(t, target, "/admin/import?conflict=skip&format="+format, dump)
+			assert.Equal(t, "OK", response["status"])
+			assert.Equal(t, float64(1), response["cache"].(map[string]any)["imported"])
+			assert.Equal(t, float64(1), response["cache"].(map[string]any)["skipped"])
+
+			entry, err := targetCtx.GetEntry("user:2")
+			assert.NoError(t, err)
+			assert.Equal(t, "existing", entry.Value)
+
+			_, err = targetCtx.GetEntry("session:1")
+			assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+
+			response = importDump(t, target, "/admin/import?format="+format, dump)
+			assert.Equal(t, float64(2), response["cache"].(map[string]any)["imported"])
+
+			entry, err = targetCtx.GetEntry("user:2")
+			assert.NoError(t, err)
+			assert.Equal(t, "bob", entry.Value)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), entry.Expiration, 5*time.Second)
+		})
+	}
+}
+
+func TestImportInvalidDump(t *testing.T) {
+	app, _ := setUpAdminApp()
+
+	response := importDump(t, app, "/admin/import?format=binary", []byte("garbage"))
+	assert.Equal(t, "ERROR", response["status"])
+
+	response = importDump(t, app, "/admin/import?conflict=merge", []byte{})
+	assert.Equal(t, "ERROR", response["status"])
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/admin/snapshot", nil))
+	assert.NoError(t, err)
+	response = map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "Snapshots are disabled", response["message"])
+}
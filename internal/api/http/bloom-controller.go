@@ -0,0 +1,195 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/probabilistic"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func CreateBloomFilter(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	bloomReq := new(model.BloomCreateRequest)
+	if err := c.Bind().Body(bloomReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(bloomReq.Key) == "" {
+		return sendError(c, "Cache `key` cannot be empty")
+	}
+
+	// Checked before allocating, the encoded filter is at least as big as its first layer
+	if ctx.MaxEntrySize > 0 && probabilistic.BloomFilterSize(bloomReq.ErrorRate, bloomReq.Capacity) > float64(ctx.MaxEntrySize) {
+		return sendError(c, "Bloom filter would exceed the max entry size, lower `capacity` or raise `error_rate`")
+	}
+
+	filter, err := probabilistic.NewScalableBloomFilter(bloomReq.ErrorRate, bloomReq.Capacity)
+	if errors.Is(err, probabilistic.ErrBloomFilterTooLarge) {
+		return sendError(c, "Bloom filter is too large, lower `capacity` or raise `error_rate`")
+	}
+	if err != nil {
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Validation error",
+			"cache":   nil,
+			"validation_error": fiber.Map{
+				"error_rate": "Value `error_rate` should be > 0 and < 1",
+				"capacity":   "Value `capacity` should be >= 1",
+			},
+		})
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	if _, err := ctx.GetEntryContext(c.UserContext(), bloomReq.Key); err == nil {
+		return sendError(c, "Key already exists")
+	}
+
+	entry := model.CacheEntry{
+		Type:       model.EntryTypeBloom,
+		Value:      filter,
+		Expiration: ctx.ExpirationFor(bloomReq.DurationInSeconds),
+	}
+	if err := ctx.SetEntryContext(c.UserContext(), bloomReq.Key, entry); err != nil {
+		return sendEntryError(c, err, "CreateBloomFilter")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Bloom filter created successfully",
+		"cache": fiber.Map{
+			"key":        bloomReq.Key,
+			"error_rate": filter.ErrorRate,
+			"capacity":   filter.Capacity,
+		},
+	})
+}
+
+// AddBloomFilter adds `items`, the filter is created with the default
+// error rate and capacity when the key does not exist yet
+func AddBloomFilter(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	bloomReq := new(model.BloomItemsRequest)
+	if err := c.Bind().Body(bloomReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(bloomReq.Key) == "" {
+		return sendError(c, "Cache `key` cannot be empty")
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), bloomReq.Key, model.EntryTypeBloom)
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "AddBloomFilter")
+	}
+
+	filter := new(probabilistic.ScalableBloomFilter)
+	if err != nil {
+		filter, _ = probabilistic.NewScalableBloomFilter(config.DEFAULT_BLOOM_ERROR_RATE, config.DEFAULT_BLOOM_CAPACITY)
+		entry.Type = model.EntryTypeBloom
+		entry.Expiration = ctx.ExpirationFor(bloomReq.DurationInSeconds)
+	} else if err := entry.DecodeValue(filter); err != nil {
+		return sendEntryError(c, err, "AddBloomFilter")
+	}
+
+	// Every add stores the whole filter, it may only grow as far as an entry can
+	if ctx.MaxEntrySize > 0 {
+		envelope := entry
+		envelope.Value = nil
+		data, _ := json.Marshal(envelope)
+		filter.MaxSize = ctx.MaxEntrySize - model.EntrySize(bloomReq.Key, data)
+	}
+
+	added := make([]bool, len(bloomReq.Items))
+	for i, item := range bloomReq.Items {
+		added[i], err = filter.Add(item)
+		if errors.Is(err, probabilistic.ErrBloomFilterMaxSize) {
+			return sendError(c, "Bloom filter cannot grow past the max entry size, nothing was added")
+		}
+		if err != nil {
+			return sendError(c, "Bloom filter is full, nothing was added")
+		}
+	}
+
+	entry.Value = filter
+	if err := ctx.SetEntryContext(c.UserContext(), bloomReq.Key, entry); err != nil {
+		return sendEntryError(c, err, "AddBloomFilter")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Items added successfully",
+		"cache": fiber.Map{
+			"key":   bloomReq.Key,
+			"added": added,
+			"count": filter.Count(),
+		},
+	})
+}
+
+func ExistsBloomFilter(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	item := c.Query("item")
+
+	exists, err := checkBloomFilter(c.UserContext(), ctx, key, []string{item})
+	if err != nil {
+		return sendEntryError(c, err, "ExistsBloomFilter")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":    key,
+			"item":   item,
+			"exists": exists[0],
+		},
+	})
+}
+
+func MultiExistsBloomFilter(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	bloomReq := new(model.BloomItemsRequest)
+	if err := c.Bind().Body(bloomReq); err != nil {
+		return err
+	}
+
+	exists, err := checkBloomFilter(c.UserContext(), ctx, bloomReq.Key, bloomReq.Items)
+	if err != nil {
+		return sendEntryError(c, err, "MultiExistsBloomFilter")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":    bloomReq.Key,
+			"exists": exists,
+		},
+	})
+}
+
+func checkBloomFilter(parent context.Context, ctx *model.CacheAppContext, key string, items []string) ([]bool, error) {
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeBloom)
+	ctx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	filter := new(probabilistic.ScalableBloomFilter)
+	if err := entry.DecodeValue(filter); err != nil {
+		return nil, err
+	}
+
+	exists := make([]bool, len(items))
+	for i, item := range items {
+		exists[i] = filter.Exists(item)
+	}
+
+	return exists, nil
+}
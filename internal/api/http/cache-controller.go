@@ -2,8 +2,9 @@ package http
 
 import (
 	"cache_engine_httpserver/internal/api/model"
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -13,10 +14,14 @@ import (
 
 func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	key := c.Query("key")
-	data, err := ctx.Cache.Get(key)
+	data, err := ctx.GetRawContext(c.UserContext(), key)
 	if err != nil {
 		cacheExists := isCacheExists(err)
 		if !cacheExists {
+			if entry, err := fillFromPeer(c.UserContext(), ctx, key); err == nil {
+				return sendValue(c, key, entry.Value)
+			}
+
 			return c.JSON(fiber.Map{
 				"status":  "ERROR",
 				"message": "Key not found",
@@ -24,7 +29,7 @@ func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 			})
 		}
 
-		log.Println(err.Error())
+		slog.ErrorContext(c.UserContext(), "Error when reading cache entry", "operation", "GetCache", "key_hash", model.KeyHash(key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Failed to decode cache entry",
@@ -34,7 +39,7 @@ func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 
 	entry := model.CacheEntry{}
 	if err := json.Unmarshal(data, &entry); err != nil {
-		log.Println(err.Error())
+		slog.ErrorContext(c.UserContext(), "Error when decoding cache entry", "operation", "GetCache", "key_hash", model.KeyHash(key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Something error with Get cache operation.",
@@ -43,9 +48,9 @@ func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	}
 
 	if time.Now().After(entry.Expiration) {
-		err = ctx.Cache.Delete(key)
+		err = ctx.ExpireEntryContext(c.UserContext(), key)
 		if err != nil {
-			log.Println(err.Error())
+			slog.ErrorContext(c.UserContext(), "Error when deleting expired cache entry", "operation", "GetCache", "key_hash", model.KeyHash(key), "error", err)
 			return c.JSON(fiber.Map{
 				"status":  "ERROR",
 				"message": "Something error with cache deletion.",
@@ -53,6 +58,11 @@ func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 			})
 		}
 
+		// An expired hot copy is refreshed from its owner
+		if entry, err := fillFromPeer(c.UserContext(), ctx, key); err == nil {
+			return sendValue(c, key, entry.Value)
+		}
+
 		return c.JSON(fiber.Map{
 			"status":  "OK",
 			"message": "Key is expired",
@@ -60,11 +70,15 @@ func GetCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 		})
 	}
 
+	return sendValue(c, key, entry.Value)
+}
+
+func sendValue(c fiber.Ctx, key string, value any) error {
 	return c.JSON(fiber.Map{
 		"status": "OK",
 		"cache": fiber.Map{
 			"key":   key,
-			"value": entry.Value,
+			"value": value,
 		},
 	})
 }
@@ -88,11 +102,12 @@ func CreateCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	entry := model.CacheEntry{
 		Value:      cacheReq.Value,
 		Expiration: expiration,
+		Tags:       cacheReq.Tags,
 	}
 
 	entryData, err := json.Marshal(entry)
 	if err != nil {
-		log.Printf("Error when marshaling entry data : %v", err.Error())
+		slog.ErrorContext(c.UserContext(), "Error when marshaling entry data", "operation", "CreateCache", "key_hash", model.KeyHash(cacheReq.Key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Failed to encode cache entry",
@@ -100,9 +115,12 @@ func CreateCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 		})
 	}
 
-	err = ctx.Cache.Set(cacheReq.Key, entryData)
+	err = ctx.SetRawContext(c.UserContext(), cacheReq.Key, entryData)
+	if err == model.ErrOutOfMemory {
+		return sendEntryError(c, err, "CreateCache")
+	}
 	if err != nil {
-		log.Printf("Error when Set cache value : %v", err.Error())
+		slog.ErrorContext(c.UserContext(), "Error when Set cache value", "operation", "CreateCache", "key_hash", model.KeyHash(cacheReq.Key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Something error when set cache",
@@ -121,24 +139,15 @@ func CreateCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	})
 }
 
+// DeleteCache deletes key locally, then broadcasts the invalidation
 func DeleteCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	key := c.Params("key")
-	_, err := ctx.Cache.Get(key)
-	if err != nil {
-		cacheExists := isCacheExists(err)
-		if !cacheExists {
-			return c.JSON(fiber.Map{
-				"status":  "ERROR",
-				"message": "Key not found",
-				"cache":   nil,
-			})
-		}
-
-	}
+	_, err := ctx.GetRawContext(c.UserContext(), key)
+	found := err == nil || isCacheExists(err)
 
-	err = ctx.Cache.Delete(key)
+	err = ctx.DeleteEntryContext(c.UserContext(), key)
 	if err != nil {
-		log.Printf("Error occured when `DeleteCache` : %v", err.Error())
+		slog.ErrorContext(c.UserContext(), "Error occured when deleting cache", "operation", "DeleteCache", "key_hash", model.KeyHash(key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Something error happened when deleting cache with key `" + key + "`",
@@ -146,6 +155,17 @@ func DeleteCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 		})
 	}
 
+	// Other nodes may hold the key even when this one does not
+	broadcastInvalidation(ctx, model.Invalidation{Op: model.InvalidateKey, Key: key})
+
+	if !found {
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Key not found",
+			"cache":   nil,
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"status":  "OK",
 		"message": "Cache deleted successfully",
@@ -155,9 +175,47 @@ func DeleteCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	})
 }
 
+// PurgeTag deletes every key created with the tag
+func PurgeTag(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	tag := c.Params("tag")
+	deleted, err := ctx.PurgeTag(tag)
+	if err != nil {
+		return sendEntryError(c, err, "PurgeTag")
+	}
+
+	broadcastInvalidation(ctx, model.Invalidation{Op: model.InvalidateTag, Tag: tag})
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Tag purged successfully",
+		"cache": fiber.Map{
+			"tag":     tag,
+			"deleted": deleted,
+		},
+	})
+}
+
+// FlushCache deletes every key
+func FlushCache(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	deleted, err := ctx.Flush()
+	if err != nil {
+		return sendEntryError(c, err, "Flush")
+	}
+
+	broadcastInvalidation(ctx, model.Invalidation{Op: model.InvalidateFlush})
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Cache flushed successfully",
+		"cache": fiber.Map{
+			"deleted": deleted,
+		},
+	})
+}
+
 func IsCacheExists(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	key := c.Params("key")
-	_, err := ctx.Cache.Get(key)
+	_, err := ctx.GetRawContext(c.UserContext(), key)
 	if err != nil {
 		cacheExists := isCacheExists(err)
 		if !cacheExists {
@@ -172,10 +230,10 @@ func IsCacheExists(c fiber.Ctx, ctx *model.CacheAppContext) error {
 
 	}
 
-	data, err := ctx.Cache.Get(key)
+	data, err := ctx.GetRawContext(c.UserContext(), key)
 	entry := model.CacheEntry{}
 	if err := json.Unmarshal(data, &entry); err != nil {
-		log.Println(err.Error())
+		slog.ErrorContext(c.UserContext(), "Error when decoding cache entry", "operation", "IsCacheExists", "key_hash", model.KeyHash(key), "error", err)
 		return c.JSON(fiber.Map{
 			"status":  "ERROR",
 			"message": "Failed to decode cache entry",
@@ -184,9 +242,9 @@ func IsCacheExists(c fiber.Ctx, ctx *model.CacheAppContext) error {
 	}
 
 	if time.Now().After(entry.Expiration) {
-		err = ctx.Cache.Delete(key)
+		err = ctx.ExpireEntryContext(c.UserContext(), key)
 		if err != nil {
-			log.Println(err.Error())
+			slog.ErrorContext(c.UserContext(), "Error when deleting expired cache entry", "operation", "IsCacheExists", "key_hash", model.KeyHash(key), "error", err)
 			return c.JSON(fiber.Map{
 				"status":  "ERROR",
 				"message": "Something error with cache deletion",
@@ -224,7 +282,7 @@ func validateCacheCreate(request model.CacheCreationRequest) (bool, map[string]i
 
 	value, ok := request.Value.(string)
 	if !ok {
-		log.Println("request.Value is not a string")
+		slog.Debug("request.Value is not a string")
 		value = ""
 	}
 
@@ -242,3 +300,48 @@ func validateCacheCreate(request model.CacheCreationRequest) (bool, map[string]i
 
 	return false, validationErr
 }
+
+// sendError writes the common ERROR envelope used by every controller
+func sendError(c fiber.Ctx, message string) error {
+	return c.JSON(fiber.Map{
+		"status":  "ERROR",
+		"message": message,
+		"cache":   nil,
+	})
+}
+
+// fillFromPeer loads a local miss from the peer owning key when peer fill is enabled
+func fillFromPeer(parent context.Context, ctx *model.CacheAppContext, key string) (model.CacheEntry, error) {
+	if ctx.Peers == nil {
+		return model.CacheEntry{}, bigcache.ErrEntryNotFound
+	}
+
+	entry, err := ctx.Peers.Fill(parent, key)
+	if err != nil && isCacheExists(err) {
+		slog.ErrorContext(parent, "Error occured when filling from peer", "key_hash", model.KeyHash(key), "error", err)
+	}
+
+	return entry, err
+}
+
+// sendEntryError maps errors returned by the model store helpers to a response
+func sendEntryError(c fiber.Ctx, err error, operation string) error {
+	if !isCacheExists(err) {
+		return sendError(c, "Key not found")
+	}
+
+	if err == model.ErrWrongType {
+		return sendError(c, "Operation against a key holding the wrong kind of value")
+	}
+
+	if err == model.ErrEntryTooLarge {
+		return sendError(c, "Entry exceeds the max entry size of the cache")
+	}
+
+	if err == model.ErrOutOfMemory {
+		return sendError(c.Status(fiber.StatusInsufficientStorage), "Not enough memory left under the max memory of the cache")
+	}
+
+	slog.ErrorContext(c.UserContext(), "Error occured in operation", "operation", operation, "error", err)
+	return sendError(c, "Something error with `"+operation+"` operation")
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/logging"
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLogging(t *testing.T, format string) (*fiber.App, *model.CacheAppContext, *bytes.Buffer) {
+	output := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.New(output, format))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	assert.NoError(t, err)
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute}
+
+	app := fiber.New()
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.AccessLogMiddleware())
+	app.Get("/get", func(c fiber.Ctx) error { return GetCache(c, ctx) })
+	app.Post("/create", func(c fiber.Ctx) error { return CreateCache(c, ctx) })
+
+	return app, ctx, output
+}
+
+// logRecords decodes the JSON lines logged so far
+func logRecords(t *testing.T, output *bytes.Buffer) []map[string]any {
+	records := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		record := map[string]any{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestAccessLogCarriesRequestID(t *testing.T) {
+	app, _, output := setUpLogging(t, logging.FormatJSON)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/create", strings.NewReader(`{"key":"user:1","value":"v","duration_in_seconds":60}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderXRequestID, "request-1")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "request-1", resp.Header.Get(fiber.HeaderXRequestID))
+
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/get?key=user:1", nil))
+	assert.NoError(t, err)
+	generated := resp.Header.Get(fiber.HeaderXRequestID)
+	assert.Len(t, generated, 32)
+
+	logged := logRecords(t, output)
+	assert.Len(t, logged, 2)
+
+	assert.Equal(t, "request", logged[0]["msg"])
+	assert.Equal(t, "request-1", logged[0]["request_id"])
+	assert.Equal(t, "POST", logged[0]["method"])
+	assert.Equal(t, "/create", logged[0]["route"])
+	assert.Equal(t, float64(200), logged[0]["status"])
+	assert.Equal(t, model.KeyHash("user:1"), logged[0]["key_hash"])
+	assert.Greater(t, logged[0]["bytes"], float64(0))
+	assert.Contains(t, logged[0], "latency")
+
+	assert.Equal(t, generated, logged[1]["request_id"])
+	assert.Equal(t, model.KeyHash("user:1"), logged[1]["key_hash"])
+	assert.NotContains(t, output.String(), "user:1")
+}
+
+func TestErrorLogsCarryRequestID(t *testing.T) {
+	app, ctx, output := setUpLogging(t, logging.FormatJSON)
+	assert.NoError(t, ctx.Cache.Set("broken", []byte("not json")))
+
+	req := httptest.NewRequest(fiber.MethodGet, "/get?key=broken", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "request-2")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+
+	logged := logRecords(t, output)
+	assert.Len(t, logged, 2)
+	assert.Equal(t, "ERROR", logged[0]["level"])
+	assert.Equal(t, "GetCache", logged[0]["operation"])
+	assert.Equal(t, "request-2", logged[0]["request_id"])
+	assert.Equal(t, "request-2", logged[1]["request_id"])
+}
+
+func TestAccessLogTextFormat(t *testing.T) {
+	app, _, output := setUpLogging(t, logging.FormatText)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/get?key=missing", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "request-3")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+
+	assert.Contains(t, output.String(), "msg=request method=GET route=/get status=200")
+	assert.Contains(t, output.String(), "request_id=request-3")
+}
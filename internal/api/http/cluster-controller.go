@@ -0,0 +1,61 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+type clusterMembersRequest struct {
+	Members []string `json:"members"`
+}
+
+func GetClusterInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Cluster == nil {
+		return sendError(c, "Cluster mode is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.Cluster.Info(),
+	})
+}
+
+// SetClusterMembers replaces the member list of this node, keys it no longer
+// owns are moved to their new owner in the background
+func SetClusterMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Cluster == nil {
+		return sendError(c, "Cluster mode is disabled")
+	}
+
+	membersReq := new(clusterMembersRequest)
+	if err := c.Bind().Body(membersReq); err != nil {
+		return err
+	}
+
+	ctx.Cluster.SetMembers(membersReq.Members)
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Cluster members updated",
+		"cache":   ctx.Cluster.Info(),
+	})
+}
+
+func GetKeyOwner(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Cluster == nil {
+		return sendError(c, "Cluster mode is disabled")
+	}
+
+	key := c.Query("key")
+	owner := ctx.Cluster.Owner(key)
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":   key,
+			"owner": owner,
+			"local": owner == ctx.Cluster.Self(),
+		},
+	})
+}
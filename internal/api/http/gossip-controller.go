@@ -0,0 +1,57 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func GetGossipMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Membership == nil {
+		return sendError(c, "Gossip is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"members": ctx.Membership.Members(),
+		},
+	})
+}
+
+// JoinGossip contacts the members gossiping on the given addresses
+func JoinGossip(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Membership == nil {
+		return sendError(c, "Gossip is disabled")
+	}
+
+	joinReq := new(model.GossipJoinRequest)
+	if err := c.Bind().Body(joinReq); err != nil {
+		return err
+	}
+
+	if len(joinReq.Addresses) < 1 {
+		return c.JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Validation error",
+			"cache":   nil,
+			"validation_error": fiber.Map{
+				"addresses": "Value `addresses` cannot be empty",
+			},
+		})
+	}
+
+	joined, err := ctx.Membership.Join(joinReq.Addresses)
+	if err != nil {
+		return sendError(c, "Failed to join : "+err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Joined successfully",
+		"cache": fiber.Map{
+			"answered": joined,
+			"members":  ctx.Membership.Members(),
+		},
+	})
+}
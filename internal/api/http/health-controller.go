@@ -0,0 +1,81 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Liveness answers 503 when the store cannot be written and read back,
+// restarting the process is then the way out
+func Liveness(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return sendHealth(c, []model.HealthCheck{storeCheck(ctx)})
+}
+
+// Readiness answers 503 while the node should not receive traffic: the
+// cache is not restored yet, a replica is too far behind its primary,
+// the entries are over the max memory or the server is shutting down
+func Readiness(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	checks := []model.HealthCheck{
+		{Name: "shutdown", OK: !ctx.ShuttingDown(), Message: "Server is shutting down"},
+		{Name: "restore", OK: ctx.Restored(), Message: "Snapshot or append-only file is still loading"},
+		storeCheck(ctx),
+	}
+
+	if ctx.Replication != nil && ctx.Replication.Role() == model.RoleReplica {
+		info := ctx.Replication.Info()
+		checks = append(checks, model.HealthCheck{
+			Name:    "replication",
+			OK:      info.LinkUp && info.Lag <= ctx.MaxReplicationLag,
+			Message: fmt.Sprintf("Link up %v, %d records behind the primary", info.LinkUp, info.Lag),
+		})
+	}
+
+	if ctx.Eviction != nil {
+		info := ctx.Eviction.Info()
+		checks = append(checks, model.HealthCheck{
+			Name:    "memory",
+			OK:      info.UsedBytes <= info.MaxBytes,
+			Message: fmt.Sprintf("%d bytes used of %d", info.UsedBytes, info.MaxBytes),
+		})
+	}
+
+	return sendHealth(c, checks)
+}
+
+func storeCheck(ctx *model.CacheAppContext) model.HealthCheck {
+	check := model.HealthCheck{Name: "store", OK: true}
+	if err := ctx.CheckStore(); err != nil {
+		check.OK = false
+		check.Message = err.Error()
+	}
+
+	return check
+}
+
+// sendHealth answers 200 when every check passed, 503 otherwise.
+// Messages are only kept for the failed checks.
+func sendHealth(c fiber.Ctx, checks []model.HealthCheck) error {
+	healthy := true
+	for i := range checks {
+		if checks[i].OK {
+			checks[i].Message = ""
+		} else {
+			healthy = false
+		}
+	}
+
+	if !healthy {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Some checks failed",
+			"cache":   fiber.Map{"checks": checks},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  fiber.Map{"checks": checks},
+	})
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/eviction"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// replicaStub is a replica reporting a fixed replication state
+type replicaStub struct {
+	model.Replication
+	info model.ReplicationInfo
+}
+
+func (replica *replicaStub) Role() string                { return model.RoleReplica }
+func (replica *replicaStub) Info() model.ReplicationInfo { return replica.info }
+
+func setUpHealthApp(t *testing.T) (*fiber.App, *model.CacheAppContext) {
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	assert.NoError(t, err)
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute, MaxReplicationLag: 10}
+
+	app := fiber.New()
+	app.Get("/healthz", func(c fiber.Ctx) error { return Liveness(c, ctx) })
+	app.Get("/readyz", func(c fiber.Ctx) error { return Readiness(c, ctx) })
+
+	return app, ctx
+}
+
+// probe returns the status code and the names of the failed checks
+func probe(t *testing.T, app *fiber.App, target string) (int, []string) {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	assert.NoError(t, err)
+
+	response := struct {
+		Cache struct {
+			Checks []model.HealthCheck `json:"checks"`
+		} `json:"cache"`
+	}{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+
+	failed := []string{}
+	for _, check := range response.Cache.Checks {
+		if !check.OK {
+			failed = append(failed, check.Name)
+		}
+	}
+	return resp.StatusCode, failed
+}
+
+func TestLivenessChecksStore(t *testing.T) {
+	app, ctx := setUpHealthApp(t)
+
+	status, failed := probe(t, app, "/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, failed)
+
+	// The probe entry is not left behind
+	assert.Equal(t, 0, ctx.Cache.Len())
+}
+
+func TestReadinessFollowsLifecycle(t *testing.T) {
+	app, ctx := setUpHealthApp(t)
+
+	status, failed := probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, []string{"restore"}, failed)
+
+	ctx.SetRestored()
+	status, failed = probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, failed)
+
+	ctx.SetShuttingDown()
+	status, failed = probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, []string{"shutdown"}, failed)
+
+	// Liveness does not depend on the lifecycle
+	status, _ = probe(t, app, "/healthz")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestReadinessChecksReplicationLag(t *testing.T) {
+	app, ctx := setUpHealthApp(t)
+	ctx.SetRestored()
+	replica := &replicaStub{info: model.ReplicationInfo{Role: model.RoleReplica, LinkUp: true, Lag: 10}}
+	ctx.Replication = replica
+
+	status, _ := probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+
+	replica.info.Lag = 11
+	status, failed := probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, []string{"replication"}, failed)
+
+	replica.info = model.ReplicationInfo{Role: model.RoleReplica}
+	status, failed = probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, []string{"replication"}, failed)
+}
+
+func TestReadinessChecksMemory(t *testing.T) {
+	app, ctx := setUpHealthApp(t)
+	ctx.SetRestored()
+	manager, err := eviction.New(model.MaxMemoryNoEviction, "", 4*1024)
+	assert.NoError(t, err)
+	ctx.Eviction = manager
+
+	value := strings.Repeat("x", 900)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.NoError(t, ctx.SetEntry(key, model.CacheEntry{Value: value, Expiration: time.Now().Add(time.Minute)}))
+	}
+	status, _ := probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+
+	// noeviction lets the entries go over the max memory
+	assert.NoError(t, ctx.SetEntry("e", model.CacheEntry{Value: value, Expiration: time.Now().Add(time.Minute)}))
+	status, failed := probe(t, app, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, []string{"memory"}, failed)
+}
@@ -0,0 +1,166 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/probabilistic"
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func AddHyperLogLog(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	hllReq := new(model.HyperLogLogAddRequest)
+	if err := c.Bind().Body(hllReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(hllReq.Key) == "" {
+		return sendError(c, "Cache `key` cannot be empty")
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, hll, err := loadHyperLogLogForWrite(c.UserContext(), ctx, hllReq.Key, hllReq.DurationInSeconds)
+	if err != nil {
+		return sendEntryError(c, err, "AddHyperLogLog")
+	}
+
+	updated := false
+	for _, element := range hllReq.Elements {
+		if hll.Add(element) {
+			updated = true
+		}
+	}
+
+	entry.Value = hll.Registers
+	if err := ctx.SetEntryContext(c.UserContext(), hllReq.Key, entry); err != nil {
+		return sendEntryError(c, err, "AddHyperLogLog")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Elements added successfully",
+		"cache": fiber.Map{
+			"key":     hllReq.Key,
+			"updated": updated,
+		},
+	})
+}
+
+// CountHyperLogLog estimates the cardinality of the union of comma separated `keys`
+func CountHyperLogLog(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	keys := splitKeys(c.Query("keys"))
+	if len(keys) < 1 {
+		return sendError(c, "Query `keys` cannot be empty")
+	}
+
+	ctx.Lock()
+	union, err := mergeHyperLogLogs(c.UserContext(), ctx, keys)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "CountHyperLogLog")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"keys":  keys,
+			"count": union.Count(),
+		},
+	})
+}
+
+// MergeHyperLogLog stores the union of `sources` and `destination` into `destination`
+func MergeHyperLogLog(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	mergeReq := new(model.HyperLogLogMergeRequest)
+	if err := c.Bind().Body(mergeReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(mergeReq.Destination) == "" {
+		return sendError(c, "Cache `destination` cannot be empty")
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, hll, err := loadHyperLogLogForWrite(c.UserContext(), ctx, mergeReq.Destination, mergeReq.DurationInSeconds)
+	if err != nil {
+		return sendEntryError(c, err, "MergeHyperLogLog")
+	}
+
+	union, err := mergeHyperLogLogs(c.UserContext(), ctx, mergeReq.Sources)
+	if err != nil {
+		return sendEntryError(c, err, "MergeHyperLogLog")
+	}
+	hll.Merge(union)
+
+	entry.Value = hll.Registers
+	if err := ctx.SetEntryContext(c.UserContext(), mergeReq.Destination, entry); err != nil {
+		return sendEntryError(c, err, "MergeHyperLogLog")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "HyperLogLog merged successfully",
+		"cache": fiber.Map{
+			"key":   mergeReq.Destination,
+			"count": hll.Count(),
+		},
+	})
+}
+
+// loadHyperLogLogForWrite returns the existing HyperLogLog or a fresh one
+// when the key is missing. Must be called while holding ctx.Lock.
+func loadHyperLogLogForWrite(parent context.Context, ctx *model.CacheAppContext, key string, durationInSeconds int) (model.CacheEntry, *probabilistic.HyperLogLog, error) {
+	entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeHLL)
+	if err != nil && isCacheExists(err) {
+		return entry, nil, err
+	}
+
+	if err != nil || durationInSeconds > 0 {
+		entry.Type = model.EntryTypeHLL
+		entry.Expiration = ctx.ExpirationFor(durationInSeconds)
+	}
+
+	if err != nil {
+		return entry, probabilistic.NewHyperLogLog(), nil
+	}
+
+	hll, err := decodeHyperLogLog(entry)
+	return entry, hll, err
+}
+
+// mergeHyperLogLogs returns the union of keys, missing keys count as empty.
+// Must be called while holding ctx.Lock.
+func mergeHyperLogLogs(parent context.Context, ctx *model.CacheAppContext, keys []string) (*probabilistic.HyperLogLog, error) {
+	union := probabilistic.NewHyperLogLog()
+	for _, key := range keys {
+		entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeHLL)
+		if err != nil {
+			if isCacheExists(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		hll, err := decodeHyperLogLog(entry)
+		if err != nil {
+			return nil, err
+		}
+		union.Merge(hll)
+	}
+
+	return union, nil
+}
+
+func decodeHyperLogLog(entry model.CacheEntry) (*probabilistic.HyperLogLog, error) {
+	registers := []byte{}
+	if err := entry.DecodeValue(&registers); err != nil {
+		return nil, err
+	}
+
+	return probabilistic.LoadHyperLogLog(registers)
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpProbabilisticApp() *fiber.App {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Post("/hll/pfadd", func(c fiber.Ctx) error { return AddHyperLogLog(c, ctx) })
+	app.Get("/hll/pfcount", func(c fiber.Ctx) error { return CountHyperLogLog(c, ctx) })
+	app.Post("/hll/pfmerge", func(c fiber.Ctx) error { return MergeHyperLogLog(c, ctx) })
+	app.Post("/bloom/create", func(c fiber.Ctx) error { return CreateBloomFilter(c, ctx) })
+	app.Post("/bloom/add", func(c fiber.Ctx) error { return AddBloomFilter(c, ctx) })
+	app.Get("/bloom/exists", func(c fiber.Ctx) error { return ExistsBloomFilter(c, ctx) })
+	app.Post("/bloom/mexists", func(c fiber.Ctx) error { return MultiExistsBloomFilter(c, ctx) })
+
+	return app
+}
+
+func TestHyperLogLogCommands(t *testing.T) {
+	app := setUpProbabilisticApp()
+
+	doMapRequest(t, app, http.MethodPost, "/hll/pfadd", `{"key":"monday","elements":["u1","u2","u3"]}`)
+	doMapRequest(t, app, http.MethodPost, "/hll/pfadd", `{"key":"tuesday","elements":["u3","u4"]}`)
+
+	response := doMapRequest(t, app, http.MethodGet, "/hll/pfcount?keys=monday", "")
+	assert.Equal(t, float64(3), response["cache"].(map[string]any)["count"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/hll/pfcount?keys=monday,tuesday", "")
+	assert.Equal(t, float64(4), response["cache"].(map[string]any)["count"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/hll/pfmerge", `{"destination":"week","sources":["monday","tuesday"]}`)
+	assert.Equal(t, float64(4), response["cache"].(map[string]any)["count"])
+}
+
+func TestBloomFilterCommands(t *testing.T) {
+	app := setUpProbabilisticApp()
+
+	response := doMapRequest(t, app, http.MethodPost, "/bloom/create", `{"key":"seen","error_rate":0.001,"capacity":100}`)
+	assert.Equal(t, "OK", response["status"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/bloom/create", `{"key":"seen","error_rate":0.001,"capacity":100}`)
+	assert.Equal(t, "Key already exists", response["message"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/bloom/create", `{"key":"huge","error_rate":0.001,"capacity":1000000000000}`)
+	assert.Equal(t, "ERROR", response["status"])
+	assert.Contains(t, response["message"], "Bloom filter is too large")
+
+	response = doMapRequest(t, app, http.MethodPost, "/bloom/add", `{"key":"seen","items":["a","b","a"]}`)
+	assert.Equal(t, []any{true, true, false}, response["cache"].(map[string]any)["added"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/bloom/exists?key=seen&item=a", "")
+	assert.Equal(t, true, response["cache"].(map[string]any)["exists"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/bloom/mexists", `{"key":"seen","items":["b","c"]}`)
+	assert.Equal(t, []any{true, false}, response["cache"].(map[string]any)["exists"])
+}
+
+func TestBloomFilterStaysUnderMaxEntrySize(t *testing.T) {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+		MaxEntrySize:      8192,
+	}
+	app.Post("/bloom/add", func(c fiber.Ctx) error { return AddBloomFilter(c, ctx) })
+
+	response := map[string]any{}
+	for i := 0; response["status"] != "ERROR"; i++ {
+		response = doMapRequest(t, app, http.MethodPost, "/bloom/add", `{"key":"seen","items":["`+strconv.Itoa(i)+`"]}`)
+	}
+	assert.Equal(t, "Bloom filter cannot grow past the max entry size, nothing was added", response["message"])
+
+	// The filter kept so far is still served
+	_, err := ctx.GetEntry("seen")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// infoSections are reported by GetInfo when no section is asked for
+var infoSections = []string{"server", "config", "stats", "commandstats", "memory", "keyspace"}
+
+// GetInfo reports the state of the server like Redis INFO. The `section` query
+// is a comma separated list of infoSections, all of them without it.
+// `keyspace` iterates over every entry to count them by namespace.
+func GetInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	sections := infoSections
+	if section := c.Query("section"); section != "" && section != "all" {
+		sections = strings.Split(section, ",")
+	}
+
+	info := fiber.Map{}
+	for _, section := range sections {
+		section = strings.TrimSpace(section)
+		switch section {
+		case "server":
+			info[section] = serverInfo(ctx)
+		case "config":
+			info[section] = configInfo(ctx)
+		case "stats":
+			info[section] = statsInfo(ctx)
+		case "commandstats":
+			info[section] = commandStatsInfo(ctx)
+		case "memory":
+			info[section] = memoryInfo(ctx)
+		case "keyspace":
+			info[section] = keyspaceInfo(ctx)
+		default:
+			return sendError(c, "Unknown section `"+section+"`, should be `all` or any of `"+strings.Join(infoSections, "`, `")+"`")
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  info,
+	})
+}
+
+func serverInfo(ctx *model.CacheAppContext) fiber.Map {
+	server := fiber.Map{
+		"version":    config.Version,
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"pid":        os.Getpid(),
+		"goroutines": runtime.NumGoroutine(),
+	}
+
+	if !ctx.StartedAt.IsZero() {
+		server["started_at"] = ctx.StartedAt
+		server["uptime_in_seconds"] = int64(time.Since(ctx.StartedAt).Seconds())
+	}
+
+	// Go records the commit it built from, unless built with -buildvcs=false
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				server["revision"] = setting.Value
+			case "vcs.time":
+				server["revision_time"] = setting.Value
+			case "vcs.modified":
+				server["modified"] = setting.Value == "true"
+			}
+		}
+	}
+
+	return server
+}
+
+// configInfo summarizes the settings, `GET /admin/config` lists all of them
+func configInfo(ctx *model.CacheAppContext) fiber.Map {
+	summary := fiber.Map{
+		"default_expiration_in_seconds": int64(ctx.GetDefaultExpiration().Seconds()),
+		"max_entry_size":                ctx.MaxEntrySize,
+		"auth":                          ctx.AuthKey() != "",
+		"snapshot":                      ctx.Snapshotter != nil,
+		"second_tier":                   ctx.SecondTier != nil,
+		"cluster":                       ctx.Cluster != nil,
+		"peers":                         ctx.Peers != nil,
+		"invalidation":                  ctx.Invalidator != nil,
+		"gossip":                        ctx.Membership != nil,
+		"metrics":                       ctx.Metrics != nil,
+	}
+
+	if ctx.Config != nil {
+		summary["file"] = ctx.Config.Source()
+	}
+	if ctx.Replication != nil {
+		summary["replication_role"] = ctx.Replication.Role()
+	}
+	if ctx.Eviction != nil {
+		info := ctx.Eviction.Info()
+		summary["max_memory_policy"] = info.MaxMemoryPolicy
+		summary["eviction_policy"] = info.Policy
+	}
+
+	return summary
+}
+
+func statsInfo(ctx *model.CacheAppContext) fiber.Map {
+	stats := ctx.Cache.Stats()
+
+	hitRatio := 0.0
+	if reads := stats.Hits + stats.Misses; reads > 0 {
+		hitRatio = float64(stats.Hits) / float64(reads)
+	}
+
+	return fiber.Map{
+		"hits":       stats.Hits,
+		"misses":     stats.Misses,
+		"hit_ratio":  hitRatio,
+		"del_hits":   stats.DelHits,
+		"del_misses": stats.DelMisses,
+		"collisions": stats.Collisions,
+	}
+}
+
+// commandStatsInfo reports the latency of every route like Redis INFO commandstats
+func commandStatsInfo(ctx *model.CacheAppContext) map[string]model.OperationStats {
+	if ctx.SlowLog == nil {
+		return map[string]model.OperationStats{}
+	}
+
+	return ctx.SlowLog.Stats()
+}
+
+func memoryInfo(ctx *model.CacheAppContext) fiber.Map {
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+
+	memory := fiber.Map{
+		"capacity":     ctx.Cache.Capacity(),
+		"heap_alloc":   memStats.HeapAlloc,
+		"heap_inuse":   memStats.HeapInuse,
+		"heap_objects": memStats.HeapObjects,
+		"sys":          memStats.Sys,
+		"num_gc":       memStats.NumGC,
+	}
+	if ctx.Eviction != nil {
+		memory["max_memory"] = ctx.Eviction.Info()
+	}
+
+	return memory
+}
+
+func keyspaceInfo(ctx *model.CacheAppContext) fiber.Map {
+	keyspace := fiber.Map{
+		"entries":    ctx.Cache.Len(),
+		"namespaces": ctx.CountByNamespace(),
+	}
+	if ctx.SecondTier != nil {
+		keyspace["second_tier_entries"] = ctx.SecondTier.Info().Entries
+	}
+
+	return keyspace
+}
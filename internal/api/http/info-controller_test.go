@@ -0,0 +1,86 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpInfoApp(t *testing.T) (*fiber.App, *model.CacheAppContext) {
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	assert.NoError(t, err)
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute, StartedAt: time.Now().Add(-time.Hour)}
+
+	app := fiber.New()
+	app.Get("/admin/info", func(c fiber.Ctx) error { return GetInfo(c, ctx) })
+
+	return app, ctx
+}
+
+func getInfo(t *testing.T, app *fiber.App, target string) map[string]any {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	assert.NoError(t, err)
+
+	response := map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+func TestInfoReportsEverySection(t *testing.T) {
+	app, _ := setUpInfoApp(t)
+
+	response := getInfo(t, app, "/admin/info")
+	assert.Equal(t, "OK", response["status"])
+
+	info := response["cache"].(map[string]any)
+	for _, section := range infoSections {
+		assert.Contains(t, info, section)
+	}
+
+	server := info["server"].(map[string]any)
+	assert.Equal(t, "dev", server["version"])
+	assert.GreaterOrEqual(t, server["uptime_in_seconds"], float64(3600))
+	assert.Greater(t, server["goroutines"], float64(0))
+}
+
+func TestInfoSelectsSections(t *testing.T) {
+	app, ctx := setUpInfoApp(t)
+	expiration := time.Now().Add(time.Minute)
+
+	for _, key := range []string{"user:1", "user:2", "order:1", "plain"} {
+		assert.NoError(t, ctx.SetEntry(key, model.CacheEntry{Value: "v", Expiration: expiration}))
+	}
+	_, _ = ctx.GetRaw("user:1")
+	_, _ = ctx.GetRaw("missing")
+	assert.NoError(t, ctx.DeleteEntry("order:1"))
+
+	response := getInfo(t, app, "/admin/info?section=stats,keyspace")
+	info := response["cache"].(map[string]any)
+	assert.Len(t, info, 2)
+
+	stats := info["stats"].(map[string]any)
+	assert.Equal(t, float64(1), stats["hits"])
+	assert.Equal(t, float64(1), stats["misses"])
+	assert.Equal(t, float64(1), stats["del_hits"])
+	assert.Equal(t, 0.5, stats["hit_ratio"])
+
+	keyspace := info["keyspace"].(map[string]any)
+	assert.Equal(t, float64(3), keyspace["entries"])
+	assert.Equal(t, map[string]any{"user": float64(2), "default": float64(1)}, keyspace["namespaces"])
+}
+
+func TestInfoRejectsUnknownSection(t *testing.T) {
+	app, _ := setUpInfoApp(t)
+
+	response := getInfo(t, app, "/admin/info?section=stats,replication")
+	assert.Equal(t, "ERROR", response["status"])
+	assert.Contains(t, response["message"], "`replication`")
+}
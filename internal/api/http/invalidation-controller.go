@@ -0,0 +1,100 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ReceiveInvalidation applies an invalidation broadcast by another node
+func ReceiveInvalidation(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Invalidator == nil {
+		return sendError(c, "Invalidation broadcast is disabled")
+	}
+
+	invalidation := new(model.Invalidation)
+	if err := c.Bind().Body(invalidation); err != nil {
+		return err
+	}
+
+	if valid, err := validateInvalidation(*invalidation); !valid {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	applied, err := ctx.Invalidator.Receive(*invalidation)
+	if err != nil {
+		log.Printf("Error occured when applying invalidation `%s` : %v", invalidation.ID, err.Error())
+		return sendError(c, "Something error with `ReceiveInvalidation` operation")
+	}
+
+	message := "Invalidation applied"
+	if !applied {
+		message = "Invalidation already applied"
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": message,
+		"cache": fiber.Map{
+			"id": invalidation.ID,
+		},
+	})
+}
+
+func GetInvalidationInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Invalidator == nil {
+		return sendError(c, "Invalidation broadcast is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.Invalidator.Info(),
+	})
+}
+
+// broadcastInvalidation sends an invalidation already applied locally to the
+// other nodes. Params point into a buffer Fiber reuses, the broadcast is
+// asynchronous so they are copied.
+func broadcastInvalidation(ctx *model.CacheAppContext, invalidation model.Invalidation) {
+	if ctx.Invalidator == nil {
+		return
+	}
+
+	invalidation.Key = strings.Clone(invalidation.Key)
+	invalidation.Tag = strings.Clone(invalidation.Tag)
+	ctx.Invalidator.Broadcast(invalidation)
+}
+
+func validateInvalidation(request model.Invalidation) (bool, map[string]any) {
+	validationErr := make(map[string]any)
+	if request.ID == "" {
+		validationErr["id"] = "Invalidation `id` cannot be empty"
+	}
+
+	switch request.Op {
+	case model.InvalidateKey:
+		if request.Key == "" {
+			validationErr["key"] = "Invalidation `key` cannot be empty"
+		}
+	case model.InvalidateTag:
+		if request.Tag == "" {
+			validationErr["tag"] = "Invalidation `tag` cannot be empty"
+		}
+	case model.InvalidateFlush:
+	default:
+		validationErr["op"] = "Value `op` should be one of `del`, `tag` or `flush`"
+	}
+
+	if len(validationErr) < 1 {
+		return true, nil
+	}
+
+	return false, validationErr
+}
@@ -0,0 +1,352 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func LeftPushList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return pushList(c, ctx, true)
+}
+
+func RightPushList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return pushList(c, ctx, false)
+}
+
+func LeftPopList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return popList(c, ctx, true)
+}
+
+func RightPopList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return popList(c, ctx, false)
+}
+
+func BlockingLeftPopList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return blockingPopList(c, ctx, true)
+}
+
+func BlockingRightPopList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return blockingPopList(c, ctx, false)
+}
+
+func RangeList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	start := fiber.Query[int](c, "start", 0)
+	stop := fiber.Query[int](c, "stop", -1)
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeList)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "RangeList")
+	}
+
+	values := listValues(entry)
+	from, to, ok := normalizeRange(start, stop, len(values))
+	result := []any{}
+	if ok {
+		result = values[from : to+1]
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":    key,
+			"values": result,
+		},
+	})
+}
+
+func TrimList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	trimReq := new(model.ListTrimRequest)
+	if err := c.Bind().Body(trimReq); err != nil {
+		return err
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), trimReq.Key, model.EntryTypeList)
+	if err != nil {
+		return sendEntryError(c, err, "TrimList")
+	}
+
+	values := listValues(entry)
+	from, to, ok := normalizeRange(trimReq.Start, trimReq.Stop, len(values))
+	if !ok {
+		values = nil
+	} else {
+		values = values[from : to+1]
+	}
+
+	if err := saveList(c.UserContext(), ctx, trimReq.Key, entry, values); err != nil {
+		return sendEntryError(c, err, "TrimList")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "List trimmed successfully",
+		"cache": fiber.Map{
+			"key":    trimReq.Key,
+			"length": len(values),
+		},
+	})
+}
+
+func LengthList(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeList)
+	ctx.Unlock()
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "LengthList")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":    key,
+			"length": len(listValues(entry)),
+		},
+	})
+}
+
+func pushList(c fiber.Ctx, ctx *model.CacheAppContext, left bool) error {
+	pushReq := new(model.ListPushRequest)
+	if err := c.Bind().Body(pushReq); err != nil {
+		return err
+	}
+
+	if valid, err := validateListPush(*pushReq); !valid {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), pushReq.Key, model.EntryTypeList)
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "PushList")
+	}
+
+	if err != nil || pushReq.DurationInSeconds > 0 {
+		entry.Type = model.EntryTypeList
+		entry.Expiration = ctx.ExpirationFor(pushReq.DurationInSeconds)
+	}
+
+	values := listValues(entry)
+	if left {
+		pushed := make([]any, 0, len(values)+len(pushReq.Values))
+		for i := len(pushReq.Values) - 1; i >= 0; i-- {
+			pushed = append(pushed, pushReq.Values[i])
+		}
+		values = append(pushed, values...)
+	} else {
+		values = append(values, pushReq.Values...)
+	}
+
+	if err := saveList(c.UserContext(), ctx, pushReq.Key, entry, values); err != nil {
+		return sendEntryError(c, err, "PushList")
+	}
+	ctx.Notify(pushReq.Key)
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Values pushed successfully",
+		"cache": fiber.Map{
+			"key":    pushReq.Key,
+			"length": len(values),
+		},
+	})
+}
+
+func popList(c fiber.Ctx, ctx *model.CacheAppContext, left bool) error {
+	popReq := new(model.ListPopRequest)
+	if err := c.Bind().Body(popReq); err != nil {
+		return err
+	}
+
+	if popReq.Count < 1 {
+		popReq.Count = 1
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	popped, err := popListValues(c.UserContext(), ctx, popReq.Key, popReq.Count, left)
+	if err != nil {
+		return sendEntryError(c, err, "PopList")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Values popped successfully",
+		"cache": fiber.Map{
+			"key":    popReq.Key,
+			"values": popped,
+		},
+	})
+}
+
+// blockingPopList long-polls until a value is pushed to the list,
+// the timeout elapses or the server shuts down
+func blockingPopList(c fiber.Ctx, ctx *model.CacheAppContext, left bool) error {
+	popReq := new(model.ListBlockingPopRequest)
+	if err := c.Bind().Body(popReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(popReq.Key) == "" {
+		return sendError(c, "Cache `key` cannot be empty")
+	}
+
+	timeout := time.Duration(popReq.TimeoutInSeconds) * time.Second
+	if popReq.TimeoutInSeconds < 1 || popReq.TimeoutInSeconds > config.MAX_BLOCKING_TIMEOUT_IN_SECONDS {
+		timeout = config.MAX_BLOCKING_TIMEOUT_IN_SECONDS * time.Second
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		ctx.Lock()
+		popped, err := popListValues(c.UserContext(), ctx, popReq.Key, 1, left)
+		if err == nil {
+			ctx.Unlock()
+			return c.JSON(fiber.Map{
+				"status":  "OK",
+				"message": "Values popped successfully",
+				"cache": fiber.Map{
+					"key":    popReq.Key,
+					"values": popped,
+				},
+			})
+		}
+
+		if isCacheExists(err) {
+			ctx.Unlock()
+			return sendEntryError(c, err, "BlockingPopList")
+		}
+
+		wait := ctx.Wait(popReq.Key)
+		ctx.Unlock()
+
+		select {
+		case <-wait:
+		case <-timer.C:
+			ctx.StopWaiting(popReq.Key, wait)
+			return c.JSON(fiber.Map{
+				"status":  "OK",
+				"message": "Timeout waiting for list values",
+				"cache":   nil,
+			})
+		case <-c.Context().Done():
+			ctx.StopWaiting(popReq.Key, wait)
+			return sendError(c, "Server is shutting down")
+		}
+	}
+}
+
+// popListValues removes up to count values from one end of the list.
+// Must be called while holding ctx.Lock.
+func popListValues(parent context.Context, ctx *model.CacheAppContext, key string, count int, left bool) ([]any, error) {
+	entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeList)
+	if err != nil {
+		return nil, err
+	}
+
+	values := listValues(entry)
+	if count > len(values) {
+		count = len(values)
+	}
+
+	popped := make([]any, 0, count)
+	if left {
+		popped = append(popped, values[:count]...)
+		values = values[count:]
+	} else {
+		for i := len(values) - 1; i >= len(values)-count; i-- {
+			popped = append(popped, values[i])
+		}
+		values = values[:len(values)-count]
+	}
+
+	return popped, saveList(parent, ctx, key, entry, values)
+}
+
+// saveList stores the list values, an empty list removes the key
+func saveList(parent context.Context, ctx *model.CacheAppContext, key string, entry model.CacheEntry, values []any) error {
+	if len(values) == 0 {
+		return ctx.DeleteEntryContext(parent, key)
+	}
+
+	entry.Value = values
+	return ctx.SetEntryContext(parent, key, entry)
+}
+
+func listValues(entry model.CacheEntry) []any {
+	values, ok := entry.Value.([]any)
+	if !ok {
+		return nil
+	}
+
+	return values
+}
+
+// normalizeRange converts inclusive start/stop indexes, which may be negative
+// to count from the tail, into bounds inside a list of the given length
+func normalizeRange(start int, stop int, length int) (int, int, bool) {
+	if start < 0 {
+		start += length
+	}
+
+	if stop < 0 {
+		stop += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= length {
+		stop = length - 1
+	}
+
+	if start > stop || start >= length {
+		return 0, 0, false
+	}
+
+	return start, stop, true
+}
+
+func validateListPush(request model.ListPushRequest) (bool, map[string]any) {
+	validationErr := make(map[string]any)
+	if strings.TrimSpace(request.Key) == "" {
+		validationErr["key"] = "Cache `key` cannot be empty"
+	}
+
+	if len(request.Values) < 1 {
+		validationErr["values"] = "List `values` cannot be empty"
+	}
+
+	if request.DurationInSeconds < 0 {
+		validationErr["duration_in_seconds"] = "Value `duration_in_seconds` should be >= 0"
+	}
+
+	if len(validationErr) < 1 {
+		return true, nil
+	}
+
+	return false, validationErr
+}
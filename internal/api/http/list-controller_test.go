@@ -0,0 +1,129 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type ListResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Cache   struct {
+		Key    string `json:"key"`
+		Values []any  `json:"values"`
+		Length int    `json:"length"`
+	} `json:"cache"`
+}
+
+func setUpListApp() *fiber.App {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Post("/list/lpush", func(c fiber.Ctx) error { return LeftPushList(c, ctx) })
+	app.Post("/list/rpush", func(c fiber.Ctx) error { return RightPushList(c, ctx) })
+	app.Post("/list/lpop", func(c fiber.Ctx) error { return LeftPopList(c, ctx) })
+	app.Post("/list/rpop", func(c fiber.Ctx) error { return RightPopList(c, ctx) })
+	app.Post("/list/blpop", func(c fiber.Ctx) error { return BlockingLeftPopList(c, ctx) })
+	app.Get("/list/lrange", func(c fiber.Ctx) error { return RangeList(c, ctx) })
+	app.Post("/list/ltrim", func(c fiber.Ctx) error { return TrimList(c, ctx) })
+	app.Get("/list/llen", func(c fiber.Ctx) error { return LengthList(c, ctx) })
+
+	return app
+}
+
+func doListRequest(t *testing.T, app *fiber.App, method string, target string, body string) ListResponse {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Error occurred while making request: %v", err)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var response ListResponse
+	assert.NoError(t, json.Unmarshal(bodyBytes, &response))
+	return response
+}
+
+func TestPushAndRangeList(t *testing.T) {
+	app := setUpListApp()
+
+	doListRequest(t, app, http.MethodPost, "/list/rpush", `{"key":"queue","values":["b","c"]}`)
+	response := doListRequest(t, app, http.MethodPost, "/list/lpush", `{"key":"queue","values":["a","z"]}`)
+	assert.Equal(t, "OK", response.Status)
+	assert.Equal(t, 4, response.Cache.Length)
+
+	response = doListRequest(t, app, http.MethodGet, "/list/lrange?key=queue&start=0&stop=-1", "")
+	assert.Equal(t, []any{"z", "a", "b", "c"}, response.Cache.Values)
+
+	response = doListRequest(t, app, http.MethodGet, "/list/lrange?key=queue&start=-2&stop=10", "")
+	assert.Equal(t, []any{"b", "c"}, response.Cache.Values)
+}
+
+func TestPopAndTrimList(t *testing.T) {
+	app := setUpListApp()
+
+	doListRequest(t, app, http.MethodPost, "/list/rpush", `{"key":"recent","values":[1,2,3,4,5]}`)
+
+	response := doListRequest(t, app, http.MethodPost, "/list/lpop", `{"key":"recent"}`)
+	assert.Equal(t, []any{float64(1)}, response.Cache.Values)
+
+	response = doListRequest(t, app, http.MethodPost, "/list/rpop", `{"key":"recent","count":2}`)
+	assert.Equal(t, []any{float64(5), float64(4)}, response.Cache.Values)
+
+	response = doListRequest(t, app, http.MethodPost, "/list/ltrim", `{"key":"recent","start":0,"stop":0}`)
+	assert.Equal(t, 1, response.Cache.Length)
+
+	response = doListRequest(t, app, http.MethodPost, "/list/lpop", `{"key":"recent","count":5}`)
+	assert.Equal(t, []any{float64(2)}, response.Cache.Values)
+
+	response = doListRequest(t, app, http.MethodGet, "/list/llen?key=recent", "")
+	assert.Equal(t, 0, response.Cache.Length)
+
+	response = doListRequest(t, app, http.MethodPost, "/list/lpop", `{"key":"recent"}`)
+	assert.Equal(t, "ERROR", response.Status)
+	assert.Equal(t, "Key not found", response.Message)
+}
+
+func TestPushListWithExpiredTTL(t *testing.T) {
+	app := setUpListApp()
+
+	doListRequest(t, app, http.MethodPost, "/list/rpush", `{"key":"short","values":["a"],"duration_in_seconds":1}`)
+	time.Sleep(1100 * time.Millisecond)
+
+	response := doListRequest(t, app, http.MethodGet, "/list/llen?key=short", "")
+	assert.Equal(t, 0, response.Cache.Length)
+}
+
+func TestBlockingPopList(t *testing.T) {
+	app := setUpListApp()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		doListRequest(t, app, http.MethodPost, "/list/rpush", `{"key":"jobs","values":["job-1"]}`)
+	}()
+
+	response := doListRequest(t, app, http.MethodPost, "/list/blpop", `{"key":"jobs","timeout_in_seconds":3}`)
+	assert.Equal(t, "OK", response.Status)
+	assert.Equal(t, []any{"job-1"}, response.Cache.Values)
+
+	response = doListRequest(t, app, http.MethodPost, "/list/blpop", `{"key":"jobs","timeout_in_seconds":1}`)
+	assert.Equal(t, "Timeout waiting for list values", response.Message)
+}
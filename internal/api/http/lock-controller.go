@@ -0,0 +1,277 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// AcquireLock takes the lock for `owner` when it is free or expired.
+// With `wait_in_seconds` the request long-polls until the lock is released,
+// its lease expires or the wait elapses.
+func AcquireLock(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	lockReq := new(model.LockRequest)
+	if err := c.Bind().Body(lockReq); err != nil {
+		return err
+	}
+
+	if valid, err := validateLock(*lockReq); !valid {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	if lockReq.Owner == "" {
+		lockReq.Owner = newOwnerToken()
+	}
+
+	wait := time.Duration(lockReq.WaitInSeconds) * time.Second
+	if lockReq.WaitInSeconds > config.MAX_BLOCKING_TIMEOUT_IN_SECONDS {
+		wait = config.MAX_BLOCKING_TIMEOUT_IN_SECONDS * time.Second
+	}
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+
+	for {
+		ctx.Lock()
+		entry, err := ctx.GetTypedEntryContext(c.UserContext(), lockReq.Key, model.EntryTypeLock)
+		if err != nil && isCacheExists(err) {
+			ctx.Unlock()
+			return sendEntryError(c, err, "AcquireLock")
+		}
+
+		if err != nil {
+			token, err := ctx.NextFencingToken(c.UserContext())
+			if err != nil {
+				ctx.Unlock()
+				return sendEntryError(c, err, "AcquireLock")
+			}
+
+			lock := model.LockValue{
+				Owner:        lockReq.Owner,
+				FencingToken: token,
+			}
+			entry = model.CacheEntry{
+				Type:       model.EntryTypeLock,
+				Value:      lock,
+				Expiration: time.Now().Add(time.Duration(lockReq.TTLInSeconds) * time.Second),
+			}
+			err = ctx.SetEntryContext(c.UserContext(), lockReq.Key, entry)
+			ctx.Unlock()
+			if err != nil {
+				return sendEntryError(c, err, "AcquireLock")
+			}
+
+			return sendLock(c, "Lock acquired", lockReq.Key, lock, entry)
+		}
+
+		if lockReq.WaitInSeconds < 1 {
+			ctx.Unlock()
+			return sendError(c, "Lock is held by another owner")
+		}
+
+		released := ctx.Wait(lockReq.Key)
+		ctx.Unlock()
+
+		leaseExpired := time.NewTimer(time.Until(entry.Expiration))
+		select {
+		case <-released:
+		case <-leaseExpired.C:
+			ctx.StopWaiting(lockReq.Key, released)
+		case <-deadline.C:
+			leaseExpired.Stop()
+			ctx.StopWaiting(lockReq.Key, released)
+			return sendError(c, "Timeout waiting for lock")
+		case <-c.Context().Done():
+			leaseExpired.Stop()
+			ctx.StopWaiting(lockReq.Key, released)
+			return sendError(c, "Server is shutting down")
+		}
+		leaseExpired.Stop()
+	}
+}
+
+// RenewLock extends the lease, only the current owner can renew
+func RenewLock(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	lockReq := new(model.LockRequest)
+	if err := c.Bind().Body(lockReq); err != nil {
+		return err
+	}
+
+	if valid, err := validateLock(*lockReq); !valid || lockReq.Owner == "" {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, lock, err := loadOwnedLock(c.UserContext(), ctx, lockReq.Key, lockReq.Owner)
+	if err != nil {
+		return sendLockError(c, err, "RenewLock")
+	}
+
+	entry.Expiration = time.Now().Add(time.Duration(lockReq.TTLInSeconds) * time.Second)
+	if err := ctx.SetEntryContext(c.UserContext(), lockReq.Key, entry); err != nil {
+		return sendEntryError(c, err, "RenewLock")
+	}
+
+	return sendLock(c, "Lock renewed", lockReq.Key, lock, entry)
+}
+
+// ReleaseLock frees the lock and wakes up waiters, only the current owner can release
+func ReleaseLock(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	lockReq := new(model.LockRequest)
+	if err := c.Bind().Body(lockReq); err != nil {
+		return err
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	_, _, err := loadOwnedLock(c.UserContext(), ctx, lockReq.Key, lockReq.Owner)
+	if err != nil {
+		return sendLockError(c, err, "ReleaseLock")
+	}
+
+	if err := ctx.DeleteEntryContext(c.UserContext(), lockReq.Key); err != nil {
+		return sendEntryError(c, err, "ReleaseLock")
+	}
+	ctx.Notify(lockReq.Key)
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Lock released",
+		"cache": fiber.Map{
+			"key": lockReq.Key,
+		},
+	})
+}
+
+func GetLock(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeLock)
+	ctx.Unlock()
+	if err != nil {
+		if !isCacheExists(err) {
+			return c.JSON(fiber.Map{
+				"status":  "OK",
+				"message": "Lock is free",
+				"cache": fiber.Map{
+					"key":    key,
+					"locked": false,
+				},
+			})
+		}
+
+		return sendEntryError(c, err, "GetLock")
+	}
+
+	lock := model.LockValue{}
+	if err := entry.DecodeValue(&lock); err != nil {
+		return sendEntryError(c, err, "GetLock")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Lock is held",
+		"cache": fiber.Map{
+			"key":           key,
+			"locked":        true,
+			"owner":         lock.Owner,
+			"fencing_token": lock.FencingToken,
+			"expiration":    entry.Expiration,
+		},
+	})
+}
+
+var errLockNotOwned = errors.New("lock is held by another owner")
+
+// loadOwnedLock returns the lock when it is held by owner.
+// Must be called while holding ctx.Lock.
+func loadOwnedLock(parent context.Context, ctx *model.CacheAppContext, key string, owner string) (model.CacheEntry, model.LockValue, error) {
+	lock := model.LockValue{}
+	entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeLock)
+	if err != nil {
+		return entry, lock, err
+	}
+
+	if err := entry.DecodeValue(&lock); err != nil {
+		return entry, lock, err
+	}
+
+	if lock.Owner != owner {
+		return entry, lock, errLockNotOwned
+	}
+
+	return entry, lock, nil
+}
+
+func sendLockError(c fiber.Ctx, err error, operation string) error {
+	if err == errLockNotOwned {
+		return sendError(c, "Lock is held by another owner")
+	}
+
+	if !isCacheExists(err) {
+		return sendError(c, "Lock is not held")
+	}
+
+	return sendEntryError(c, err, operation)
+}
+
+func sendLock(c fiber.Ctx, message string, key string, lock model.LockValue, entry model.CacheEntry) error {
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": message,
+		"cache": fiber.Map{
+			"key":           key,
+			"owner":         lock.Owner,
+			"fencing_token": lock.FencingToken,
+			"expiration":    entry.Expiration,
+		},
+	})
+}
+
+// newOwnerToken generates an owner for clients that do not bring their own
+func newOwnerToken() string {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+func validateLock(request model.LockRequest) (bool, map[string]any) {
+	validationErr := make(map[string]any)
+	if strings.TrimSpace(request.Key) == "" {
+		validationErr["key"] = "Lock `key` cannot be empty"
+	}
+
+	if request.TTLInSeconds < 1 {
+		validationErr["ttl_in_seconds"] = "Value `ttl_in_seconds` should be >= 1"
+	}
+
+	if request.WaitInSeconds < 0 {
+		validationErr["wait_in_seconds"] = "Value `wait_in_seconds` should be >= 0"
+	}
+
+	if len(validationErr) < 1 {
+		return true, nil
+	}
+
+	return false, validationErr
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLockApp() *fiber.App {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Post("/lock/acquire", func(c fiber.Ctx) error { return AcquireLock(c, ctx) })
+	app.Post("/lock/renew", func(c fiber.Ctx) error { return RenewLock(c, ctx) })
+	app.Post("/lock/release", func(c fiber.Ctx) error { return ReleaseLock(c, ctx) })
+	app.Get("/lock/status", func(c fiber.Ctx) error { return GetLock(c, ctx) })
+
+	return app
+}
+
+func TestLockOwnership(t *testing.T) {
+	app := setUpLockApp()
+
+	response := doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"cron","owner":"worker-1","ttl_in_seconds":10}`)
+	assert.Equal(t, "OK", response["status"])
+	firstToken := response["cache"].(map[string]any)["fencing_token"].(float64)
+
+	response = doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"cron","owner":"worker-2","ttl_in_seconds":10}`)
+	assert.Equal(t, "Lock is held by another owner", response["message"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/lock/release", `{"key":"cron","owner":"worker-2"}`)
+	assert.Equal(t, "Lock is held by another owner", response["message"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/lock/renew", `{"key":"cron","owner":"worker-1","ttl_in_seconds":20}`)
+	assert.Equal(t, "Lock renewed", response["message"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/lock/release", `{"key":"cron","owner":"worker-1"}`)
+	assert.Equal(t, "Lock released", response["message"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"cron","owner":"worker-2","ttl_in_seconds":10}`)
+	assert.Greater(t, response["cache"].(map[string]any)["fencing_token"].(float64), firstToken)
+}
+
+func TestLockWaitAndExclusion(t *testing.T) {
+	app := setUpLockApp()
+
+	doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"job","owner":"holder","ttl_in_seconds":1}`)
+
+	// The lease expires after one second, so the waiter gets the lock
+	response := doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"job","owner":"waiter","ttl_in_seconds":10,"wait_in_seconds":3}`)
+	assert.Equal(t, "Lock acquired", response["message"])
+	assert.Equal(t, "waiter", response["cache"].(map[string]any)["owner"])
+
+	var wg sync.WaitGroup
+	acquired := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response := doMapRequest(t, app, http.MethodPost, "/lock/acquire", `{"key":"race","ttl_in_seconds":10}`)
+			if response["status"] == "OK" {
+				acquired <- response["cache"].(map[string]any)["owner"].(string)
+			}
+		}()
+	}
+	wg.Wait()
+	close(acquired)
+
+	assert.Len(t, acquired, 1)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MemoryUsage reports how many bytes a key occupies in BigCache,
+// including the entry envelope and the BigCache entry header
+func MemoryUsage(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+
+	ctx.Lock()
+	entry, err := ctx.GetEntryContext(c.UserContext(), key)
+	data, _ := ctx.Cache.Get(key)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "MemoryUsage")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":            key,
+			"type":           entry.TypeName(),
+			"bytes":          model.EntrySize(key, data),
+			"max_entry_size": ctx.MaxEntrySize,
+		},
+	})
+}
+
+// GetMemoryInfo reports the memory BigCache reserved and, when a max memory
+// is set, the bytes accounted for the entries against it
+func GetMemoryInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	info := fiber.Map{
+		"entries":  ctx.Cache.Len(),
+		"capacity": ctx.Cache.Capacity(),
+	}
+	if ctx.Eviction != nil {
+		info["max_memory"] = ctx.Eviction.Info()
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  info,
+	})
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/eviction"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/tier"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpMemoryApp(t *testing.T, maxMemoryPolicy string) (*fiber.App, *model.CacheAppContext) {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	manager, err := eviction.New(maxMemoryPolicy, eviction.PolicyLRU, 4*1024)
+	assert.NoError(t, err)
+
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute, Eviction: manager}
+
+	app := fiber.New()
+	app.Post("/create", func(c fiber.Ctx) error { return CreateCache(c, ctx) })
+	app.Get("/admin/memory", func(c fiber.Ctx) error { return GetMemoryInfo(c, ctx) })
+
+	return app, ctx
+}
+
+func createKey(t *testing.T, app *fiber.App, key string, value string) (int, map[string]any) {
+	body, _ := json.Marshal(map[string]any{"key": key, "value": value, "duration_in_seconds": 60})
+	req := httptest.NewRequest(http.MethodPost, "/create", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	response := map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return resp.StatusCode, response
+}
+
+func TestMaxMemoryRejectsWrites(t *testing.T) {
+	app, ctx := setUpMemoryApp(t, model.MaxMemoryReject)
+	value := strings.Repeat("x", 900)
+
+	for i := 0; i < 4; i++ {
+		status, response := createKey(t, app, "key-"+strconv.Itoa(i), value)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "OK", response["status"])
+	}
+
+	status, response := createKey(t, app, "key-4", value)
+	assert.Equal(t, http.StatusInsufficientStorage, status)
+	assert.Equal(t, "ERROR", response["status"])
+	assert.Equal(t, 4, ctx.Cache.Len())
+
+	// Overwriting a key with a value of the same size still fits
+	status, _ = createKey(t, app, "key-0", value)
+	assert.Equal(t, http.StatusOK, status)
+
+	assert.NoError(t, ctx.DeleteEntry("key-1"))
+	status, _ = createKey(t, app, "key-4", value)
+	assert.Equal(t, http.StatusOK, status)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/admin/memory", nil))
+	assert.NoError(t, err)
+	response = map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+
+	info := response["cache"].(map[string]any)["max_memory"].(map[string]any)
+	assert.Equal(t, model.MaxMemoryReject, info["max_memory_policy"])
+	assert.Equal(t, float64(4), info["entries"])
+	assert.Equal(t, float64(1), info["rejected"])
+	assert.LessOrEqual(t, info["used_bytes"], float64(4*1024))
+}
+
+func TestMaxMemoryEvictsAndReports(t *testing.T) {
+	for _, maxMemoryPolicy := range []string{model.MaxMemoryEvict, model.MaxMemoryNoEviction} {
+		t.Run(maxMemoryPolicy, func(t *testing.T) {
+			app, ctx := setUpMemoryApp(t, maxMemoryPolicy)
+			value := strings.Repeat("x", 900)
+
+			for i := 0; i < 10; i++ {
+				status, _ := createKey(t, app, "key-"+strconv.Itoa(i), value)
+				assert.Equal(t, http.StatusOK, status)
+			}
+
+			info := ctx.Eviction.Info()
+			assert.Equal(t, ctx.Cache.Len(), info.Entries)
+			if maxMemoryPolicy == model.MaxMemoryEvict {
+				assert.LessOrEqual(t, info.UsedBytes, int64(4*1024))
+				assert.Equal(t, uint64(6), info.Evictions)
+			} else {
+				assert.Greater(t, info.UsedBytes, int64(4*1024))
+				assert.Zero(t, info.Evictions)
+			}
+		})
+	}
+}
+
+// removalCounter counts every mutation but sets
+type removalCounter struct {
+	count atomic.Int32
+}
+
+func (counter *removalCounter) OnMutation(mutation model.Mutation) {
+	if mutation.Op != model.MutationSet {
+		counter.count.Add(1)
+	}
+}
+
+func TestMaxMemoryDemotesToSecondTier(t *testing.T) {
+	app, ctx := setUpMemoryApp(t, model.MaxMemoryEvict)
+	store, err := tier.Open(t.TempDir(), 0)
+	assert.NoError(t, err)
+	defer store.Close()
+	ctx.SecondTier = store
+
+	removals := &removalCounter{}
+	ctx.AddObserver(removals)
+
+	value := strings.Repeat("x", 900)
+	for i := 0; i < 10; i++ {
+		status, _ := createKey(t, app, "key-"+strconv.Itoa(i), value)
+		assert.Equal(t, http.StatusOK, status)
+	}
+
+	// Victims of the policy are still stored and were never deleted
+	assert.Less(t, ctx.Cache.Len(), 10)
+	assert.Zero(t, removals.count.Load())
+	for i := 0; i < 10; i++ {
+		entry, err := ctx.GetEntry("key-" + strconv.Itoa(i))
+		assert.NoError(t, err)
+		assert.Equal(t, value, entry.Value)
+	}
+}
@@ -0,0 +1,17 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetMetrics exports the metrics in the Prometheus text format
+func GetMetrics(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Metrics == nil {
+		return sendError(c, "Metrics are disabled")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	return ctx.Metrics.WriteText(c)
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetPeerEntry serves the local entry of key to the peer filling a miss,
+// it never fills from other peers itself
+func GetPeerEntry(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	entry, err := ctx.GetEntryContext(c.UserContext(), c.Query("key"))
+	if err != nil {
+		return sendEntryError(c, err, "Get")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  entry,
+	})
+}
+
+func GetPeerInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Peers == nil {
+		return sendError(c, "Peer fill is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.Peers.Info(),
+	})
+}
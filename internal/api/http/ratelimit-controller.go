@@ -0,0 +1,133 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/ratelimit"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func SetRateLimitRule(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	rule := new(ratelimit.Rule)
+	if err := c.Bind().Body(rule); err != nil {
+		return err
+	}
+
+	if err := rule.Validate(); err != nil {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	ctx.SetRateLimitRule(*rule)
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Rule saved successfully",
+		"cache":   rule,
+	})
+}
+
+func GetRateLimitRules(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"rules": ctx.RateLimitRules(),
+		},
+	})
+}
+
+func DeleteRateLimitRule(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	name := c.Params("name")
+	if !ctx.DeleteRateLimitRule(name) {
+		return sendError(c, "Rule not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Rule deleted successfully",
+		"cache": fiber.Map{
+			"name": name,
+		},
+	})
+}
+
+// CheckRateLimit answers "may `client` do `rule`?" and consumes `cost` units
+// from the client counter when allowed
+func CheckRateLimit(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	checkReq := new(model.RateLimitCheckRequest)
+	if err := c.Bind().Body(checkReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(checkReq.Client) == "" {
+		return sendError(c, "Rate limit `client` cannot be empty")
+	}
+
+	if checkReq.Cost < 1 {
+		checkReq.Cost = 1
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	rule, ok := ctx.RateLimitRule(checkReq.Rule)
+	if !ok {
+		return sendError(c, "Rule not found")
+	}
+
+	key := model.RateLimitKey(rule.Name, checkReq.Client)
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeRateLimit)
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "CheckRateLimit")
+	}
+
+	state := new(ratelimit.State)
+	if err == nil {
+		if err := entry.DecodeValue(state); err != nil {
+			return sendEntryError(c, err, "CheckRateLimit")
+		}
+	}
+
+	now := time.Now()
+	result := rule.Take(state, now, checkReq.Cost)
+
+	entry = model.CacheEntry{
+		Type:       model.EntryTypeRateLimit,
+		Value:      state,
+		Expiration: now.Add(rule.Window()),
+	}
+	if err := ctx.SetEntryContext(c.UserContext(), key, entry); err != nil {
+		return sendEntryError(c, err, "CheckRateLimit")
+	}
+
+	resetInSeconds := int(math.Ceil(result.ResetAfter.Seconds()))
+	c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(resetInSeconds))
+
+	message := "Request allowed"
+	if !result.Allowed {
+		message = "Rate limit exceeded"
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": message,
+		"cache": fiber.Map{
+			"rule":             rule.Name,
+			"client":           checkReq.Client,
+			"allowed":          result.Allowed,
+			"limit":            result.Limit,
+			"remaining":        result.Remaining,
+			"reset_in_seconds": resetInSeconds,
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpRateLimitApp() *fiber.App {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Post("/ratelimit/rules", func(c fiber.Ctx) error { return SetRateLimitRule(c, ctx) })
+	app.Get("/ratelimit/rules", func(c fiber.Ctx) error { return GetRateLimitRules(c, ctx) })
+	app.Delete("/ratelimit/rules/:name", func(c fiber.Ctx) error { return DeleteRateLimitRule(c, ctx) })
+	app.Post("/ratelimit/check", func(c fiber.Ctx) error { return CheckRateLimit(c, ctx) })
+
+	return app
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	app := setUpRateLimitApp()
+
+	response := doMapRequest(t, app, http.MethodPost, "/ratelimit/rules", `{"name":"login","algorithm":"fixed_window","limit":2,"window_in_seconds":60}`)
+	assert.Equal(t, "OK", response["status"])
+
+	response = doMapRequest(t, app, http.MethodPost, "/ratelimit/check", `{"rule":"login","client":"10.0.0.1"}`)
+	assert.Equal(t, true, response["cache"].(map[string]any)["allowed"])
+	assert.Equal(t, float64(1), response["cache"].(map[string]any)["remaining"])
+
+	doMapRequest(t, app, http.MethodPost, "/ratelimit/check", `{"rule":"login","client":"10.0.0.1"}`)
+	response = doMapRequest(t, app, http.MethodPost, "/ratelimit/check", `{"rule":"login","client":"10.0.0.1"}`)
+	assert.Equal(t, false, response["cache"].(map[string]any)["allowed"])
+	assert.Equal(t, "Rate limit exceeded", response["message"])
+
+	// Counters are per client
+	response = doMapRequest(t, app, http.MethodPost, "/ratelimit/check", `{"rule":"login","client":"10.0.0.2"}`)
+	assert.Equal(t, true, response["cache"].(map[string]any)["allowed"])
+}
+
+func TestRateLimitRules(t *testing.T) {
+	app := setUpRateLimitApp()
+
+	response := doMapRequest(t, app, http.MethodPost, "/ratelimit/rules", `{"name":"bad","algorithm":"leaky_bucket","limit":0}`)
+	assert.Equal(t, "Validation error", response["message"])
+
+	doMapRequest(t, app, http.MethodPost, "/ratelimit/rules", `{"name":"api","algorithm":"token_bucket","limit":5,"window_in_seconds":1}`)
+	response = doMapRequest(t, app, http.MethodGet, "/ratelimit/rules", "")
+	assert.Len(t, response["cache"].(map[string]any)["rules"], 1)
+
+	doMapRequest(t, app, http.MethodDelete, "/ratelimit/rules/api", "")
+	response = doMapRequest(t, app, http.MethodPost, "/ratelimit/check", `{"rule":"api","client":"svc"}`)
+	assert.Equal(t, "Rule not found", response["message"])
+}
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// PartialSync returns mutations after `offset` to a replica, holding the
+// request up to `wait_ms` (capped to MAX_PSYNC_WAIT_IN_MILLISECONDS) when
+// there is none yet. Replicas that are too far behind, or that follow another
+// replication ID, get a FULLRESYNC status.
+func PartialSync(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Replication == nil {
+		return sendError(c, "Replication is disabled")
+	}
+
+	offset := fiber.Query[uint64](c, "offset", 0)
+	limit := fiber.Query[int](c, "limit", 1000)
+	waitMs := fiber.Query[int](c, "wait_ms", 0)
+	if waitMs > config.MAX_PSYNC_WAIT_IN_MILLISECONDS {
+		waitMs = config.MAX_PSYNC_WAIT_IN_MILLISECONDS
+	}
+	wait := time.Duration(waitMs) * time.Millisecond
+
+	records, ok := ctx.Replication.Changes(c.Query("replication_id"), offset, c.Query("replica_id"), limit, wait)
+	if !ok {
+		return c.JSON(fiber.Map{
+			"status":  "FULLRESYNC",
+			"message": "Full resync required",
+			"cache":   nil,
+		})
+	}
+
+	// Replicas compute their lag from the latest offset
+	_, latest := ctx.Replication.Position()
+	c.Set("X-Replication-Offset", strconv.FormatUint(latest, 10))
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"records": records,
+		},
+	})
+}
+
+// FullSync streams every live entry with the replication position the
+// stream starts from, mutations after it are fetched with PartialSync
+func FullSync(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Replication == nil || ctx.Replication.Role() != model.RolePrimary {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Node is not a primary",
+			"cache":   nil,
+		})
+	}
+
+	replicationID, offset := ctx.Replication.Position()
+	c.Set("X-Replication-Id", replicationID)
+	c.Set("X-Replication-Offset", strconv.FormatUint(offset, 10))
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := persistence.WriteEntries(ctx, writer, "")
+		writer.CloseWithError(err)
+	}()
+
+	return c.SendStream(reader)
+}
+
+func GetReplicationInfo(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Replication == nil {
+		return sendError(c, "Replication is disabled")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache":  ctx.Replication.Info(),
+	})
+}
+
+// PromoteReplica turns this replica into a primary that accepts writes
+func PromoteReplica(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.Replication == nil {
+		return sendError(c, "Replication is disabled")
+	}
+
+	if err := ctx.Replication.Promote(); err != nil {
+		return sendError(c, "Promotion failed : "+err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Replica promoted to primary",
+		"cache":   ctx.Replication.Info(),
+	})
+}
@@ -0,0 +1,265 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func AddSetMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	setReq := new(model.SetMembersRequest)
+	if err := c.Bind().Body(setReq); err != nil {
+		return err
+	}
+
+	if valid, err := validateSetMembers(*setReq); !valid {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), setReq.Key, model.EntryTypeSet)
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "AddSetMembers")
+	}
+
+	if err != nil || setReq.DurationInSeconds > 0 {
+		entry.Type = model.EntryTypeSet
+		entry.Expiration = ctx.ExpirationFor(setReq.DurationInSeconds)
+	}
+
+	members := setMembers(entry)
+	added := 0
+	for _, member := range setReq.Members {
+		if _, ok := members[member]; !ok {
+			members[member] = struct{}{}
+			added++
+		}
+	}
+
+	if err := saveSet(c.UserContext(), ctx, setReq.Key, entry, members); err != nil {
+		return sendEntryError(c, err, "AddSetMembers")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Members added successfully",
+		"cache": fiber.Map{
+			"key":   setReq.Key,
+			"added": added,
+			"size":  len(members),
+		},
+	})
+}
+
+func RemoveSetMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	setReq := new(model.SetMembersRequest)
+	if err := c.Bind().Body(setReq); err != nil {
+		return err
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), setReq.Key, model.EntryTypeSet)
+	if err != nil {
+		return sendEntryError(c, err, "RemoveSetMembers")
+	}
+
+	members := setMembers(entry)
+	removed := 0
+	for _, member := range setReq.Members {
+		if _, ok := members[member]; ok {
+			delete(members, member)
+			removed++
+		}
+	}
+
+	if err := saveSet(c.UserContext(), ctx, setReq.Key, entry, members); err != nil {
+		return sendEntryError(c, err, "RemoveSetMembers")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Members removed successfully",
+		"cache": fiber.Map{
+			"key":     setReq.Key,
+			"removed": removed,
+			"size":    len(members),
+		},
+	})
+}
+
+func IsSetMember(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	member := c.Query("member")
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSet)
+	ctx.Unlock()
+	if err != nil && isCacheExists(err) {
+		return sendEntryError(c, err, "IsSetMember")
+	}
+
+	_, exists := setMembers(entry)[member]
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":       key,
+			"member":    member,
+			"is_member": exists,
+		},
+	})
+}
+
+func GetSetMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSet)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "GetSetMembers")
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":     key,
+			"members": sortedMembers(setMembers(entry)),
+		},
+	})
+}
+
+// IntersectSets returns members present in every set listed in `keys`.
+// A missing key is an empty set, so the intersection is empty too.
+func IntersectSets(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return combineSets(c, ctx, func(result map[string]struct{}, members map[string]struct{}) {
+		for member := range result {
+			if _, ok := members[member]; !ok {
+				delete(result, member)
+			}
+		}
+	})
+}
+
+// UnionSets returns members present in any set listed in `keys`
+func UnionSets(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	return combineSets(c, ctx, func(result map[string]struct{}, members map[string]struct{}) {
+		for member := range members {
+			result[member] = struct{}{}
+		}
+	})
+}
+
+func combineSets(c fiber.Ctx, ctx *model.CacheAppContext, combine func(result map[string]struct{}, members map[string]struct{})) error {
+	keys := splitKeys(c.Query("keys"))
+	if len(keys) < 1 {
+		return sendError(c, "Query `keys` cannot be empty")
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	var result map[string]struct{}
+	for _, key := range keys {
+		entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSet)
+		if err != nil && isCacheExists(err) {
+			return sendEntryError(c, err, "CombineSets")
+		}
+
+		members := setMembers(entry)
+		if result == nil {
+			result = members
+			continue
+		}
+
+		combine(result, members)
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"keys":    keys,
+			"members": sortedMembers(result),
+		},
+	})
+}
+
+// saveSet stores the set members sorted, an empty set removes the key
+func saveSet(parent context.Context, ctx *model.CacheAppContext, key string, entry model.CacheEntry, members map[string]struct{}) error {
+	if len(members) == 0 {
+		return ctx.DeleteEntryContext(parent, key)
+	}
+
+	entry.Value = sortedMembers(members)
+	return ctx.SetEntryContext(parent, key, entry)
+}
+
+func setMembers(entry model.CacheEntry) map[string]struct{} {
+	members := make(map[string]struct{})
+	values, ok := entry.Value.([]any)
+	if !ok {
+		return members
+	}
+
+	for _, value := range values {
+		if member, ok := value.(string); ok {
+			members[member] = struct{}{}
+		}
+	}
+
+	return members
+}
+
+func sortedMembers(members map[string]struct{}) []string {
+	result := make([]string, 0, len(members))
+	for member := range members {
+		result = append(result, member)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// splitKeys parses a comma separated `keys` query param
+func splitKeys(keys string) []string {
+	result := []string{}
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
+
+func validateSetMembers(request model.SetMembersRequest) (bool, map[string]any) {
+	validationErr := make(map[string]any)
+	if strings.TrimSpace(request.Key) == "" {
+		validationErr["key"] = "Cache `key` cannot be empty"
+	}
+
+	if len(request.Members) < 1 {
+		validationErr["members"] = "Set `members` cannot be empty"
+	}
+
+	if request.DurationInSeconds < 0 {
+		validationErr["duration_in_seconds"] = "Value `duration_in_seconds` should be >= 0"
+	}
+
+	if len(validationErr) < 1 {
+		return true, nil
+	}
+
+	return false, validationErr
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpSetApp() (*fiber.App, *model.CacheAppContext) {
+	app := fiber.New()
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	app.Post("/set/sadd", func(c fiber.Ctx) error { return AddSetMembers(c, ctx) })
+	app.Post("/set/srem", func(c fiber.Ctx) error { return RemoveSetMembers(c, ctx) })
+	app.Get("/set/sismember", func(c fiber.Ctx) error { return IsSetMember(c, ctx) })
+	app.Get("/set/smembers", func(c fiber.Ctx) error { return GetSetMembers(c, ctx) })
+	app.Get("/set/sinter", func(c fiber.Ctx) error { return IntersectSets(c, ctx) })
+	app.Get("/set/sunion", func(c fiber.Ctx) error { return UnionSets(c, ctx) })
+	app.Post("/zset/zadd", func(c fiber.Ctx) error { return AddSortedSetMembers(c, ctx) })
+	app.Post("/zset/zincrby", func(c fiber.Ctx) error { return IncrementSortedSetMember(c, ctx) })
+	app.Get("/zset/zrange", func(c fiber.Ctx) error { return RangeSortedSet(c, ctx) })
+	app.Get("/zset/zrangebyscore", func(c fiber.Ctx) error { return RangeSortedSetByScore(c, ctx) })
+	app.Get("/zset/zrank", func(c fiber.Ctx) error { return RankSortedSetMember(c, ctx) })
+	app.Post("/zset/zrem", func(c fiber.Ctx) error { return RemoveSortedSetMembers(c, ctx) })
+	app.Post("/list/rpush", func(c fiber.Ctx) error { return RightPushList(c, ctx) })
+	app.Get("/memory/usage", func(c fiber.Ctx) error { return MemoryUsage(c, ctx) })
+
+	return app, ctx
+}
+
+func doMapRequest(t *testing.T, app *fiber.App, method string, target string, body string) map[string]any {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Error occurred while making request: %v", err)
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	response := map[string]any{}
+	assert.NoError(t, json.Unmarshal(bodyBytes, &response))
+	return response
+}
+
+func TestSetCommands(t *testing.T) {
+	app, _ := setUpSetApp()
+
+	response := doMapRequest(t, app, http.MethodPost, "/set/sadd", `{"key":"a","members":["x","y","z","x"]}`)
+	assert.Equal(t, float64(3), response["cache"].(map[string]any)["added"])
+	doMapRequest(t, app, http.MethodPost, "/set/sadd", `{"key":"b","members":["y","z","w"]}`)
+
+	response = doMapRequest(t, app, http.MethodGet, "/set/sismember?key=a&member=x", "")
+	assert.Equal(t, true, response["cache"].(map[string]any)["is_member"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/set/sinter?keys=a,b", "")
+	assert.Equal(t, []any{"y", "z"}, response["cache"].(map[string]any)["members"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/set/sunion?keys=a,b", "")
+	assert.Equal(t, []any{"w", "x", "y", "z"}, response["cache"].(map[string]any)["members"])
+
+	doMapRequest(t, app, http.MethodPost, "/set/srem", `{"key":"a","members":["x"]}`)
+	response = doMapRequest(t, app, http.MethodGet, "/set/smembers?key=a", "")
+	assert.Equal(t, []any{"y", "z"}, response["cache"].(map[string]any)["members"])
+}
+
+func TestSortedSetCommands(t *testing.T) {
+	app, _ := setUpSetApp()
+
+	doMapRequest(t, app, http.MethodPost, "/zset/zadd", `{"key":"board","members":[{"member":"alice","score":10},{"member":"bob","score":5},{"member":"carol","score":7}]}`)
+	response := doMapRequest(t, app, http.MethodPost, "/zset/zincrby", `{"key":"board","member":"bob","increment":10}`)
+	assert.Equal(t, float64(15), response["cache"].(map[string]any)["score"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/zset/zrange?key=board&start=0&stop=-1", "")
+	members := response["cache"].(map[string]any)["members"].([]any)
+	assert.Len(t, members, 3)
+	assert.Equal(t, "carol", members[0].(map[string]any)["member"])
+	assert.Equal(t, "bob", members[2].(map[string]any)["member"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/zset/zrangebyscore?key=board&min=8&max=12", "")
+	members = response["cache"].(map[string]any)["members"].([]any)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "alice", members[0].(map[string]any)["member"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/zset/zrank?key=board&member=alice", "")
+	assert.Equal(t, float64(1), response["cache"].(map[string]any)["rank"])
+
+	doMapRequest(t, app, http.MethodPost, "/zset/zrem", `{"key":"board","members":["alice"]}`)
+	response = doMapRequest(t, app, http.MethodGet, "/zset/zrank?key=board&member=alice", "")
+	assert.Equal(t, "Member not found", response["message"])
+}
+
+func TestWrongTypeAndMemoryLimit(t *testing.T) {
+	app, ctx := setUpSetApp()
+
+	doMapRequest(t, app, http.MethodPost, "/list/rpush", `{"key":"mixed","values":["a"]}`)
+	response := doMapRequest(t, app, http.MethodPost, "/set/sadd", `{"key":"mixed","members":["a"]}`)
+	assert.Equal(t, "ERROR", response["status"])
+	assert.Equal(t, "Operation against a key holding the wrong kind of value", response["message"])
+
+	response = doMapRequest(t, app, http.MethodGet, "/memory/usage?key=mixed", "")
+	assert.Equal(t, "list", response["cache"].(map[string]any)["type"])
+	assert.Greater(t, response["cache"].(map[string]any)["bytes"], float64(0))
+
+	ctx.MaxEntrySize = 128
+	response = doMapRequest(t, app, http.MethodPost, "/set/sadd", `{"key":"big","members":["`+strings.Repeat("m", 200)+`"]}`)
+	assert.Equal(t, "Entry exceeds the max entry size of the cache", response["message"])
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// GetSlowLog returns the `count` latest slow requests like Redis SLOWLOG GET,
+// 10 by default and all of them with -1, newest first
+func GetSlowLog(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.SlowLog == nil {
+		return sendError(c, "Slow log is disabled")
+	}
+
+	count := fiber.Query[int](c, "count", 10)
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"info":    ctx.SlowLog.Info(),
+			"entries": ctx.SlowLog.Entries(count),
+		},
+	})
+}
+
+// ResetSlowLog empties the slow log like Redis SLOWLOG RESET
+func ResetSlowLog(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	if ctx.SlowLog == nil {
+		return sendError(c, "Slow log is disabled")
+	}
+
+	ctx.SlowLog.Reset()
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Slow log reset successfully",
+		"cache":   ctx.SlowLog.Info(),
+	})
+}
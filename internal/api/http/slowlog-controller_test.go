@@ -0,0 +1,112 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/slowlog"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpSlowLogApp(t *testing.T, threshold time.Duration) (*fiber.App, *model.CacheAppContext) {
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	assert.NoError(t, err)
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute, SlowLog: slowlog.New(10, threshold)}
+
+	app := fiber.New()
+	app.Use(middleware.SlowLogMiddleware(ctx))
+	app.Get("/get", func(c fiber.Ctx) error { return GetCache(c, ctx) })
+	app.Post("/create", func(c fiber.Ctx) error { return CreateCache(c, ctx) })
+	app.Post("/list/blpop", func(c fiber.Ctx) error { return BlockingLeftPopList(c, ctx) })
+	app.Get("/admin/slowlog", func(c fiber.Ctx) error { return GetSlowLog(c, ctx) })
+	app.Post("/admin/slowlog/reset", func(c fiber.Ctx) error { return ResetSlowLog(c, ctx) })
+
+	return app, ctx
+}
+
+type slowLogResponse struct {
+	Status string `json:"status"`
+	Cache  struct {
+		Info    model.SlowLogInfo    `json:"info"`
+		Entries []model.SlowLogEntry `json:"entries"`
+	} `json:"cache"`
+}
+
+func getSlowLog(t *testing.T, app *fiber.App, target string) slowLogResponse {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+	assert.NoError(t, err)
+
+	response := slowLogResponse{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+func TestSlowLogRecordsSlowRequests(t *testing.T) {
+	app, _ := setUpSlowLogApp(t, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(`{"key":"user:1","value":"value","duration_in_seconds":60}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderXForwardedFor, "10.0.0.1")
+	_, err := app.Test(req)
+	assert.NoError(t, err)
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/get?key=user:1", nil))
+	assert.NoError(t, err)
+
+	// Blocking pops wait by design and are left out
+	req = httptest.NewRequest(http.MethodPost, "/list/blpop", strings.NewReader(`{"key":"queue","timeout_in_seconds":1}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	_, err = app.Test(req, 5*time.Second)
+	assert.NoError(t, err)
+
+	response := getSlowLog(t, app, "/admin/slowlog")
+	assert.Equal(t, "OK", response.Status)
+	assert.Equal(t, 2, response.Cache.Info.Len)
+	assert.Len(t, response.Cache.Entries, 2)
+
+	get, create := response.Cache.Entries[0], response.Cache.Entries[1]
+	assert.Equal(t, "GET /get", get.Operation)
+	assert.Equal(t, "user:1", get.Key)
+	assert.Greater(t, get.ValueSize, 0)
+	assert.Equal(t, "POST /create", create.Operation)
+	assert.Equal(t, "user:1", create.Key)
+	assert.Equal(t, len(`{"key":"user:1","value":"value","duration_in_seconds":60}`), create.ValueSize)
+	assert.Equal(t, "10.0.0.1", create.Client)
+	assert.Greater(t, get.ID, create.ID)
+
+	// Reading the log is logged once answered, like any request
+	response = getSlowLog(t, app, "/admin/slowlog?count=1")
+	assert.Len(t, response.Cache.Entries, 1)
+	assert.Equal(t, "GET /admin/slowlog", response.Cache.Entries[0].Operation)
+}
+
+func TestSlowLogReset(t *testing.T) {
+	app, ctx := setUpSlowLogApp(t, time.Hour)
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/get?key=missing", nil))
+	assert.NoError(t, err)
+	assert.Empty(t, getSlowLog(t, app, "/admin/slowlog").Cache.Entries)
+
+	ctx.SlowLog.SetThreshold(0)
+	_, err = app.Test(httptest.NewRequest(http.MethodGet, "/get?key=missing", nil))
+	assert.NoError(t, err)
+	assert.Len(t, getSlowLog(t, app, "/admin/slowlog").Cache.Entries, 1)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/admin/slowlog/reset", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Only the reset itself was logged since, the stats are kept
+	entries := getSlowLog(t, app, "/admin/slowlog").Cache.Entries
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "POST /admin/slowlog/reset", entries[0].Operation)
+	assert.Equal(t, uint64(2), ctx.SlowLog.Stats()["GET /get"].Calls)
+}
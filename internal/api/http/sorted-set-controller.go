@@ -0,0 +1,304 @@
+package http
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func AddSortedSetMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	zsetReq := new(model.SortedSetAddRequest)
+	if err := c.Bind().Body(zsetReq); err != nil {
+		return err
+	}
+
+	if valid, err := validateSortedSetAdd(*zsetReq); !valid {
+		return c.JSON(fiber.Map{
+			"status":           "ERROR",
+			"message":          "Validation error",
+			"cache":            nil,
+			"validation_error": err,
+		})
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := loadSortedSetForWrite(c.UserContext(), ctx, zsetReq.Key, zsetReq.DurationInSeconds)
+	if err != nil {
+		return sendEntryError(c, err, "AddSortedSetMembers")
+	}
+
+	scores := sortedSetScores(entry)
+	added := 0
+	for _, member := range zsetReq.Members {
+		if _, ok := scores[member.Member]; !ok {
+			added++
+		}
+		scores[member.Member] = member.Score
+	}
+
+	if err := saveSortedSet(c.UserContext(), ctx, zsetReq.Key, entry, scores); err != nil {
+		return sendEntryError(c, err, "AddSortedSetMembers")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Members added successfully",
+		"cache": fiber.Map{
+			"key":   zsetReq.Key,
+			"added": added,
+			"size":  len(scores),
+		},
+	})
+}
+
+func IncrementSortedSetMember(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	zsetReq := new(model.SortedSetIncrementRequest)
+	if err := c.Bind().Body(zsetReq); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(zsetReq.Key) == "" || zsetReq.Member == "" {
+		return sendError(c, "Cache `key` and `member` cannot be empty")
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := loadSortedSetForWrite(c.UserContext(), ctx, zsetReq.Key, zsetReq.DurationInSeconds)
+	if err != nil {
+		return sendEntryError(c, err, "IncrementSortedSetMember")
+	}
+
+	scores := sortedSetScores(entry)
+	scores[zsetReq.Member] += zsetReq.Increment
+
+	if err := saveSortedSet(c.UserContext(), ctx, zsetReq.Key, entry, scores); err != nil {
+		return sendEntryError(c, err, "IncrementSortedSetMember")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Member incremented successfully",
+		"cache": fiber.Map{
+			"key":    zsetReq.Key,
+			"member": zsetReq.Member,
+			"score":  scores[zsetReq.Member],
+		},
+	})
+}
+
+// RangeSortedSet returns members ordered by score between rank `start` and `stop`
+func RangeSortedSet(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	start := fiber.Query[int](c, "start", 0)
+	stop := fiber.Query[int](c, "stop", -1)
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSortedSet)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "RangeSortedSet")
+	}
+
+	members := rankedMembers(sortedSetScores(entry))
+	from, to, ok := normalizeRange(start, stop, len(members))
+	result := []model.SortedSetMember{}
+	if ok {
+		result = members[from : to+1]
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":     key,
+			"members": result,
+		},
+	})
+}
+
+// RangeSortedSetByScore returns members with `min` <= score <= `max`
+func RangeSortedSetByScore(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	minScore := fiber.Query[float64](c, "min", math.Inf(-1))
+	maxScore := fiber.Query[float64](c, "max", math.Inf(1))
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSortedSet)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "RangeSortedSetByScore")
+	}
+
+	result := []model.SortedSetMember{}
+	for _, member := range rankedMembers(sortedSetScores(entry)) {
+		if member.Score >= minScore && member.Score <= maxScore {
+			result = append(result, member)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"status": "OK",
+		"cache": fiber.Map{
+			"key":     key,
+			"members": result,
+		},
+	})
+}
+
+func RankSortedSetMember(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	key := c.Query("key")
+	member := c.Query("member")
+
+	ctx.Lock()
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), key, model.EntryTypeSortedSet)
+	ctx.Unlock()
+	if err != nil {
+		return sendEntryError(c, err, "RankSortedSetMember")
+	}
+
+	for rank, ranked := range rankedMembers(sortedSetScores(entry)) {
+		if ranked.Member == member {
+			return c.JSON(fiber.Map{
+				"status": "OK",
+				"cache": fiber.Map{
+					"key":    key,
+					"member": member,
+					"rank":   rank,
+					"score":  ranked.Score,
+				},
+			})
+		}
+	}
+
+	return sendError(c, "Member not found")
+}
+
+func RemoveSortedSetMembers(c fiber.Ctx, ctx *model.CacheAppContext) error {
+	zsetReq := new(model.SortedSetRemoveRequest)
+	if err := c.Bind().Body(zsetReq); err != nil {
+		return err
+	}
+
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	entry, err := ctx.GetTypedEntryContext(c.UserContext(), zsetReq.Key, model.EntryTypeSortedSet)
+	if err != nil {
+		return sendEntryError(c, err, "RemoveSortedSetMembers")
+	}
+
+	scores := sortedSetScores(entry)
+	removed := 0
+	for _, member := range zsetReq.Members {
+		if _, ok := scores[member]; ok {
+			delete(scores, member)
+			removed++
+		}
+	}
+
+	if err := saveSortedSet(c.UserContext(), ctx, zsetReq.Key, entry, scores); err != nil {
+		return sendEntryError(c, err, "RemoveSortedSetMembers")
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  "OK",
+		"message": "Members removed successfully",
+		"cache": fiber.Map{
+			"key":     zsetReq.Key,
+			"removed": removed,
+			"size":    len(scores),
+		},
+	})
+}
+
+// loadSortedSetForWrite returns the existing sorted set or a fresh one
+// when the key is missing. Must be called while holding ctx.Lock.
+func loadSortedSetForWrite(parent context.Context, ctx *model.CacheAppContext, key string, durationInSeconds int) (model.CacheEntry, error) {
+	entry, err := ctx.GetTypedEntryContext(parent, key, model.EntryTypeSortedSet)
+	if err != nil && isCacheExists(err) {
+		return entry, err
+	}
+
+	if err != nil || durationInSeconds > 0 {
+		entry.Type = model.EntryTypeSortedSet
+		entry.Expiration = ctx.ExpirationFor(durationInSeconds)
+	}
+
+	return entry, nil
+}
+
+// saveSortedSet stores member scores, an empty sorted set removes the key
+func saveSortedSet(parent context.Context, ctx *model.CacheAppContext, key string, entry model.CacheEntry, scores map[string]float64) error {
+	if len(scores) == 0 {
+		return ctx.DeleteEntryContext(parent, key)
+	}
+
+	entry.Value = scores
+	return ctx.SetEntryContext(parent, key, entry)
+}
+
+func sortedSetScores(entry model.CacheEntry) map[string]float64 {
+	scores := make(map[string]float64)
+	values, ok := entry.Value.(map[string]any)
+	if !ok {
+		return scores
+	}
+
+	for member, value := range values {
+		if score, ok := value.(float64); ok {
+			scores[member] = score
+		}
+	}
+
+	return scores
+}
+
+// rankedMembers orders members by score, ties are ordered by member name
+func rankedMembers(scores map[string]float64) []model.SortedSetMember {
+	members := make([]model.SortedSetMember, 0, len(scores))
+	for member, score := range scores {
+		members = append(members, model.SortedSetMember{Member: member, Score: score})
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+
+	return members
+}
+
+func validateSortedSetAdd(request model.SortedSetAddRequest) (bool, map[string]any) {
+	validationErr := make(map[string]any)
+	if strings.TrimSpace(request.Key) == "" {
+		validationErr["key"] = "Cache `key` cannot be empty"
+	}
+
+	if len(request.Members) < 1 {
+		validationErr["members"] = "Sorted set `members` cannot be empty"
+	}
+
+	for _, member := range request.Members {
+		if member.Member == "" {
+			validationErr["members"] = "Sorted set `member` cannot be empty"
+		}
+	}
+
+	if request.DurationInSeconds < 0 {
+		validationErr["duration_in_seconds"] = "Value `duration_in_seconds` should be >= 0"
+	}
+
+	if len(validationErr) < 1 {
+		return true, nil
+	}
+
+	return false, validationErr
+}
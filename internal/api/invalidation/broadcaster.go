@@ -0,0 +1,254 @@
+package invalidation
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// retryDelay doubles after every failed delivery to a peer
+	retryDelay = 100 * time.Millisecond
+	queueSize  = 1024
+	seenSize   = 10000
+	// multicastCopies is how many times every invalidation is multicast,
+	// UDP has no acknowledgement to retry on
+	multicastCopies = 2
+)
+
+// Broadcaster implements model.Invalidator. Invalidations are posted to every
+// peer over HTTP, each peer has its own queue so a slow or unreachable peer
+// does not delay the others, and/or multicast over UDP on a LAN.
+type Broadcaster struct {
+	ctx     *model.CacheAppContext
+	origin  string
+	retries int
+	client  *http.Client
+	peers   []*peerQueue
+	seen    *seenIDs
+
+	multicastAddress string
+	multicastConn    *net.UDPConn
+	multicastReader  *net.UDPConn
+
+	sent       atomic.Uint64
+	received   atomic.Uint64
+	duplicates atomic.Uint64
+	retried    atomic.Uint64
+	failed     atomic.Uint64
+	rejected   atomic.Uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type peerQueue struct {
+	url   string
+	queue chan model.Invalidation
+}
+
+// New creates a broadcaster identified by origin, the hostname with a random
+// suffix when empty so processes sharing a host tell each other apart. peers are base URLs of the
+// other nodes and multicastAddress, when not empty, a UDP group like
+// `239.0.0.1:7946` every node joins. Deliveries to a peer are retried
+// `retries` times before being counted as failed.
+func New(ctx *model.CacheAppContext, origin string, peers []string, multicastAddress string, retries int) (*Broadcaster, error) {
+	if origin == "" {
+		hostname, _ := os.Hostname()
+		origin = hostname + "-" + newID()[:8]
+	}
+
+	broadcaster := &Broadcaster{
+		ctx:     ctx,
+		origin:  origin,
+		retries: retries,
+		client:  &http.Client{Timeout: 5 * time.Second, Transport: ctx.NodeTransport()},
+		seen:    newSeenIDs(seenSize),
+		stop:    make(chan struct{}),
+	}
+
+	if multicastAddress != "" {
+		if err := broadcaster.joinMulticast(multicastAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, peer := range peers {
+		if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer == "" {
+			continue
+		}
+
+		queue := &peerQueue{url: peer, queue: make(chan model.Invalidation, queueSize)}
+		broadcaster.peers = append(broadcaster.peers, queue)
+
+		broadcaster.wg.Add(1)
+		go broadcaster.deliver(queue)
+	}
+
+	return broadcaster, nil
+}
+
+func (broadcaster *Broadcaster) Info() model.InvalidationInfo {
+	peers := make([]string, 0, len(broadcaster.peers))
+	for _, peer := range broadcaster.peers {
+		peers = append(peers, peer.url)
+	}
+
+	return model.InvalidationInfo{
+		Origin:     broadcaster.origin,
+		Peers:      peers,
+		Multicast:  broadcaster.multicastAddress,
+		Sent:       broadcaster.sent.Load(),
+		Received:   broadcaster.received.Load(),
+		Duplicates: broadcaster.duplicates.Load(),
+		Retries:    broadcaster.retried.Load(),
+		Failed:     broadcaster.failed.Load(),
+		Rejected:   broadcaster.rejected.Load(),
+	}
+}
+
+func (broadcaster *Broadcaster) Broadcast(invalidation model.Invalidation) {
+	invalidation.ID = newID()
+	invalidation.Origin = broadcaster.origin
+	broadcaster.seen.add(invalidation.ID)
+	broadcaster.sent.Add(1)
+
+	for _, peer := range broadcaster.peers {
+		select {
+		case peer.queue <- invalidation:
+		default:
+			broadcaster.failed.Add(1)
+			log.Printf("Invalidation queue of `%s` is full, dropping `%s`", peer.url, invalidation.ID)
+		}
+	}
+
+	if broadcaster.multicastConn != nil {
+		broadcaster.multicast(invalidation)
+	}
+}
+
+func (broadcaster *Broadcaster) Receive(invalidation model.Invalidation) (bool, error) {
+	if !broadcaster.seen.add(invalidation.ID) {
+		broadcaster.duplicates.Add(1)
+		return false, nil
+	}
+
+	broadcaster.received.Add(1)
+	if err := Apply(broadcaster.ctx, invalidation); err != nil {
+		// The sender retries with the same ID
+		broadcaster.seen.remove(invalidation.ID)
+		return true, err
+	}
+
+	return true, nil
+}
+
+// Close stops delivering, invalidations still queued are dropped
+func (broadcaster *Broadcaster) Close() {
+	close(broadcaster.stop)
+	if broadcaster.multicastReader != nil {
+		broadcaster.multicastReader.Close()
+		broadcaster.multicastConn.Close()
+	}
+
+	broadcaster.wg.Wait()
+}
+
+// Apply performs invalidation on the local cache
+func Apply(ctx *model.CacheAppContext, invalidation model.Invalidation) error {
+	switch invalidation.Op {
+	case model.InvalidateKey:
+		return ctx.DeleteEntry(invalidation.Key)
+	case model.InvalidateTag:
+		_, err := ctx.PurgeTag(invalidation.Tag)
+		return err
+	case model.InvalidateFlush:
+		_, err := ctx.Flush()
+		return err
+	}
+
+	return fmt.Errorf("unknown invalidation op `%s`", invalidation.Op)
+}
+
+// deliver sends the invalidations queued for peer in order
+func (broadcaster *Broadcaster) deliver(peer *peerQueue) {
+	defer broadcaster.wg.Done()
+
+	for {
+		select {
+		case <-broadcaster.stop:
+			return
+		case invalidation := <-peer.queue:
+			broadcaster.send(peer.url, invalidation)
+		}
+	}
+}
+
+func (broadcaster *Broadcaster) send(peer string, invalidation model.Invalidation) {
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err := broadcaster.post(peer, invalidation)
+		if err == nil {
+			return
+		}
+
+		if attempt >= broadcaster.retries {
+			broadcaster.failed.Add(1)
+			log.Printf("Error when sending invalidation `%s` to `%s` : %v", invalidation.ID, peer, err.Error())
+			return
+		}
+
+		broadcaster.retried.Add(1)
+		select {
+		case <-broadcaster.stop:
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (broadcaster *Broadcaster) post(peer string, invalidation model.Invalidation) error {
+	body, err := json.Marshal(invalidation)
+	if err != nil {
+		return err
+	}
+
+	resp, err := broadcaster.client.Post(peer+"/"+config.BASE_URL_NAME+"/internal/invalidation", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	response := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return err
+	}
+
+	if response.Status != "OK" {
+		return fmt.Errorf("peer rejected the invalidation : %s", response.Message)
+	}
+
+	return nil
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
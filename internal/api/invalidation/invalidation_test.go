@@ -0,0 +1,266 @@
+package invalidation
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/router"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	ctx         *model.CacheAppContext
+	broadcaster *Broadcaster
+	url         string
+}
+
+func newAppContext() *model.CacheAppContext {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	return &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+}
+
+// startNodes serves the whole API of size nodes on loopback ports,
+// every node broadcasting to all the others
+func startNodes(t *testing.T, size int) []*node {
+	listeners := []net.Listener{}
+	urls := []string{}
+	for i := 0; i < size; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		listeners = append(listeners, listener)
+		urls = append(urls, "http://"+listener.Addr().String())
+	}
+
+	nodes := []*node{}
+	for i, listener := range listeners {
+		peers := append(append([]string{}, urls[:i]...), urls[i+1:]...)
+
+		ctx := newAppContext()
+		broadcaster, err := New(ctx, urls[i], peers, "", 3)
+		assert.NoError(t, err)
+		ctx.Invalidator = broadcaster
+
+		app := fiber.New()
+		router.HandleRoute(app, ctx)
+		go app.Listener(listener, fiber.ListenConfig{DisableStartupMessage: true})
+		t.Cleanup(func() {
+			app.ShutdownWithTimeout(time.Second)
+		})
+		t.Cleanup(broadcaster.Close)
+
+		nodes = append(nodes, &node{ctx: ctx, broadcaster: broadcaster, url: urls[i]})
+	}
+
+	return nodes
+}
+
+func (node *node) request(t *testing.T, method string, path string, body string) map[string]any {
+	req, _ := http.NewRequest(method, node.url+"/cache-engine-api"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return response
+}
+
+func (node *node) set(key string, tags ...string) {
+	node.ctx.SetEntry(key, model.CacheEntry{Value: key, Expiration: time.Now().Add(time.Hour), Tags: tags})
+}
+
+func (node *node) has(key string) bool {
+	_, err := node.ctx.GetEntry(key)
+	return err == nil
+}
+
+func TestBroadcastDelete(t *testing.T) {
+	nodes := startNodes(t, 3)
+	for _, node := range nodes {
+		node.set("greeting")
+		node.set("other")
+	}
+
+	response := nodes[0].request(t, http.MethodDelete, "/delete/greeting", "")
+	assert.Equal(t, "OK", response["status"])
+
+	assert.Eventually(t, func() bool {
+		return !nodes[1].has("greeting") && !nodes[2].has("greeting")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, nodes[1].has("other"))
+
+	// A node that does not hold the key still broadcasts the delete
+	nodes[2].ctx.DeleteEntry("other")
+	nodes[2].request(t, http.MethodDelete, "/delete/other", "")
+	assert.Eventually(t, func() bool {
+		return !nodes[0].has("other") && !nodes[1].has("other")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, uint64(1), nodes[0].broadcaster.Info().Sent)
+	assert.Equal(t, uint64(2), nodes[1].broadcaster.Info().Received)
+}
+
+func TestBroadcastTagPurgeAndFlush(t *testing.T) {
+	nodes := startNodes(t, 2)
+	for _, node := range nodes {
+		node.set("user:1", "users")
+		node.set("user:2", "users", "admins")
+		node.set("post:1", "posts")
+	}
+
+	response := nodes[0].request(t, http.MethodPost, "/create", `{"key":"user:3","value":"3","duration_in_seconds":60,"tags":["users"]}`)
+	assert.Equal(t, "OK", response["status"])
+
+	response = nodes[0].request(t, http.MethodDelete, "/tag/users", "")
+	assert.Equal(t, "OK", response["status"])
+	assert.Equal(t, float64(3), response["cache"].(map[string]any)["deleted"])
+
+	assert.Eventually(t, func() bool {
+		return !nodes[1].has("user:1") && !nodes[1].has("user:2")
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, nodes[1].has("post:1"))
+
+	response = nodes[1].request(t, http.MethodDelete, "/flush", "")
+	assert.Equal(t, "OK", response["status"])
+	assert.Eventually(t, func() bool {
+		return !nodes[0].has("post:1")
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestBroadcastRetriesAndDeduplicates(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := "OK"
+		if requests.Add(1) <= 2 {
+			status = "ERROR"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": status})
+	}))
+	defer server.Close()
+
+	broadcaster, err := New(newAppContext(), "origin", []string{server.URL}, "", 3)
+	assert.NoError(t, err)
+	defer broadcaster.Close()
+
+	broadcaster.Broadcast(model.Invalidation{Op: model.InvalidateKey, Key: "greeting"})
+	assert.Eventually(t, func() bool {
+		return requests.Load() == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(2), broadcaster.Info().Retries)
+	assert.Equal(t, uint64(0), broadcaster.Info().Failed)
+
+	invalidation := model.Invalidation{ID: "id-1", Origin: "other", Op: model.InvalidateKey, Key: "greeting"}
+	applied, err := broadcaster.Receive(invalidation)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+
+	applied, err = broadcaster.Receive(invalidation)
+	assert.NoError(t, err)
+	assert.False(t, applied)
+	assert.Equal(t, uint64(1), broadcaster.Info().Duplicates)
+}
+
+// failingTier is a disk tier whose first Delete fails
+type failingTier struct {
+	model.SecondTier
+	deletes atomic.Int32
+}
+
+func (tier *failingTier) Delete(key string) (bool, error) {
+	if tier.deletes.Add(1) == 1 {
+		return false, errors.New("disk is gone")
+	}
+	return false, nil
+}
+
+func TestReceiveRetriesFailedInvalidation(t *testing.T) {
+	ctx := newAppContext()
+	ctx.SecondTier = &failingTier{}
+	broadcaster, err := New(ctx, "origin", nil, "", 3)
+	assert.NoError(t, err)
+	defer broadcaster.Close()
+
+	ctx.SetEntry("greeting", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+	invalidation := model.Invalidation{ID: "id-1", Origin: "other", Op: model.InvalidateKey, Key: "greeting"}
+	applied, err := broadcaster.Receive(invalidation)
+	assert.Error(t, err)
+	assert.True(t, applied)
+
+	// The retry of the sender carries the same ID and is applied
+	applied, err = broadcaster.Receive(invalidation)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	assert.Zero(t, broadcaster.Info().Duplicates)
+}
+
+func TestBroadcastMulticast(t *testing.T) {
+	address := "239.255.77.78:17947"
+	first, err := New(newAppContext(), "first", nil, address, 0)
+	if err != nil {
+		t.Skipf("multicast is not available : %v", err)
+	}
+	defer first.Close()
+
+	second, err := New(newAppContext(), "second", nil, address, 0)
+	assert.NoError(t, err)
+	defer second.Close()
+
+	second.ctx.SetEntry("greeting", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+	first.Broadcast(model.Invalidation{Op: model.InvalidateKey, Key: "greeting"})
+
+	assert.Eventually(t, func() bool {
+		_, err := second.ctx.GetEntry("greeting")
+		return err != nil && second.Info().Duplicates == multicastCopies-1
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(1), second.Info().Received)
+}
+
+func TestBroadcastMulticastRejectsUnsignedDatagrams(t *testing.T) {
+	address := "239.255.77.79:17948"
+	first, err := New(newAppContext(), "", nil, address, 0)
+	if err != nil {
+		t.Skipf("multicast is not available : %v", err)
+	}
+	defer first.Close()
+	first.ctx.SetAuthKeys([]string{"secret"})
+
+	second, err := New(newAppContext(), "", nil, address, 0)
+	assert.NoError(t, err)
+	defer second.Close()
+	second.ctx.SetAuthKeys([]string{"secret"})
+
+	// Both default to the hostname, the random suffix keeps them apart
+	assert.NotEqual(t, first.origin, second.origin)
+
+	second.ctx.SetEntry("forged", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+	second.ctx.SetEntry("greeting", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+
+	// Sent without the key, as anyone on the LAN could
+	forged, _ := json.Marshal(model.Invalidation{ID: "forged", Origin: "attacker", Op: model.InvalidateKey, Key: "forged"})
+	data := append(newAppContext().Sign(forged), forged...)
+	first.multicastConn.Write(data)
+	first.Broadcast(model.Invalidation{Op: model.InvalidateKey, Key: "greeting"})
+
+	assert.Eventually(t, func() bool {
+		_, err := second.ctx.GetEntry("greeting")
+		return err != nil && second.Info().Rejected == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	_, err = second.ctx.GetEntry("forged")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,84 @@
+package invalidation
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+)
+
+func (broadcaster *Broadcaster) joinMulticast(address string) error {
+	group, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return err
+	}
+
+	reader, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialUDP("udp4", nil, group)
+	if err != nil {
+		reader.Close()
+		return err
+	}
+
+	broadcaster.multicastAddress = address
+	broadcaster.multicastReader = reader
+	broadcaster.multicastConn = conn
+
+	broadcaster.wg.Add(1)
+	go broadcaster.readMulticast()
+	return nil
+}
+
+// multicast sends the invalidation prefixed with its signature, UDP has no
+// auth key header and anyone on the LAN may send to the group
+func (broadcaster *Broadcaster) multicast(invalidation model.Invalidation) {
+	body, err := json.Marshal(invalidation)
+	if err != nil {
+		return
+	}
+	data := append(broadcaster.ctx.Sign(body), body...)
+
+	for i := 0; i < multicastCopies; i++ {
+		if _, err := broadcaster.multicastConn.Write(data); err != nil {
+			broadcaster.failed.Add(1)
+			log.Printf("Error when multicasting invalidation `%s` : %v", invalidation.ID, err.Error())
+			return
+		}
+	}
+}
+
+func (broadcaster *Broadcaster) readMulticast() {
+	defer broadcaster.wg.Done()
+
+	buffer := make([]byte, 64*1024)
+	for {
+		n, _, err := broadcaster.multicastReader.ReadFromUDP(buffer)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil || n < sha256.Size {
+			continue
+		}
+
+		signature, body := buffer[:sha256.Size], buffer[sha256.Size:n]
+		if !broadcaster.ctx.Verify(body, signature) {
+			broadcaster.rejected.Add(1)
+			continue
+		}
+
+		invalidation := model.Invalidation{}
+		if err := json.Unmarshal(body, &invalidation); err != nil || invalidation.Origin == broadcaster.origin {
+			continue
+		}
+
+		if _, err := broadcaster.Receive(invalidation); err != nil {
+			log.Printf("Error when applying invalidation `%s` : %v", invalidation.ID, err.Error())
+		}
+	}
+}
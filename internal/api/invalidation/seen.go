@@ -0,0 +1,50 @@
+package invalidation
+
+import "sync"
+
+// seenIDs remembers the last size invalidation IDs so a delivery
+// repeated by a retry or by the network is applied only once
+type seenIDs struct {
+	mu sync.Mutex
+	// ids maps every recorded id to its slot in order
+	ids   map[string]int
+	order []string
+	next  int
+}
+
+func newSeenIDs(size int) *seenIDs {
+	return &seenIDs{
+		ids:   make(map[string]int, size),
+		order: make([]string, size),
+	}
+}
+
+// add records id and returns false when it was already recorded
+func (seen *seenIDs) add(id string) bool {
+	seen.mu.Lock()
+	defer seen.mu.Unlock()
+
+	if _, exists := seen.ids[id]; exists {
+		return false
+	}
+
+	if oldest := seen.order[seen.next]; oldest != "" {
+		delete(seen.ids, oldest)
+	}
+	seen.order[seen.next] = id
+	seen.ids[id] = seen.next
+	seen.next = (seen.next + 1) % len(seen.order)
+
+	return true
+}
+
+// remove forgets id so a later delivery of it is applied
+func (seen *seenIDs) remove(id string) {
+	seen.mu.Lock()
+	defer seen.mu.Unlock()
+
+	if slot, exists := seen.ids[id]; exists {
+		seen.order[slot] = ""
+		delete(seen.ids, id)
+	}
+}
@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Level is the minimum level logged, it can change while the server runs
+var Level = new(slog.LevelVar)
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// ParseLevel accepts `debug`, `info`, `warn` and `error`
+func ParseLevel(name string) (slog.Level, error) {
+	level := slog.LevelInfo
+	err := level.UnmarshalText([]byte(strings.TrimSpace(name)))
+	return level, err
+}
+
+// ValidFormat reports whether format is FormatText or FormatJSON
+func ValidFormat(format string) bool {
+	return format == FormatText || format == FormatJSON
+}
+
+// SetUp routes the log package, which logs at the info level, and slog
+// through a handler filtered by Level, writing records in format
+func SetUp(format string) {
+	slog.SetDefault(New(os.Stderr, format))
+}
+
+// New creates a logger writing records in format to w. Records logged
+// with the context of a request carry its request ID and trace ID.
+func New(w io.Writer, format string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: Level}
+
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(contextHandler{handler})
+}
+
+// WithRequestID returns parent carrying the ID of the request it belongs to
+func WithRequestID(parent context.Context, requestID string) context.Context {
+	return context.WithValue(parent, requestIDKey, requestID)
+}
+
+// RequestID returns the ID WithRequestID stored in ctx, empty without one
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// contextHandler adds the correlation IDs found in the context of a record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{handler.Handler.WithGroup(name)}
+}
@@ -0,0 +1,248 @@
+package metrics
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// OtherNamespace gathers the namespaces seen after the first maxNamespaces
+	OtherNamespace = "other"
+
+	// Reasons of cache_engine_evictions_total
+	reasonPolicy  = "policy"
+	reasonExpired = "expired"
+	reasonNoSpace = "no_space"
+)
+
+// Metrics implements model.Metrics and model.MutationObserver and exports
+// them with the BigCache stats in the Prometheus text format.
+//
+// Keys are only recorded by namespace, the first maxNamespaces namespaces get
+// their own label and the following ones are counted under OtherNamespace.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	hits          *prometheus.CounterVec
+	misses        *prometheus.CounterVec
+	expiredOnRead *prometheus.CounterVec
+	sets          *prometheus.CounterVec
+	deletes       *prometheus.CounterVec
+	evictions     *prometheus.CounterVec
+	hotCopies     *prometheus.CounterVec
+	requests      *prometheus.HistogramVec
+
+	maxNamespaces int
+	mu            sync.Mutex
+	namespaces    map[string]string
+}
+
+// New creates the metrics recorded from the requests, the reads and the
+// mutations. The metrics read from the cache are added by Register.
+func New(maxNamespaces int) *Metrics {
+	metrics := &Metrics{
+		registry:      prometheus.NewRegistry(),
+		maxNamespaces: maxNamespaces,
+		namespaces:    map[string]string{},
+		hits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_hits_total",
+			Help: "Reads that found the key in memory or on disk.",
+		}, []string{"namespace"}),
+		misses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_misses_total",
+			Help: "Reads that did not find the key.",
+		}, []string{"namespace"}),
+		expiredOnRead: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_expired_on_read_total",
+			Help: "Keys found past their TTL and deleted when read.",
+		}, []string{"namespace"}),
+		sets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_sets_total",
+			Help: "Keys stored.",
+		}, []string{"namespace"}),
+		deletes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_deletes_total",
+			Help: "Keys deleted, purged by tag or flushed.",
+		}, []string{"namespace"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_evictions_total",
+			Help: "Keys evicted from memory by the eviction policy or by BigCache when they outlived its life window or it ran out of space.",
+		}, []string{"namespace", "reason"}),
+		hotCopies: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_engine_hot_copies_total",
+			Help: "Copies of entries owned by other peers stored after a local miss.",
+		}, []string{"namespace"}),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cache_engine_http_request_duration_seconds",
+			Help:    "Latency of the requests by route pattern.",
+			Buckets: []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+	}
+
+	metrics.registry.MustRegister(
+		metrics.hits,
+		metrics.misses,
+		metrics.expiredOnRead,
+		metrics.sets,
+		metrics.deletes,
+		metrics.evictions,
+		metrics.hotCopies,
+		metrics.requests,
+	)
+
+	return metrics
+}
+
+// Register adds the metrics read from ctx at every scrape, rateLimited
+// returns how many requests the rate limiter rejected and may be nil
+func (metrics *Metrics) Register(ctx *model.CacheAppContext, rateLimited func() uint64) {
+	metrics.registry.MustRegister(
+		bigCacheCollector{cache: ctx.Cache},
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_engine_entries",
+			Help: "Entries in memory.",
+		}, func() float64 { return float64(ctx.Cache.Len()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_engine_bytes",
+			Help: "Bytes BigCache allocated for the entries.",
+		}, func() float64 { return float64(ctx.Cache.Capacity()) }),
+	)
+
+	if ctx.Eviction != nil {
+		metrics.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cache_engine_used_bytes",
+			Help: "Bytes of the entries accounted against the max memory.",
+		}, func() float64 { return float64(ctx.Eviction.Info().UsedBytes) }))
+	}
+
+	if rateLimited != nil {
+		metrics.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "cache_engine_rate_limited_total",
+			Help: "Requests rejected by the rate limiter.",
+		}, func() float64 { return float64(rateLimited()) }))
+	}
+}
+
+// namespace returns the label of the namespace of key. Labels are kept by
+// the counters, so they are copies Fiber cannot reuse the buffers of.
+func (metrics *Metrics) namespace(key string) string {
+	namespace := model.NamespaceOf(key)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	if label, ok := metrics.namespaces[namespace]; ok {
+		return label
+	}
+	if len(metrics.namespaces) >= metrics.maxNamespaces {
+		return OtherNamespace
+	}
+
+	label := strings.Clone(namespace)
+	metrics.namespaces[label] = label
+	return label
+}
+
+func (metrics *Metrics) OnRead(key string, hit bool) {
+	if hit {
+		metrics.hits.WithLabelValues(metrics.namespace(key)).Inc()
+	} else {
+		metrics.misses.WithLabelValues(metrics.namespace(key)).Inc()
+	}
+}
+
+// OnDemote counts policy evictions that are not mutations, the entry
+// is still stored in the second tier
+func (metrics *Metrics) OnDemote(key string) {
+	metrics.evictions.WithLabelValues(metrics.namespace(key), reasonPolicy).Inc()
+}
+
+func (metrics *Metrics) OnHotCopy(key string) {
+	metrics.hotCopies.WithLabelValues(metrics.namespace(key)).Inc()
+}
+
+func (metrics *Metrics) OnRequest(method string, route string, status int, duration time.Duration) {
+	metrics.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) OnMutation(mutation model.Mutation) {
+	namespace := metrics.namespace(mutation.Key)
+
+	switch mutation.Op {
+	case model.MutationSet:
+		metrics.sets.WithLabelValues(namespace).Inc()
+	case model.MutationDelete:
+		metrics.deletes.WithLabelValues(namespace).Inc()
+	case model.MutationExpire:
+		metrics.expiredOnRead.WithLabelValues(namespace).Inc()
+	case model.MutationEvict:
+		metrics.evictions.WithLabelValues(namespace, reasonPolicy).Inc()
+	}
+}
+
+// OnRemove counts the entries BigCache evicts on its own, to be chained
+// into bigcache.Config.OnRemoveWithReason
+func (metrics *Metrics) OnRemove(key string, entry []byte, reason bigcache.RemoveReason) {
+	switch reason {
+	case bigcache.Expired:
+		metrics.evictions.WithLabelValues(metrics.namespace(key), reasonExpired).Inc()
+	case bigcache.NoSpace:
+		metrics.evictions.WithLabelValues(metrics.namespace(key), reasonNoSpace).Inc()
+	}
+}
+
+// WriteText writes every metric in the Prometheus text format
+func (metrics *Metrics) WriteText(w io.Writer) error {
+	families, err := metrics.registry.Gather()
+	if err != nil {
+		return err
+	}
+
+	encoder := expfmt.NewEncoder(w, expfmt.NewFormat(expfmt.TypeTextPlain))
+	for _, family := range families {
+		if err := encoder.Encode(family); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bigCacheCollector exports bigcache.Stats, read once per scrape
+type bigCacheCollector struct {
+	cache *bigcache.BigCache
+}
+
+var (
+	bigCacheHits       = prometheus.NewDesc("cache_engine_bigcache_hits_total", "Gets that found the key in BigCache.", nil, nil)
+	bigCacheMisses     = prometheus.NewDesc("cache_engine_bigcache_misses_total", "Gets that did not find the key in BigCache.", nil, nil)
+	bigCacheDelHits    = prometheus.NewDesc("cache_engine_bigcache_delete_hits_total", "Deletes that found the key in BigCache.", nil, nil)
+	bigCacheDelMisses  = prometheus.NewDesc("cache_engine_bigcache_delete_misses_total", "Deletes that did not find the key in BigCache.", nil, nil)
+	bigCacheCollisions = prometheus.NewDesc("cache_engine_bigcache_collisions_total", "Keys that collided with another key of the same hash.", nil, nil)
+)
+
+func (collector bigCacheCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- bigCacheHits
+	descs <- bigCacheMisses
+	descs <- bigCacheDelHits
+	descs <- bigCacheDelMisses
+	descs <- bigCacheCollisions
+}
+
+func (collector bigCacheCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats := collector.cache.Stats()
+
+	metrics <- prometheus.MustNewConstMetric(bigCacheHits, prometheus.CounterValue, float64(stats.Hits))
+	metrics <- prometheus.MustNewConstMetric(bigCacheMisses, prometheus.CounterValue, float64(stats.Misses))
+	metrics <- prometheus.MustNewConstMetric(bigCacheDelHits, prometheus.CounterValue, float64(stats.DelHits))
+	metrics <- prometheus.MustNewConstMetric(bigCacheDelMisses, prometheus.CounterValue, float64(stats.DelMisses))
+	metrics <- prometheus.MustNewConstMetric(bigCacheCollisions, prometheus.CounterValue, float64(stats.Collisions))
+}
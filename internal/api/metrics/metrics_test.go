@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"cache_engine_httpserver/internal/api/http"
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpMetrics(t *testing.T, maxNamespaces int) (*fiber.App, *model.CacheAppContext) {
+	collector := New(maxNamespaces)
+	cacheConfig := bigcache.DefaultConfig(10 * time.Minute)
+	cacheConfig.OnRemoveWithReason = collector.OnRemove
+	cache, err := bigcache.New(context.Background(), cacheConfig)
+	assert.NoError(t, err)
+
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute}
+	collector.Register(ctx, func() uint64 { return 3 })
+	ctx.AddObserver(collector)
+	ctx.Metrics = collector
+
+	app := fiber.New()
+	app.Use(middleware.MetricsMiddleware(ctx))
+	app.Get("/get", func(c fiber.Ctx) error { return http.GetCache(c, ctx) })
+	app.Get("/metrics", func(c fiber.Ctx) error { return http.GetMetrics(c, ctx) })
+
+	return app, ctx
+}
+
+func scrape(t *testing.T, app *fiber.App) string {
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/metrics", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(resp.Header.Get(fiber.HeaderContentType), "text/plain"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestMetricsCountOperationsByNamespace(t *testing.T) {
+	app, ctx := setUpMetrics(t, 10)
+	expiration := time.Now().Add(time.Minute)
+
+	assert.NoError(t, ctx.SetEntry("user:1", model.CacheEntry{Value: "a", Expiration: expiration}))
+	assert.NoError(t, ctx.SetEntry("user:2", model.CacheEntry{Value: "b", Expiration: expiration}))
+	assert.NoError(t, ctx.SetEntry("plain", model.CacheEntry{Value: "c", Expiration: expiration}))
+	assert.NoError(t, ctx.SetEntry("session:1", model.CacheEntry{Value: "d", Expiration: time.Now().Add(-time.Second)}))
+
+	for _, key := range []string{"user:1", "user:1", "user:3", "plain", "session:1"} {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/get?key="+key, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+	assert.NoError(t, ctx.DeleteEntry("user:2"))
+
+	text := scrape(t, app)
+	for _, line := range []string{
+		`cache_engine_sets_total{namespace="user"} 2`,
+		`cache_engine_sets_total{namespace="default"} 1`,
+		`cache_engine_hits_total{namespace="user"} 2`,
+		`cache_engine_misses_total{namespace="user"} 1`,
+		`cache_engine_hits_total{namespace="default"} 1`,
+		`cache_engine_expired_on_read_total{namespace="session"} 1`,
+		`cache_engine_deletes_total{namespace="user"} 1`,
+		`cache_engine_entries 2`,
+		`cache_engine_bigcache_hits_total 4`,
+		`cache_engine_bigcache_misses_total 1`,
+		`cache_engine_rate_limited_total 3`,
+		`cache_engine_http_request_duration_seconds_count{method="GET",route="/get",status="200"} 5`,
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+}
+
+func TestMetricsFoldExtraNamespaces(t *testing.T) {
+	app, ctx := setUpMetrics(t, 2)
+	expiration := time.Now().Add(time.Minute)
+
+	for _, key := range []string{"a:1", "b:1", "c:1", "d:1"} {
+		assert.NoError(t, ctx.SetEntry(key, model.CacheEntry{Value: "v", Expiration: expiration}))
+	}
+
+	text := scrape(t, app)
+	assert.Contains(t, text, `cache_engine_sets_total{namespace="a"} 1`+"\n")
+	assert.Contains(t, text, `cache_engine_sets_total{namespace="b"} 1`+"\n")
+	assert.Contains(t, text, `cache_engine_sets_total{namespace="other"} 2`+"\n")
+	assert.NotContains(t, text, `namespace="c"`)
+}
+
+func TestMetricsCountBigCacheEvictions(t *testing.T) {
+	collector := New(10)
+
+	collector.OnRemove("user:1", nil, bigcache.Expired)
+	collector.OnRemove("user:2", nil, bigcache.NoSpace)
+	collector.OnRemove("user:3", nil, bigcache.Deleted)
+
+	text := &strings.Builder{}
+	assert.NoError(t, collector.WriteText(text))
+	assert.Contains(t, text.String(), `cache_engine_evictions_total{namespace="user",reason="expired"} 1`+"\n")
+	assert.Contains(t, text.String(), `cache_engine_evictions_total{namespace="user",reason="no_space"} 1`+"\n")
+	// Deletes are counted from the mutations
+	assert.Equal(t, 2, strings.Count(text.String(), "cache_engine_evictions_total{"))
+}
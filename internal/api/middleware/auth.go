@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// isProbe reports whether c is a health probe, probes are answered without
+// auth or rate limit since orchestrators send them from every node
+func isProbe(c fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet && (c.Path() == "/healthz" || c.Path() == "/readyz")
+}
+
+// AuthMiddleware rejects requests without one of the auth keys
+// in the model.AuthKeyHeader header, once auth keys are set
+func AuthMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if isProbe(c) || ctx.Authorized(c.Get(model.AuthKeyHeader)) {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Missing or invalid `" + model.AuthKeyHeader + "` header",
+			"cache":   nil,
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/proxy"
+)
+
+// ForwardedHeader marks requests already forwarded by another member,
+// they are always served locally so a membership disagreement cannot loop
+const ForwardedHeader = "X-Cluster-Forwarded-By"
+
+// ForwardedSignatureHeader carries the HMAC of a forwarded request under the
+// auth key, a client setting ForwardedHeader alone is still routed
+const ForwardedSignatureHeader = "X-Cluster-Forwarded-Signature"
+
+// Route prefixes of the data type commands, each of them addresses keys
+var partitionedPrefixes = []string{"/list/", "/set/", "/zset/", "/hll/", "/bloom/", "/lock/"}
+
+// ClusterRoutingMiddleware forwards every request addressing keys to the
+// member owning them. Admin and internal routes are always served locally,
+// commands addressing keys owned by different members are rejected.
+func ClusterRoutingMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if ctx.Cluster == nil || forwardedByMember(c, ctx) {
+			return c.Next()
+		}
+
+		keys, ok := routedKeys(c)
+		if !ok {
+			return c.Next()
+		}
+
+		owner := ctx.Cluster.Owner(keys[0])
+		for _, key := range keys[1:] {
+			if ctx.Cluster.Owner(key) != owner {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "ERROR",
+					"message": "Keys of a command must be owned by the same member",
+					"cache":   nil,
+				})
+			}
+		}
+
+		self := ctx.Cluster.Self()
+		if owner == "" || owner == self {
+			return c.Next()
+		}
+
+		c.Request().Header.Set(ForwardedHeader, self)
+		c.Request().Header.Set(ForwardedSignatureHeader, hex.EncodeToString(ctx.Sign(forwardedPayload(c, self))))
+		if err := proxy.Do(c, owner+c.OriginalURL()); err != nil {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"status":  "ERROR",
+				"message": "Failed to forward request to `" + owner + "`",
+				"cache":   nil,
+			})
+		}
+
+		return nil
+	}
+}
+
+// forwardedByMember trusts ForwardedHeader only when it comes with the
+// signature of a member holding one of the auth keys
+func forwardedByMember(c fiber.Ctx, ctx *model.CacheAppContext) bool {
+	member := c.Get(ForwardedHeader)
+	if member == "" {
+		return false
+	}
+
+	signature, err := hex.DecodeString(c.Get(ForwardedSignatureHeader))
+	if err != nil {
+		return false
+	}
+
+	return ctx.Verify(forwardedPayload(c, member), signature)
+}
+
+// forwardedPayload is what a forwarding member signs, the signature
+// cannot be reused for another request
+func forwardedPayload(c fiber.Ctx, member string) []byte {
+	return []byte(member + " " + c.Method() + " " + c.OriginalURL())
+}
+
+// routedKeys extracts the keys of the routes that are partitioned
+func routedKeys(c fiber.Ctx) ([]string, bool) {
+	path := strings.TrimPrefix(c.Path(), "/"+config.BASE_URL_NAME)
+
+	switch {
+	case c.Method() == fiber.MethodDelete && strings.HasPrefix(path, "/delete/"):
+		return []string{strings.TrimPrefix(path, "/delete/")}, true
+	case c.Method() == fiber.MethodGet && strings.HasPrefix(path, "/exists/"):
+		return []string{strings.TrimPrefix(path, "/exists/")}, true
+	case c.Method() == fiber.MethodPost && path == "/ratelimit/check":
+		body := model.RateLimitCheckRequest{}
+		if err := json.Unmarshal(c.Body(), &body); err != nil || body.Rule == "" || body.Client == "" {
+			return nil, false
+		}
+		return []string{model.RateLimitKey(body.Rule, body.Client)}, true
+	case !partitioned(path):
+		return nil, false
+	case c.Method() == fiber.MethodGet:
+		if key := c.Query("key"); key != "" {
+			return []string{key}, true
+		}
+		keys := splitKeys(c.Query("keys"))
+		return keys, len(keys) > 0
+	case c.Method() == fiber.MethodPost:
+		body := struct {
+			Key         string   `json:"key"`
+			Destination string   `json:"destination"`
+			Sources     []string `json:"sources"`
+		}{}
+		if err := json.Unmarshal(c.Body(), &body); err != nil {
+			return nil, false
+		}
+
+		keys := []string{}
+		for _, key := range append([]string{body.Key, body.Destination}, body.Sources...) {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		return keys, len(keys) > 0
+	}
+
+	return nil, false
+}
+
+func partitioned(path string) bool {
+	if path == "/get" || path == "/create" || path == "/memory/usage" {
+		return true
+	}
+
+	for _, prefix := range partitionedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitKeys(keys string) []string {
+	result := []string{}
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			result = append(result, key)
+		}
+	}
+
+	return result
+}
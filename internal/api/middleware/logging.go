@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/logging"
+	"cache_engine_httpserver/internal/api/model"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	// maxRequestIDLength bounds the IDs taken from clients
+	maxRequestIDLength = 128
+	// maxLoggedBodySize bounds the JSON bodies read for the key of a request
+	maxLoggedBodySize = 64 * 1024
+)
+
+// RequestIDMiddleware answers every request with an `X-Request-Id` header,
+// the one the client sent or a new one. Handlers log with c.UserContext()
+// so their records carry the ID.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		// The header is reused by the next request, the ID outlives it in logs
+		requestID := strings.Clone(c.Get(fiber.HeaderXRequestID))
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		c.Set(fiber.HeaderXRequestID, requestID)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), requestID))
+		return c.Next()
+	}
+}
+
+// AccessLogMiddleware logs every request at the info level once answered,
+// after RequestIDMiddleware so it carries the ID. Keys are logged as their hash.
+func AccessLogMiddleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		started := time.Now()
+		err := c.Next()
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", responseStatus(c, err)),
+			slog.Duration("latency", time.Since(started)),
+			slog.Int("bytes", len(c.Response().Body())),
+		}
+		if key := requestKey(c); key != "" {
+			attrs = append(attrs, slog.String("key_hash", model.KeyHash(key)))
+		}
+		slog.LogAttrs(c.UserContext(), slog.LevelInfo, "request", attrs...)
+
+		return err
+	}
+}
+
+// requestKey is the key a request is about, from the query,
+// the route or a small JSON body, empty when there is none
+func requestKey(c fiber.Ctx) string {
+	if key := c.Query("key"); key != "" {
+		return key
+	}
+	if key := c.Params("key"); key != "" {
+		return key
+	}
+
+	length := c.Request().Header.ContentLength()
+	if length <= 0 || length > maxLoggedBodySize || !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationJSON) {
+		return ""
+	}
+	body := struct {
+		Key string `json:"key"`
+	}{}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return ""
+	}
+
+	return body.Key
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MetricsMiddleware records the latency of every request by the route
+// pattern it matched, so keys do not end up in labels. Requests answered by
+// a middleware or matching no route are recorded under the `/` of app.Use.
+func MetricsMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if ctx.Metrics == nil {
+			return c.Next()
+		}
+
+		started := time.Now()
+		err := c.Next()
+
+		ctx.Metrics.OnRequest(c.Method(), c.Route().Path, responseStatus(c, err), time.Since(started))
+		return err
+	}
+}
+
+// responseStatus is the status err will be answered with,
+// the error handler only sets it after the middlewares return
+func responseStatus(c fiber.Ctx, err error) int {
+	fiberError := &fiber.Error{}
+	if errors.As(err, &fiberError) {
+		return fiberError.Code
+	} else if err != nil {
+		return fiber.StatusInternalServerError
+	}
+
+	return c.Response().StatusCode()
+}
@@ -1,25 +1,52 @@
 package middleware
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/limiter"
 )
 
-// Function to return the limiter middleware
-func RateLimiterMiddleware() fiber.Handler {
-	return limiter.New(limiter.Config{
+// RateLimiter is the limiter middleware with limits that can change while serving
+type RateLimiter struct {
+	handler  atomic.Pointer[fiber.Handler]
+	rejected atomic.Uint64
+}
+
+// RateLimiterMiddleware allows max requests per client every expiration
+func RateLimiterMiddleware(max int, expiration time.Duration) *RateLimiter {
+	rateLimiter := &RateLimiter{}
+	rateLimiter.SetLimits(max, expiration)
+	return rateLimiter
+}
+
+// SetLimits swaps in a limiter with the new limits, clients start over with a full quota
+func (rateLimiter *RateLimiter) SetLimits(max int, expiration time.Duration) {
+	handler := limiter.New(limiter.Config{
 		Next: func(c fiber.Ctx) bool {
-			return c.IP() == "127.0.0.1" // Allow localhost to bypass rate limiting
+			return c.IP() == "127.0.0.1" || isProbe(c) // Allow localhost and probes to bypass rate limiting
 		},
-		Max:        1020,             // Max requests allowed
-		Expiration: 30 * time.Second, // Expiration time window
+		Max:        max,        // Max requests allowed
+		Expiration: expiration, // Expiration time window
 		KeyGenerator: func(c fiber.Ctx) string {
 			return c.Get("x-forwarded-for") // Use "X-Forwarded-For" header
 		},
 		LimitReached: func(c fiber.Ctx) error {
+			rateLimiter.rejected.Add(1)
 			return c.SendFile("./toofast.html") // Custom response when limit is reached
 		},
 	})
+	rateLimiter.handler.Store(&handler)
+}
+
+// Rejected returns how many requests went over the limits since startup
+func (rateLimiter *RateLimiter) Rejected() uint64 {
+	return rateLimiter.rejected.Load()
+}
+
+func (rateLimiter *RateLimiter) Handler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		return (*rateLimiter.handler.Load())(c)
+	}
 }
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Routes that use POST but do not write to the replicated cache. Rate limit
+// counters are local to the node checking them, like on independent nodes.
+var readOnlyPostRoutes = map[string]bool{
+	"/" + config.BASE_URL_NAME + "/bloom/mexists":       true,
+	"/" + config.BASE_URL_NAME + "/admin/snapshot":      true,
+	"/" + config.BASE_URL_NAME + "/admin/config/reload": true,
+	"/" + config.BASE_URL_NAME + "/admin/slowlog/reset": true,
+	"/" + config.BASE_URL_NAME + "/admin/gossip/join":   true,
+	"/" + config.BASE_URL_NAME + "/cluster/members":     true,
+	"/" + config.BASE_URL_NAME + "/ratelimit/check":     true,
+}
+
+// ReadOnlyReplicaMiddleware rejects writes while the node is a replica.
+// GET requests and replication routes are always allowed, admin routes
+// writing to the cache like imports are rejected.
+func ReadOnlyReplicaMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if ctx.Replication == nil || ctx.Replication.Role() != model.RoleReplica {
+			return c.Next()
+		}
+
+		path := c.Path()
+		if c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead || readOnlyPostRoutes[path] ||
+			strings.HasPrefix(path, "/"+config.BASE_URL_NAME+"/replication/") {
+			return c.Next()
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "ERROR",
+			"message": "Writes are not allowed against a read only replica",
+			"cache":   nil,
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxSlowLogKeyLength truncates the keys kept in the slow log, like Redis does with arguments
+const maxSlowLogKeyLength = 128
+
+// blockingRoutes wait for data or stream by design,
+// their duration says nothing about how busy the server is
+var blockingRoutes = []string{"/list/blpop", "/list/brpop", "/replication/psync", "/replication/snapshot", "/admin/export", "/admin/import"}
+
+// SlowLogMiddleware times every request by the route pattern it matched
+// and adds the ones slower than the slow log threshold to ctx.SlowLog
+func SlowLogMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if ctx.SlowLog == nil {
+			return c.Next()
+		}
+
+		started := time.Now()
+		err := c.Next()
+		duration := time.Since(started)
+
+		route := c.Route().Path
+		for _, blocking := range blockingRoutes {
+			if strings.HasSuffix(route, blocking) {
+				return err
+			}
+		}
+
+		operation := c.Method() + " " + route
+		if !ctx.SlowLog.Observe(operation, duration) {
+			return err
+		}
+
+		// Only slow requests pay for finding their key
+		key := requestKey(c)
+		if len(key) > maxSlowLogKeyLength {
+			key = key[:maxSlowLogKeyLength] + "..."
+		}
+		valueSize := len(c.Response().Body())
+		if c.Method() != fiber.MethodGet {
+			valueSize = len(c.Body())
+		}
+
+		ctx.SlowLog.Add(model.SlowLogEntry{
+			DurationInMicroseconds: duration.Microseconds(),
+			Operation:              operation,
+			Key:                    strings.Clone(key),
+			ValueSize:              valueSize,
+			Client:                 clientAddress(c),
+		})
+		return err
+	}
+}
+
+// clientAddress identifies clients by `X-Forwarded-For` like the rate limiter,
+// by their address when it is missing
+func clientAddress(c fiber.Ctx) string {
+	if forwarded := c.Get(fiber.HeaderXForwardedFor); forwarded != "" {
+		return strings.Clone(forwarded)
+	}
+
+	return strings.Clone(c.IP())
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace of an incoming W3C `traceparent` header. Handlers reach the span
+// through c.UserContext(), the store and peer spans become its children.
+func TracingMiddleware(ctx *model.CacheAppContext) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if ctx.Tracer == nil {
+			return c.Next()
+		}
+
+		parent := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaders{c})
+		spanContext, span := ctx.Tracer.Start(parent, c.Method(), trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		c.SetUserContext(spanContext)
+
+		err := c.Next()
+
+		// The route pattern is only known once the request was routed
+		route := c.Route().Path
+		status := responseStatus(c, err)
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.request.method", c.Method()),
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
+
+// requestHeaders reads the propagation headers of a request
+type requestHeaders struct {
+	c fiber.Ctx
+}
+
+func (headers requestHeaders) Get(key string) string {
+	return headers.c.Get(key)
+}
+
+// Set is not used, headers are only extracted from requests
+func (headers requestHeaders) Set(key string, value string) {}
+
+func (headers requestHeaders) Keys() []string {
+	keys := []string{}
+	for key := range headers.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// AuthKeyHeader carries an auth key on requests, between nodes as well
+const AuthKeyHeader = "X-Api-Key"
+
+// SetAuthKeys replaces the keys requests must carry, none disables auth
+func (ctx *CacheAppContext) SetAuthKeys(keys []string) {
+	ctx.authKeys.Store(&keys)
+}
+
+// Authorized reports whether key is one of the auth keys, always true without keys
+func (ctx *CacheAppContext) Authorized(key string) bool {
+	keys := ctx.authKeys.Load()
+	if keys == nil || len(*keys) == 0 {
+		return true
+	}
+
+	authorized := false
+	for _, authKey := range *keys {
+		// Every key is compared so the time taken does not tell which one matched
+		if subtle.ConstantTimeCompare([]byte(authKey), []byte(key)) == 1 {
+			authorized = true
+		}
+	}
+
+	return authorized
+}
+
+// AuthKey is the key this node sends to the other nodes, the first auth key
+func (ctx *CacheAppContext) AuthKey() string {
+	keys := ctx.authKeys.Load()
+	if keys == nil || len(*keys) == 0 {
+		return ""
+	}
+
+	return (*keys)[0]
+}
+
+// Sign returns the HMAC-SHA256 of data under AuthKey, for messages between
+// nodes that do not go through HTTP
+func (ctx *CacheAppContext) Sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(ctx.AuthKey()))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Verify reports whether signature is the HMAC-SHA256 of data under one of
+// the auth keys, always true without keys
+func (ctx *CacheAppContext) Verify(data []byte, signature []byte) bool {
+	keys := ctx.authKeys.Load()
+	if keys == nil || len(*keys) == 0 {
+		return true
+	}
+
+	verified := false
+	for _, authKey := range *keys {
+		mac := hmac.New(sha256.New, []byte(authKey))
+		mac.Write(data)
+		if hmac.Equal(mac.Sum(nil), signature) {
+			verified = true
+		}
+	}
+
+	return verified
+}
+
+// NodeTransport is the transport of the requests this node sends
+// to the other nodes, it sets AuthKey on each of them
+func (ctx *CacheAppContext) NodeTransport() http.RoundTripper {
+	return nodeTransport{ctx: ctx}
+}
+
+type nodeTransport struct {
+	ctx *CacheAppContext
+}
+
+func (transport nodeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if key := transport.ctx.AuthKey(); key != "" {
+		req.Header.Set(AuthKeyHeader, key)
+	}
+	// The W3C traceparent header continues the trace of the request on the other node
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -1,27 +1,119 @@
 package model
 
 import (
+	"cache_engine_httpserver/internal/api/ratelimit"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/allegro/bigcache/v3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	EntryTypeString    string = "string"
+	EntryTypeList      string = "list"
+	EntryTypeSet       string = "set"
+	EntryTypeSortedSet string = "zset"
+	EntryTypeHLL       string = "hll"
+	EntryTypeBloom     string = "bloom"
+	EntryTypeLock      string = "lock"
+	EntryTypeRateLimit string = "ratelimit"
 )
 
 type CacheCreationRequest struct {
 	Key               string `json:"key"`
 	Value             any    `json:"value"`
 	DurationInSeconds int    `json:"duration_in_seconds"`
+	// Tags group keys so they can be purged together
+	Tags []string `json:"tags"`
 }
 
 // CacheEntry represents the data that stored in BigCache
-// Has two props : Value and Expiration
+// Has four props : Type, Value, Expiration and Tags
+// Empty Type is treated as a plain string value
 type CacheEntry struct {
+	Type       string    `json:"type,omitempty"`
 	Value      any       `json:"value"`
 	Expiration time.Time `json:"expiration"`
+	Tags       []string  `json:"tags,omitempty"`
+	// Hot marks a copy of an entry owned by another peer, it is left out of
+	// snapshots, exports and full syncs
+	Hot bool `json:"hot,omitempty"`
 }
 
 // CacheAppContext is to holds shared dependencies
 type CacheAppContext struct {
 	Cache *bigcache.BigCache
+
+	// DefaultExpiration is used by data type commands when the request
+	// does not carry its own `duration_in_seconds`, until SetDefaultExpiration
+	DefaultExpiration time.Duration
+
+	// StartedAt is when the server started serving
+	StartedAt time.Time
+
+	// MaxReplicationLag is how many records a replica may be behind
+	// its primary and still be ready
+	MaxReplicationLag uint64
+
+	// MaxEntrySize is the biggest entry BigCache accepts in bytes, 0 means unlimited
+	MaxEntrySize int
+
+	// Snapshotter is nil when snapshots are disabled
+	Snapshotter Snapshotter
+
+	// Replication is nil when replication is disabled
+	Replication Replication
+
+	// Cluster is nil when cluster mode is disabled
+	Cluster Cluster
+
+	// Peers is nil when peer fill is disabled
+	Peers Peers
+
+	// Invalidator is nil when invalidations are not broadcast
+	Invalidator Invalidator
+
+	// Membership is nil when gossip is disabled
+	Membership Membership
+
+	// SecondTier is nil when evicted entries are not kept on disk
+	SecondTier SecondTier
+
+	// Eviction is nil when BigCache evicts on its own
+	Eviction EvictionPolicy
+
+	// Metrics is nil when metrics are disabled
+	Metrics Metrics
+
+	// Tracer is nil when tracing is disabled
+	Tracer trace.Tracer
+
+	// SlowLog is nil when the slow log is not set up
+	SlowLog SlowLog
+
+	// Config is nil when the settings were not loaded by the config package
+	Config Configuration
+
+	// Settings that can change while serving
+	defaultExpiration atomic.Int64
+	authKeys          atomic.Pointer[[]string]
+
+	// Lifecycle reported by the readiness probe
+	restored     atomic.Bool
+	shuttingDown atomic.Bool
+
+	mu      sync.Mutex
+	waiters map[string][]chan struct{}
+	rules   map[string]ratelimit.Rule
+
+	// fencingToken is the last token handed out, stored under FencingTokenKey
+	fencingToken atomic.Uint64
+
+	// writeMu keeps BigCache writes and observer notifications in the same order
+	writeMu   sync.Mutex
+	observers []MutationObserver
 }
 
 type ValidationError struct {
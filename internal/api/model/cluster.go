@@ -0,0 +1,20 @@
+package model
+
+// ClusterInfo describes the membership known by this node
+type ClusterInfo struct {
+	Self         string   `json:"self"`
+	Members      []string `json:"members"`
+	VirtualNodes int      `json:"virtual_nodes"`
+	Rebalancing  bool     `json:"rebalancing"`
+}
+
+// Cluster partitions keys between members, implemented by cluster.Cluster
+type Cluster interface {
+	Info() ClusterInfo
+	// Owner returns the base URL of the member that owns key
+	Owner(key string) string
+	Self() string
+	// SetMembers replaces the member list and moves the keys
+	// this node no longer owns to their new owners
+	SetMembers(members []string)
+}
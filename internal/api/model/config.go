@@ -0,0 +1,18 @@
+package model
+
+// Configuration is the effective configuration, implemented by config.Config
+type Configuration interface {
+	// Source returns the config file, empty when there is none
+	Source() string
+	// Effective returns every setting nested by section
+	Effective() map[string]any
+	// Reload loads the configuration again and applies the settings
+	// that can change without a restart
+	Reload() (ReloadResult, error)
+}
+
+// ReloadResult lists the settings that changed, by key
+type ReloadResult struct {
+	Applied         []string `json:"applied"`
+	RequiresRestart []string `json:"requires_restart"`
+}
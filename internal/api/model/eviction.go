@@ -0,0 +1,45 @@
+package model
+
+import "errors"
+
+// ErrOutOfMemory is returned when a write would take the cache over
+// its max memory and the max memory policy is to reject writes
+var ErrOutOfMemory = errors.New("not enough memory left under the max memory")
+
+const (
+	// MaxMemoryEvict evicts keys chosen by the eviction policy
+	MaxMemoryEvict string = "evict"
+	// MaxMemoryReject rejects the writes that would go over the max memory
+	MaxMemoryReject string = "reject"
+	// MaxMemoryNoEviction neither evicts nor rejects, usage is only reported
+	MaxMemoryNoEviction string = "noeviction"
+)
+
+// EvictionInfo describes the eviction policy and its memory budget
+type EvictionInfo struct {
+	MaxMemoryPolicy string `json:"max_memory_policy"`
+	Policy          string `json:"policy"`
+	MaxBytes        int64  `json:"max_bytes"`
+	UsedBytes       int64  `json:"used_bytes"`
+	Entries         int    `json:"entries"`
+	Evictions       uint64 `json:"evictions"`
+	Rejected        uint64 `json:"rejected"`
+}
+
+// EvictionPolicy accounts the memory of every entry and keeps it under
+// a budget according to its max memory policy, implemented by eviction.Manager
+type EvictionPolicy interface {
+	Info() EvictionInfo
+	// Reserve returns ErrOutOfMemory when storing size bytes under key
+	// would go over the budget and writes are rejected
+	Reserve(key string, size int) error
+	// Touch records a read of key, hit or miss
+	Touch(key string)
+	// Admit records key being stored with size bytes and returns the keys
+	// to evict, which may include key itself when it is not worth keeping
+	Admit(key string, size int) []string
+	// Forget records key leaving the cache for another reason
+	Forget(key string)
+	// Reset forgets every key, used when the cache itself is reset
+	Reset()
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	MemberAlive   string = "alive"
+	MemberSuspect string = "suspect"
+	MemberDead    string = "dead"
+	MemberLeft    string = "left"
+)
+
+// MemberInfo is the view this node has of a gossip member
+type MemberInfo struct {
+	Name string `json:"name"`
+	// Address is the UDP address the member gossips on
+	Address string `json:"address"`
+	// URL is the HTTP base URL the member serves the API on
+	URL            string    `json:"url"`
+	State          string    `json:"state"`
+	Incarnation    uint64    `json:"incarnation"`
+	StateChangedAt time.Time `json:"state_changed_at"`
+}
+
+// Membership maintains the member list without a coordinator, implemented by gossip.Gossip
+type Membership interface {
+	Members() []MemberInfo
+	// Join contacts the members gossiping on addresses and
+	// returns how many of them answered
+	Join(addresses []string) (int, error)
+}
+
+type GossipJoinRequest struct {
+	Addresses []string `json:"addresses"`
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// healthKey is written and deleted by CheckStore, the namespace
+// keeps it apart from keys sent by clients
+const healthKey = "__health__:probe"
+
+// HealthCheck is the result of one check of the health probes
+type HealthCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// SetRestored records that the snapshot and the append-only file were loaded
+func (ctx *CacheAppContext) SetRestored() {
+	ctx.restored.Store(true)
+}
+
+func (ctx *CacheAppContext) Restored() bool {
+	return ctx.restored.Load()
+}
+
+// SetShuttingDown records that the server stops serving, it is not ready anymore
+func (ctx *CacheAppContext) SetShuttingDown() {
+	ctx.shuttingDown.Store(true)
+}
+
+func (ctx *CacheAppContext) ShuttingDown() bool {
+	return ctx.shuttingDown.Load()
+}
+
+// CheckStore writes a probe entry to BigCache, reads it back and deletes it.
+// Observers are not notified so the probe is neither persisted nor replicated,
+// it expires right away in case a snapshot catches it.
+func (ctx *CacheAppContext) CheckStore() error {
+	probe := CacheEntry{Value: strconv.FormatInt(time.Now().UnixNano(), 10), Expiration: time.Now()}
+	data, err := json.Marshal(probe)
+	if err != nil {
+		return err
+	}
+
+	ctx.writeMu.Lock()
+	defer ctx.writeMu.Unlock()
+
+	if err := ctx.Cache.Set(healthKey, data); err != nil {
+		return err
+	}
+
+	read, err := ctx.Cache.Get(healthKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(read, data) {
+		return errors.New("read back a different value than written")
+	}
+
+	return ctx.Cache.Delete(healthKey)
+}
@@ -0,0 +1,40 @@
+package model
+
+const (
+	InvalidateKey   string = "del"
+	InvalidateTag   string = "tag"
+	InvalidateFlush string = "flush"
+)
+
+// Invalidation is a delete, tag purge or flush broadcast to the other nodes.
+// ID is unique per invalidation so retried or repeated deliveries apply once.
+type Invalidation struct {
+	ID     string `json:"id"`
+	Origin string `json:"origin"`
+	Op     string `json:"op"`
+	Key    string `json:"key,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// InvalidationInfo counts the invalidations sent and received by this node
+type InvalidationInfo struct {
+	Origin     string   `json:"origin"`
+	Peers      []string `json:"peers"`
+	Multicast  string   `json:"multicast,omitempty"`
+	Sent       uint64   `json:"sent"`
+	Received   uint64   `json:"received"`
+	Duplicates uint64   `json:"duplicates"`
+	Retries    uint64   `json:"retries"`
+	Failed     uint64   `json:"failed"`
+	Rejected   uint64   `json:"rejected"`
+}
+
+// Invalidator fans invalidations out to the other nodes, implemented by invalidation.Broadcaster
+type Invalidator interface {
+	Info() InvalidationInfo
+	// Broadcast sends an invalidation already applied locally to every node
+	Broadcast(invalidation Invalidation)
+	// Receive applies an invalidation from another node,
+	// it returns false when the invalidation was already applied
+	Receive(invalidation Invalidation) (bool, error)
+}
@@ -0,0 +1,23 @@
+package model
+
+type ListPushRequest struct {
+	Key               string `json:"key"`
+	Values            []any  `json:"values"`
+	DurationInSeconds int    `json:"duration_in_seconds"`
+}
+
+type ListPopRequest struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+type ListBlockingPopRequest struct {
+	Key              string `json:"key"`
+	TimeoutInSeconds int    `json:"timeout_in_seconds"`
+}
+
+type ListTrimRequest struct {
+	Key   string `json:"key"`
+	Start int    `json:"start"`
+	Stop  int    `json:"stop"`
+}
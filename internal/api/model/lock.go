@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+type LockRequest struct {
+	Key           string `json:"key"`
+	Owner         string `json:"owner"`
+	TTLInSeconds  int    `json:"ttl_in_seconds"`
+	WaitInSeconds int    `json:"wait_in_seconds"`
+}
+
+// LockValue is stored as the Value of an EntryTypeLock entry
+type LockValue struct {
+	Owner        string `json:"owner"`
+	FencingToken uint64 `json:"fencing_token"`
+}
+
+// FencingTokenKey stores the last fencing token handed out. It is set like
+// any entry so the append-only file, the snapshots and the replicas keep it.
+const FencingTokenKey = "cache-engine:fencing-token"
+
+// fencingTokenLifetime outlives any lock, the entry is set again on every acquire
+const fencingTokenLifetime = 10 * 365 * 24 * time.Hour
+
+// NextFencingToken returns a token bigger than every token handed out before
+// and stores it under FencingTokenKey. Must be called while holding Lock.
+func (ctx *CacheAppContext) NextFencingToken(parent context.Context) (uint64, error) {
+	token := ctx.fencingToken.Add(1)
+	if err := ctx.SetEntryContext(parent, FencingTokenKey, ctx.fencingTokenEntry(token)); err != nil {
+		return 0, err
+	}
+
+	return token, nil
+}
+
+// FencingTokenEntry returns the entry holding the last token handed out,
+// ok is false before the first one. Snapshots and rewrites write it when
+// BigCache already dropped the entry at the end of its life window.
+func (ctx *CacheAppContext) FencingTokenEntry() (CacheEntry, bool) {
+	token := ctx.fencingToken.Load()
+	return ctx.fencingTokenEntry(token), token > 0
+}
+
+func (ctx *CacheAppContext) fencingTokenEntry(token uint64) CacheEntry {
+	return CacheEntry{Value: token, Expiration: time.Now().Add(fencingTokenLifetime)}
+}
+
+// SeedFencingToken raises the fencing token to the one stored under
+// FencingTokenKey. The counter lives in memory, so it is seeded once the
+// entry is restored from a snapshot, the append-only file, an import or a primary.
+func (ctx *CacheAppContext) SeedFencingToken() error {
+	entry, err := ctx.GetEntry(FencingTokenKey)
+	if err == bigcache.ErrEntryNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored uint64
+	if err := entry.DecodeValue(&stored); err != nil {
+		return err
+	}
+
+	ctx.raiseFencingToken(stored)
+	return nil
+}
+
+// SeedFencingTokenFromLock raises the fencing token to the one of a lock moved
+// from another member, so the tokens of that lock keep growing here. The raised
+// token is stored under FencingTokenKey. Must be called while holding Lock.
+func (ctx *CacheAppContext) SeedFencingTokenFromLock(entry CacheEntry) error {
+	lock := LockValue{}
+	if err := entry.DecodeValue(&lock); err != nil {
+		return err
+	}
+
+	if !ctx.raiseFencingToken(lock.FencingToken) {
+		return nil
+	}
+
+	return ctx.SetEntry(FencingTokenKey, ctx.fencingTokenEntry(lock.FencingToken))
+}
+
+// raiseFencingToken reports whether token was bigger than the current one
+func (ctx *CacheAppContext) raiseFencingToken(token uint64) bool {
+	for {
+		current := ctx.fencingToken.Load()
+		if token <= current {
+			return false
+		}
+		if ctx.fencingToken.CompareAndSwap(current, token) {
+			return true
+		}
+	}
+}
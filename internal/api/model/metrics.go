@@ -0,0 +1,55 @@
+package model
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	// NamespaceSeparator ends the namespace of a key, as in `user:42`
+	NamespaceSeparator string = ":"
+	// DefaultNamespace is the namespace of keys without a separator
+	DefaultNamespace string = "default"
+)
+
+// NamespaceOf returns the part of key before the first NamespaceSeparator
+func NamespaceOf(key string) string {
+	namespace, _, found := strings.Cut(key, NamespaceSeparator)
+	if !found || namespace == "" {
+		return DefaultNamespace
+	}
+
+	return namespace
+}
+
+// CountByNamespace returns how many entries of every namespace are in
+// BigCache, it iterates over all of them
+func (ctx *CacheAppContext) CountByNamespace() map[string]int {
+	counts := map[string]int{}
+	iterator := ctx.Cache.Iterator()
+	for iterator.SetNext() {
+		info, err := iterator.Value()
+		if err != nil {
+			continue
+		}
+		counts[NamespaceOf(info.Key())]++
+	}
+
+	return counts
+}
+
+// Metrics records what observers cannot see from mutations,
+// implemented by metrics.Metrics
+type Metrics interface {
+	// OnRead records a read of key from either tier, found or not
+	OnRead(key string, hit bool)
+	// OnDemote records a key the eviction policy moved from BigCache to SecondTier
+	OnDemote(key string)
+	// OnHotCopy records a copy of an entry owned by another peer being stored
+	OnHotCopy(key string)
+	// OnRequest records a request served on route, the pattern it matched
+	OnRequest(method string, route string, status int, duration time.Duration)
+	// WriteText writes every metric in the Prometheus text format
+	WriteText(w io.Writer) error
+}
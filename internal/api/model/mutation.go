@@ -0,0 +1,42 @@
+package model
+
+import "strings"
+
+const (
+	MutationSet    string = "set"
+	MutationDelete string = "del"
+	MutationExpire string = "expire"
+	// MutationEvict is a key dropped by the eviction policy to stay under its budget
+	MutationEvict string = "evict"
+)
+
+// Mutation describes a single write applied to the cache.
+// Data holds the encoded CacheEntry for MutationSet and is empty otherwise.
+type Mutation struct {
+	Op   string
+	Key  string
+	Data []byte
+}
+
+// MutationObserver is notified synchronously, in order, after every mutation
+// reaches BigCache. Implementations must not call back into the cache.
+type MutationObserver interface {
+	OnMutation(mutation Mutation)
+}
+
+// AddObserver registers observer for every following mutation
+func (ctx *CacheAppContext) AddObserver(observer MutationObserver) {
+	ctx.writeMu.Lock()
+	defer ctx.writeMu.Unlock()
+
+	ctx.observers = append(ctx.observers, observer)
+}
+
+// notify copies the key since Fiber reuses the buffers behind
+// request params and observers may keep mutations around
+func (ctx *CacheAppContext) notify(mutation Mutation) {
+	mutation.Key = strings.Clone(mutation.Key)
+	for _, observer := range ctx.observers {
+		observer.OnMutation(mutation)
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import "context"
+
+// PeerInfo describes the peers of this node and how their fills went
+type PeerInfo struct {
+	Self            string   `json:"self"`
+	Peers           []string `json:"peers"`
+	HotTTLInSeconds float64  `json:"hot_ttl_in_seconds"`
+	// Loads counts requests sent to owners, Shared the
+	// misses served by a load already in flight
+	Loads  uint64 `json:"loads"`
+	Shared uint64 `json:"shared"`
+	Misses uint64 `json:"misses"`
+	Errors uint64 `json:"errors"`
+}
+
+// Peers fills local misses from the peer owning the key, implemented by peer.Group
+type Peers interface {
+	Info() PeerInfo
+	// Owner returns the base URL of the peer that owns key
+	Owner(key string) string
+	Self() string
+	// Fill loads key from its owner and keeps a short lived local copy,
+	// it returns bigcache.ErrEntryNotFound when this node owns key
+	// or the owner does not hold it
+	Fill(parent context.Context, key string) (CacheEntry, error)
+}
@@ -0,0 +1,26 @@
+package model
+
+type HyperLogLogAddRequest struct {
+	Key               string   `json:"key"`
+	Elements          []string `json:"elements"`
+	DurationInSeconds int      `json:"duration_in_seconds"`
+}
+
+type HyperLogLogMergeRequest struct {
+	Destination       string   `json:"destination"`
+	Sources           []string `json:"sources"`
+	DurationInSeconds int      `json:"duration_in_seconds"`
+}
+
+type BloomCreateRequest struct {
+	Key               string  `json:"key"`
+	ErrorRate         float64 `json:"error_rate"`
+	Capacity          int     `json:"capacity"`
+	DurationInSeconds int     `json:"duration_in_seconds"`
+}
+
+type BloomItemsRequest struct {
+	Key               string   `json:"key"`
+	Items             []string `json:"items"`
+	DurationInSeconds int      `json:"duration_in_seconds"`
+}
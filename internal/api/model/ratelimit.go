@@ -0,0 +1,60 @@
+package model
+
+import (
+	"cache_engine_httpserver/internal/api/ratelimit"
+	"sort"
+)
+
+type RateLimitCheckRequest struct {
+	Rule   string `json:"rule"`
+	Client string `json:"client"`
+	Cost   int    `json:"cost"`
+}
+
+// RateLimitKey is the key holding the counter of client under rule
+func RateLimitKey(rule string, client string) string {
+	return "ratelimit:" + rule + ":" + client
+}
+
+// SetRateLimitRule adds or replaces a rule by name
+func (ctx *CacheAppContext) SetRateLimitRule(rule ratelimit.Rule) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	if ctx.rules == nil {
+		ctx.rules = make(map[string]ratelimit.Rule)
+	}
+	ctx.rules[rule.Name] = rule
+}
+
+// DeleteRateLimitRule removes a rule and reports whether it existed
+func (ctx *CacheAppContext) DeleteRateLimitRule(name string) bool {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	_, ok := ctx.rules[name]
+	delete(ctx.rules, name)
+	return ok
+}
+
+// RateLimitRule must be called while holding Lock
+func (ctx *CacheAppContext) RateLimitRule(name string) (ratelimit.Rule, bool) {
+	rule, ok := ctx.rules[name]
+	return rule, ok
+}
+
+// RateLimitRules returns every rule ordered by name
+func (ctx *CacheAppContext) RateLimitRules() []ratelimit.Rule {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	rules := make([]ratelimit.Rule, 0, len(ctx.rules))
+	for _, rule := range ctx.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Name < rules[j].Name
+	})
+
+	return rules
+}
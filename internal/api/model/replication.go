@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	RolePrimary string = "primary"
+	RoleReplica string = "replica"
+)
+
+// ReplicationRecord is a mutation tagged with its position in the primary stream
+type ReplicationRecord struct {
+	Offset uint64          `json:"offset"`
+	Op     string          `json:"op"`
+	Key    string          `json:"key"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// ReplicaInfo is what the primary knows about a connected replica
+type ReplicaInfo struct {
+	ID       string    `json:"id"`
+	Offset   uint64    `json:"offset"`
+	Lag      uint64    `json:"lag"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type ReplicationInfo struct {
+	Role          string `json:"role"`
+	ReplicationID string `json:"replication_id"`
+	Offset        uint64 `json:"offset"`
+	PrimaryURL    string `json:"primary_url,omitempty"`
+	LinkUp        bool   `json:"link_up"`
+	// Lag is how many records a replica is behind its primary
+	Lag        uint64        `json:"lag"`
+	LastSyncAt time.Time     `json:"last_sync_at,omitempty"`
+	Replicas   []ReplicaInfo `json:"replicas"`
+}
+
+// Replication is implemented by replication.Manager
+type Replication interface {
+	Role() string
+	Info() ReplicationInfo
+	// Position returns the replication ID and offset of the latest mutation
+	Position() (string, uint64)
+	// Changes returns up to limit records after offset, waiting up to wait
+	// when there is none yet. ok is false when the replica must full resync.
+	Changes(replicationID string, offset uint64, replicaID string, limit int, wait time.Duration) (records []ReplicationRecord, ok bool)
+	// Promote turns a replica into a primary
+	Promote() error
+}
@@ -0,0 +1,30 @@
+package model
+
+type SetMembersRequest struct {
+	Key               string   `json:"key"`
+	Members           []string `json:"members"`
+	DurationInSeconds int      `json:"duration_in_seconds"`
+}
+
+type SortedSetMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+type SortedSetAddRequest struct {
+	Key               string            `json:"key"`
+	Members           []SortedSetMember `json:"members"`
+	DurationInSeconds int               `json:"duration_in_seconds"`
+}
+
+type SortedSetIncrementRequest struct {
+	Key               string  `json:"key"`
+	Member            string  `json:"member"`
+	Increment         float64 `json:"increment"`
+	DurationInSeconds int     `json:"duration_in_seconds"`
+}
+
+type SortedSetRemoveRequest struct {
+	Key     string   `json:"key"`
+	Members []string `json:"members"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// SlowLogEntry is a request that took longer than the slow log threshold
+type SlowLogEntry struct {
+	ID                     uint64    `json:"id"`
+	Time                   time.Time `json:"time"`
+	DurationInMicroseconds int64     `json:"duration_in_microseconds"`
+	Operation              string    `json:"operation"`
+	Key                    string    `json:"key,omitempty"`
+	// ValueSize is the size of the request body of writes
+	// and of the response body of reads
+	ValueSize int    `json:"value_size"`
+	Client    string `json:"client"`
+}
+
+// OperationStats sums up the latency of every request of an operation
+type OperationStats struct {
+	Calls                 uint64  `json:"calls"`
+	TotalInMicroseconds   int64   `json:"total_in_microseconds"`
+	AverageInMicroseconds float64 `json:"average_in_microseconds"`
+	MaxInMicroseconds     int64   `json:"max_in_microseconds"`
+	SlowCalls             uint64  `json:"slow_calls"`
+}
+
+// SlowLogInfo describes the slow log settings and content
+type SlowLogInfo struct {
+	ThresholdInMicroseconds int64  `json:"threshold_in_microseconds"`
+	MaxLen                  int    `json:"max_len"`
+	Len                     int    `json:"len"`
+	Logged                  uint64 `json:"logged"`
+}
+
+// SlowLog keeps the latest requests slower than a threshold in a ring buffer
+// like Redis SLOWLOG and the latency of every operation, implemented by slowlog.Log
+type SlowLog interface {
+	Info() SlowLogInfo
+	// Observe counts a request of operation and reports whether it is slow enough to be added
+	Observe(operation string, duration time.Duration) bool
+	// Add logs entry, its ID and Time are set by Add
+	Add(entry SlowLogEntry)
+	// Entries returns the count latest entries, newest first, all of them when count is negative
+	Entries(count int) []SlowLogEntry
+	// Stats returns the latency of every operation observed
+	Stats() map[string]OperationStats
+	// SetThreshold changes the duration over which requests are logged,
+	// 0 logs every request and a negative duration none
+	SetThreshold(threshold time.Duration)
+	// Reset empties the log, the operation stats are kept like Redis SLOWLOG RESET does
+	Reset()
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SnapshotStatus reports the state of the last point-in-time snapshot
+type SnapshotStatus struct {
+	Path           string    `json:"path"`
+	InProgress     bool      `json:"in_progress"`
+	LastStartedAt  time.Time `json:"last_started_at"`
+	LastSuccessAt  time.Time `json:"last_success_at"`
+	LastDurationMs int64     `json:"last_duration_ms"`
+	LastEntries    int       `json:"last_entries"`
+	LastError      string    `json:"last_error"`
+}
+
+// Snapshotter writes the whole cache to disk, implemented by persistence.Snapshotter
+type Snapshotter interface {
+	// Trigger starts a snapshot in the background, false when one is already running
+	Trigger() bool
+	Status() SnapshotStatus
+}
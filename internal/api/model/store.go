@@ -0,0 +1,525 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrWrongType is returned when a command targets a key holding another data type
+var ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+// ErrEntryTooLarge is returned when an encoded entry exceeds MaxEntrySize
+var ErrEntryTooLarge = errors.New("entry is bigger than the max entry size")
+
+// entryHeaderSize mirrors the header BigCache wraps every entry with:
+// timestamp (8 bytes), key hash (8 bytes) and key length (2 bytes)
+const entryHeaderSize = 18
+
+// entryIndexSize is what a shard index spends on every entry,
+// a uint64 key hash mapped to a uint64 offset in the shard queue
+const entryIndexSize = 16
+
+// Lock serializes read-modify-write commands on typed entries.
+// BigCache is safe for concurrent Get/Set, but not for a Get followed by a Set.
+func (ctx *CacheAppContext) Lock() {
+	ctx.mu.Lock()
+}
+
+func (ctx *CacheAppContext) Unlock() {
+	ctx.mu.Unlock()
+}
+
+// GetEntry reads and decodes the entry stored under key.
+// Expired entries are deleted and reported as bigcache.ErrEntryNotFound.
+func (ctx *CacheAppContext) GetEntry(key string) (CacheEntry, error) {
+	return ctx.GetEntryContext(context.Background(), key)
+}
+
+// GetEntryContext is GetEntry traced under the span in parent
+func (ctx *CacheAppContext) GetEntryContext(parent context.Context, key string) (CacheEntry, error) {
+	entry := CacheEntry{}
+	data, err := ctx.GetRawContext(parent, key)
+	if err != nil {
+		return entry, err
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, err
+	}
+
+	if time.Now().After(entry.Expiration) {
+		if err := ctx.ExpireEntryContext(parent, key); err != nil {
+			return CacheEntry{}, err
+		}
+
+		return CacheEntry{}, bigcache.ErrEntryNotFound
+	}
+
+	return entry, nil
+}
+
+// GetRaw reads the encoded entry stored under key. On a BigCache miss the
+// entry is looked up in SecondTier and promoted back into BigCache.
+func (ctx *CacheAppContext) GetRaw(key string) ([]byte, error) {
+	return ctx.GetRawContext(context.Background(), key)
+}
+
+// GetRawContext is GetRaw traced under the span in parent
+func (ctx *CacheAppContext) GetRawContext(parent context.Context, key string) ([]byte, error) {
+	_, span := ctx.StartSpan(parent, "cache.get", key)
+	data, err := ctx.getRaw(key)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	EndSpan(span, err)
+
+	if ctx.Metrics != nil {
+		ctx.Metrics.OnRead(key, err == nil)
+	}
+
+	return data, err
+}
+
+func (ctx *CacheAppContext) getRaw(key string) ([]byte, error) {
+	// Misses are recorded too, W-TinyLFU admits keys by how often they are asked for
+	if ctx.Eviction != nil {
+		ctx.Eviction.Touch(key)
+	}
+
+	data, err := ctx.Cache.Get(key)
+	if err != bigcache.ErrEntryNotFound || ctx.SecondTier == nil {
+		return data, err
+	}
+
+	// Promotion holds writeMu from the read on disk to the write in memory,
+	// a set or a delete landing meanwhile must not be undone by the disk copy
+	ctx.writeMu.Lock()
+	defer ctx.writeMu.Unlock()
+
+	data, err = ctx.Cache.Get(key)
+	if err != bigcache.ErrEntryNotFound {
+		return data, err
+	}
+
+	data, err = ctx.SecondTier.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without room in memory the entry is served from disk and stays there
+	if ctx.Eviction != nil && ctx.Eviction.Reserve(key, MemorySize(key, data)) != nil {
+		return data, nil
+	}
+
+	// Promotion is not a mutation, observers already saw the entry being set
+	if err := ctx.Cache.Set(key, data); err != nil {
+		return nil, err
+	}
+	if _, err := ctx.SecondTier.Delete(key); err != nil {
+		return nil, err
+	}
+
+	if ctx.Eviction != nil {
+		if err := ctx.admit(key, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// GetTypedEntry is GetEntry that additionally checks the entry type
+func (ctx *CacheAppContext) GetTypedEntry(key string, entryType string) (CacheEntry, error) {
+	return ctx.GetTypedEntryContext(context.Background(), key, entryType)
+}
+
+// GetTypedEntryContext is GetTypedEntry traced under the span in parent
+func (ctx *CacheAppContext) GetTypedEntryContext(parent context.Context, key string, entryType string) (CacheEntry, error) {
+	entry, err := ctx.GetEntryContext(parent, key)
+	if err != nil {
+		return entry, err
+	}
+
+	if entry.TypeName() != entryType {
+		return entry, ErrWrongType
+	}
+
+	return entry, nil
+}
+
+// SetEntry encodes and stores entry under key
+func (ctx *CacheAppContext) SetEntry(key string, entry CacheEntry) error {
+	return ctx.SetEntryContext(context.Background(), key, entry)
+}
+
+// SetEntryContext is SetEntry traced under the span in parent
+func (ctx *CacheAppContext) SetEntryContext(parent context.Context, key string, entry CacheEntry) error {
+	// A hot copy written back by a command becomes an entry of this node
+	entry.Hot = false
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return ctx.SetRawContext(parent, key, data)
+}
+
+// SetRaw stores an already encoded CacheEntry and notifies observers
+func (ctx *CacheAppContext) SetRaw(key string, data []byte) error {
+	return ctx.SetRawContext(context.Background(), key, data)
+}
+
+// SetRawContext is SetRaw traced under the span in parent
+func (ctx *CacheAppContext) SetRawContext(parent context.Context, key string, data []byte) error {
+	_, span := ctx.StartSpan(parent, "cache.set", key)
+	span.SetAttributes(attribute.Int("cache.value_size", len(data)))
+	err := ctx.setRaw(key, data, true)
+	EndSpan(span, err)
+
+	return err
+}
+
+// SetHotCopy stores a copy of an entry owned by another peer. The copy counts
+// against the max memory like any entry, but observers are not notified so it
+// does not reach the append-only file or the replicas of this node.
+func (ctx *CacheAppContext) SetHotCopy(key string, entry CacheEntry) error {
+	entry.Hot = true
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.setRaw(key, data, false); err != nil {
+		return err
+	}
+
+	if ctx.Metrics != nil {
+		ctx.Metrics.OnHotCopy(key)
+	}
+
+	return nil
+}
+
+func (ctx *CacheAppContext) setRaw(key string, data []byte, notify bool) error {
+	if ctx.MaxEntrySize > 0 && EntrySize(key, data) > ctx.MaxEntrySize {
+		return ErrEntryTooLarge
+	}
+
+	ctx.writeMu.Lock()
+	defer ctx.writeMu.Unlock()
+
+	if ctx.Eviction != nil {
+		if err := ctx.Eviction.Reserve(key, MemorySize(key, data)); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Cache.Set(key, data); err != nil {
+		return err
+	}
+
+	if notify {
+		ctx.notify(Mutation{Op: MutationSet, Key: key, Data: data})
+	}
+
+	if ctx.Eviction != nil {
+		return ctx.admit(key, data)
+	}
+
+	return nil
+}
+
+// admit tells the eviction policy about a stored entry and removes the keys
+// it picks to stay under its budget, or demotes them when SecondTier is set.
+// Must be called while holding writeMu.
+func (ctx *CacheAppContext) admit(key string, data []byte) error {
+	for _, victim := range ctx.Eviction.Admit(key, MemorySize(key, data)) {
+		var err error
+		if ctx.SecondTier != nil {
+			err = ctx.demoteLocked(victim)
+		} else {
+			err = ctx.removeLocked(victim, MutationEvict)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// demoteLocked moves key from BigCache to SecondTier. The key is still
+// stored, so observers are not notified. Must be called while holding writeMu.
+func (ctx *CacheAppContext) demoteLocked(key string) error {
+	ctx.Eviction.Forget(key)
+
+	data, err := ctx.Cache.Get(key)
+	if err == bigcache.ErrEntryNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Queued before the delete so reads find the entry in either tier
+	ctx.SecondTier.Demote(key, data)
+	if err := ctx.Cache.Delete(key); err != nil && err != bigcache.ErrEntryNotFound {
+		return err
+	}
+
+	if ctx.Metrics != nil {
+		ctx.Metrics.OnDemote(key)
+	}
+
+	return nil
+}
+
+// DeleteEntry removes key, a missing key is not an error
+func (ctx *CacheAppContext) DeleteEntry(key string) error {
+	return ctx.DeleteEntryContext(context.Background(), key)
+}
+
+// DeleteEntryContext is DeleteEntry traced under the span in parent
+func (ctx *CacheAppContext) DeleteEntryContext(parent context.Context, key string) error {
+	return ctx.remove(parent, key, MutationDelete)
+}
+
+// ExpireEntry removes a key whose Expiration has passed
+func (ctx *CacheAppContext) ExpireEntry(key string) error {
+	return ctx.ExpireEntryContext(context.Background(), key)
+}
+
+// ExpireEntryContext is ExpireEntry traced under the span in parent
+func (ctx *CacheAppContext) ExpireEntryContext(parent context.Context, key string) error {
+	return ctx.remove(parent, key, MutationExpire)
+}
+
+// DeleteIfUnchanged deletes key only while it still holds data and reports
+// whether it did, a write landing after data was read keeps the key
+func (ctx *CacheAppContext) DeleteIfUnchanged(key string, data []byte) (bool, error) {
+	ctx.writeMu.Lock()
+	defer ctx.writeMu.Unlock()
+
+	current, err := ctx.Cache.Get(key)
+	if err == bigcache.ErrEntryNotFound && ctx.SecondTier != nil {
+		current, err = ctx.SecondTier.Get(key)
+	}
+	if err == bigcache.ErrEntryNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.Equal(current, data) {
+		return false, nil
+	}
+
+	return true, ctx.removeLocked(key, MutationDelete)
+}
+
+func (ctx *CacheAppContext) remove(parent context.Context, key string, op string) error {
+	_, span := ctx.StartSpan(parent, "cache."+op, key)
+
+	ctx.writeMu.Lock()
+	err := ctx.removeLocked(key, op)
+	ctx.writeMu.Unlock()
+
+	EndSpan(span, err)
+	return err
+}
+
+func (ctx *CacheAppContext) removeLocked(key string, op string) error {
+	if ctx.Eviction != nil {
+		ctx.Eviction.Forget(key)
+	}
+
+	err := ctx.Cache.Delete(key)
+	if err != nil && err != bigcache.ErrEntryNotFound {
+		return err
+	}
+
+	found := err == nil
+
+	// The key may only be left on disk after being evicted
+	if ctx.SecondTier != nil {
+		stored, err := ctx.SecondTier.Delete(key)
+		if err != nil {
+			return err
+		}
+		found = found || stored
+	}
+
+	if found {
+		ctx.notify(Mutation{Op: op, Key: key})
+	}
+
+	return nil
+}
+
+// PurgeTag deletes every key tagged with tag and returns how many were deleted
+func (ctx *CacheAppContext) PurgeTag(tag string) (int, error) {
+	return ctx.removeMatching(func(data []byte) bool {
+		entry := struct {
+			Tags []string `json:"tags"`
+		}{}
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return false
+		}
+
+		return slices.Contains(entry.Tags, tag)
+	})
+}
+
+// Flush deletes every key and returns how many were deleted. Keys are
+// deleted one by one so observers see a regular delete for each of them.
+func (ctx *CacheAppContext) Flush() (int, error) {
+	return ctx.removeMatching(func(data []byte) bool {
+		return true
+	})
+}
+
+func (ctx *CacheAppContext) removeMatching(match func(data []byte) bool) (int, error) {
+	keys := map[string]struct{}{}
+	iterator := ctx.Cache.Iterator()
+	for iterator.SetNext() {
+		info, err := iterator.Value()
+		if err != nil {
+			continue
+		}
+
+		if match(info.Value()) {
+			keys[info.Key()] = struct{}{}
+		}
+	}
+
+	// A key set again after being evicted is in both tiers
+	if ctx.SecondTier != nil {
+		tierKeys, err := ctx.SecondTier.Keys(match)
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range tierKeys {
+			keys[key] = struct{}{}
+		}
+	}
+
+	for key := range keys {
+		if err := ctx.DeleteEntry(key); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(keys), nil
+}
+
+// EntrySize is the number of bytes an encoded entry occupies inside BigCache
+func EntrySize(key string, data []byte) int {
+	return entryHeaderSize + len(key) + len(data)
+}
+
+// MemorySize is the number of bytes an entry costs in memory:
+// the encoded value and metadata, the BigCache header and the shard index
+func MemorySize(key string, data []byte) int {
+	return EntrySize(key, data) + entryIndexSize
+}
+
+// MaxEntrySizeFor derives the biggest accepted entry from the BigCache config.
+// BigCache rejects entries bigger than a single shard, which is only bounded
+// when HardMaxCacheSize is set.
+func MaxEntrySizeFor(config bigcache.Config) int {
+	if config.HardMaxCacheSize <= 0 || config.Shards <= 0 {
+		return 0
+	}
+
+	return config.HardMaxCacheSize * 1024 * 1024 / config.Shards
+}
+
+// TypeName returns the entry type, defaulting to EntryTypeString
+func (entry CacheEntry) TypeName() string {
+	if entry.Type == "" {
+		return EntryTypeString
+	}
+
+	return entry.Type
+}
+
+// DecodeValue converts the generic decoded Value into target,
+// used by types whose Value is a struct rather than a JSON primitive
+func (entry CacheEntry) DecodeValue(target any) error {
+	data, err := json.Marshal(entry.Value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+// ExpirationFor returns the expiration time for a new entry.
+// Falls back to DefaultExpiration when durationInSeconds is not positive.
+func (ctx *CacheAppContext) ExpirationFor(durationInSeconds int) time.Time {
+	if durationInSeconds > 0 {
+		return time.Now().Add(time.Duration(durationInSeconds) * time.Second)
+	}
+
+	return time.Now().Add(ctx.GetDefaultExpiration())
+}
+
+// GetDefaultExpiration returns the TTL of entries created without one
+func (ctx *CacheAppContext) GetDefaultExpiration() time.Duration {
+	if expiration := ctx.defaultExpiration.Load(); expiration > 0 {
+		return time.Duration(expiration)
+	}
+
+	return ctx.DefaultExpiration
+}
+
+// SetDefaultExpiration changes DefaultExpiration while serving
+func (ctx *CacheAppContext) SetDefaultExpiration(expiration time.Duration) {
+	ctx.defaultExpiration.Store(int64(expiration))
+}
+
+// Wait registers a channel that is closed by the next Notify for key.
+// Must be called while holding Lock.
+func (ctx *CacheAppContext) Wait(key string) chan struct{} {
+	if ctx.waiters == nil {
+		ctx.waiters = make(map[string][]chan struct{})
+	}
+
+	ch := make(chan struct{})
+	ctx.waiters[key] = append(ctx.waiters[key], ch)
+	return ch
+}
+
+// StopWaiting removes a channel registered by Wait that was never notified
+func (ctx *CacheAppContext) StopWaiting(key string, ch chan struct{}) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	waiters := ctx.waiters[key]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			ctx.waiters[key] = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+
+	if len(ctx.waiters[key]) == 0 {
+		delete(ctx.waiters, key)
+	}
+}
+
+// Notify wakes up every waiter registered for key.
+// Must be called while holding Lock.
+func (ctx *CacheAppContext) Notify(key string) {
+	for _, ch := range ctx.waiters[key] {
+		close(ch)
+	}
+
+	delete(ctx.waiters, key)
+}
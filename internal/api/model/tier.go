@@ -0,0 +1,34 @@
+package model
+
+// TierInfo describes the disk tier holding entries evicted from BigCache
+type TierInfo struct {
+	Dir       string `json:"dir"`
+	Entries   int    `json:"entries"`
+	LiveBytes int64  `json:"live_bytes"`
+	FileBytes int64  `json:"file_bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Pending   int    `json:"pending"`
+	// Demoted counts entries received from BigCache, Evicted the ones
+	// dropped over MaxBytes and Hits the ones promoted back to BigCache
+	Demoted uint64 `json:"demoted"`
+	Evicted uint64 `json:"evicted"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// SecondTier stores the entries BigCache evicts before they expire,
+// implemented by tier.DiskStore
+type SecondTier interface {
+	Info() TierInfo
+	// Get returns an encoded CacheEntry or bigcache.ErrEntryNotFound
+	Get(key string) ([]byte, error)
+	// Demote stores an entry the eviction policy removed from BigCache
+	Demote(key string, data []byte)
+	// Delete reports whether key was stored
+	Delete(key string) (bool, error)
+	// Keys returns the keys whose encoded entry matches
+	Keys(match func(data []byte) bool) ([]string, error)
+	// Each calls fn with every live key and its encoded CacheEntry
+	Each(fn func(key string, data []byte) error) error
+	Reset() error
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/allegro/bigcache/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// noopTracer stands in for Tracer when tracing is disabled
+var noopTracer = noop.NewTracerProvider().Tracer("")
+
+// KeyHash identifies key in traces and logs without revealing it
+func KeyHash(key string) string {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(key))
+	return strconv.FormatUint(hasher.Sum64(), 16)
+}
+
+// StartSpan starts a child span of the span in parent for an operation on key.
+// Operations outside of a traced request, like restoring the cache or
+// applying replicated writes, are not traced so they do not flood the exporter.
+func (ctx *CacheAppContext) StartSpan(parent context.Context, name string, key string) (context.Context, trace.Span) {
+	tracer := ctx.Tracer
+	if tracer == nil || !trace.SpanContextFromContext(parent).IsValid() {
+		tracer = noopTracer
+	}
+
+	return tracer.Start(parent, name, trace.WithAttributes(
+		attribute.String("cache.namespace", NamespaceOf(key)),
+		attribute.String("cache.key_hash", KeyHash(key)),
+	))
+}
+
+// EndSpan ends span, recording err unless it only means the key is missing
+func EndSpan(span trace.Span, err error) {
+	if err != nil && err != bigcache.ErrEntryNotFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,143 @@
+package peer
+
+import (
+	"cache_engine_httpserver/internal/api/cluster"
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Group implements model.Peers. Every peer owns the keys the consistent hash
+// ring gives it, a miss on any other peer is filled from the owner and the
+// entry is kept locally for hotTTL so hot keys are not fetched on every read.
+type Group struct {
+	ctx    *model.CacheAppContext
+	self   string
+	ring   *cluster.Ring
+	hotTTL time.Duration
+	client *http.Client
+	flight flight
+
+	loads  atomic.Uint64
+	shared atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// New creates a group where self is the base URL the other peers reach this node on
+func New(ctx *model.CacheAppContext, self string, peers []string, hotTTL time.Duration) *Group {
+	self = strings.TrimRight(strings.TrimSpace(self), "/")
+	members := []string{self}
+	for _, peer := range peers {
+		if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer != "" {
+			members = append(members, peer)
+		}
+	}
+
+	return &Group{
+		ctx:    ctx,
+		self:   self,
+		ring:   cluster.NewRing(cluster.DefaultVirtualNodes, members),
+		hotTTL: hotTTL,
+		client: &http.Client{Timeout: 5 * time.Second, Transport: ctx.NodeTransport()},
+	}
+}
+
+func (group *Group) Self() string {
+	return group.self
+}
+
+func (group *Group) Owner(key string) string {
+	return group.ring.Owner(key)
+}
+
+func (group *Group) Info() model.PeerInfo {
+	return model.PeerInfo{
+		Self:            group.self,
+		Peers:           group.ring.Members(),
+		HotTTLInSeconds: group.hotTTL.Seconds(),
+		Loads:           group.loads.Load(),
+		Shared:          group.shared.Load(),
+		Misses:          group.misses.Load(),
+		Errors:          group.errors.Load(),
+	}
+}
+
+func (group *Group) Fill(parent context.Context, key string) (model.CacheEntry, error) {
+	owner := group.Owner(key)
+	if owner == "" || owner == group.self {
+		return model.CacheEntry{}, bigcache.ErrEntryNotFound
+	}
+
+	entry, err, shared := group.flight.do(key, func() (model.CacheEntry, error) {
+		return group.load(parent, owner, key)
+	})
+	if shared {
+		group.shared.Add(1)
+	}
+
+	return entry, err
+}
+
+// load fetches key from owner and stores the hot copy, the request
+// carries the span of parent so the owner continues the same trace
+func (group *Group) load(parent context.Context, owner string, key string) (entry model.CacheEntry, err error) {
+	group.loads.Add(1)
+
+	parent, span := group.ctx.StartSpan(parent, "peer.load", key)
+	span.SetAttributes(attribute.String("peer.owner", owner))
+	defer func() { model.EndSpan(span, err) }()
+
+	target := owner + "/" + config.BASE_URL_NAME + "/internal/peer/get?key=" + url.QueryEscape(key)
+	req, err := http.NewRequestWithContext(parent, http.MethodGet, target, nil)
+	if err != nil {
+		return model.CacheEntry{}, err
+	}
+	resp, err := group.client.Do(req)
+	if err != nil {
+		group.errors.Add(1)
+		return model.CacheEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	response := struct {
+		Status  string            `json:"status"`
+		Message string            `json:"message"`
+		Cache   *model.CacheEntry `json:"cache"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		group.errors.Add(1)
+		return model.CacheEntry{}, err
+	}
+
+	if response.Status != "OK" || response.Cache == nil {
+		if response.Message != "Key not found" {
+			group.errors.Add(1)
+			return model.CacheEntry{}, fmt.Errorf("peer `%s` failed to load `%s` : %s", owner, key, response.Message)
+		}
+
+		group.misses.Add(1)
+		return model.CacheEntry{}, bigcache.ErrEntryNotFound
+	}
+
+	entry = *response.Cache
+	if hotExpiration := time.Now().Add(group.hotTTL); entry.Expiration.After(hotExpiration) {
+		entry.Expiration = hotExpiration
+	}
+
+	if err := group.ctx.SetHotCopy(key, entry); err != nil {
+		return model.CacheEntry{}, err
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,195 @@
+package peer
+
+import (
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/router"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	ctx   *model.CacheAppContext
+	group *Group
+	url   string
+}
+
+func newAppContext() *model.CacheAppContext {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	return &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+}
+
+// startPeers serves the whole API of size peers on loopback ports, the
+// listeners are opened first so every peer knows the others' URL
+func startPeers(t *testing.T, size int, hotTTL time.Duration) []*node {
+	listeners := []net.Listener{}
+	urls := []string{}
+	for i := 0; i < size; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		listeners = append(listeners, listener)
+		urls = append(urls, "http://"+listener.Addr().String())
+	}
+
+	nodes := []*node{}
+	for i, listener := range listeners {
+		ctx := newAppContext()
+		group := New(ctx, urls[i], urls, hotTTL)
+		ctx.Peers = group
+
+		app := fiber.New()
+		app.Use(middleware.AuthMiddleware(ctx))
+		router.HandleRoute(app, ctx)
+		go app.Listener(listener, fiber.ListenConfig{DisableStartupMessage: true})
+		t.Cleanup(func() {
+			app.ShutdownWithTimeout(time.Second)
+		})
+
+		nodes = append(nodes, &node{ctx: ctx, group: group, url: urls[i]})
+	}
+
+	return nodes
+}
+
+func (node *node) value(t *testing.T, key string) any {
+	resp, err := http.Get(node.url + "/cache-engine-api/get?key=" + key)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	cache, ok := response["cache"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return cache["value"]
+}
+
+// ownerAndReader returns the owner of key and another peer
+func ownerAndReader(nodes []*node, key string) (*node, *node) {
+	var owner, reader *node
+	for _, node := range nodes {
+		if node.url == node.group.Owner(key) {
+			owner = node
+		} else {
+			reader = node
+		}
+	}
+	return owner, reader
+}
+
+func TestPeerFill(t *testing.T) {
+	nodes := startPeers(t, 3, time.Minute)
+
+	owner, reader := ownerAndReader(nodes, "greeting")
+	owner.ctx.SetEntry("greeting", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+
+	assert.Equal(t, "hello", reader.value(t, "greeting"))
+
+	// The hot copy expires with the hot TTL, not the TTL of the owner
+	entry, err := reader.ctx.GetEntry("greeting")
+	assert.NoError(t, err)
+	assert.True(t, entry.Hot)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), entry.Expiration, 5*time.Second)
+
+	assert.Equal(t, "hello", reader.value(t, "greeting"))
+	assert.Equal(t, uint64(1), reader.group.Info().Loads)
+
+	// The owner does not fill its own misses
+	assert.Nil(t, owner.value(t, "missing"))
+	assert.Nil(t, reader.value(t, "missing"))
+}
+
+func TestPeerFillWithAuthKeys(t *testing.T) {
+	nodes := startPeers(t, 2, time.Minute)
+	for _, node := range nodes {
+		node.ctx.SetAuthKeys([]string{"current", "previous"})
+	}
+
+	owner, reader := ownerAndReader(nodes, "greeting")
+	owner.ctx.SetEntry("greeting", model.CacheEntry{Value: "hello", Expiration: time.Now().Add(time.Hour)})
+
+	resp, err := http.Get(reader.url + "/cache-engine-api/get?key=greeting")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// The reader authenticates to the owner with its own key
+	req, _ := http.NewRequest(http.MethodGet, reader.url+"/cache-engine-api/get?key=greeting", nil)
+	req.Header.Set(model.AuthKeyHeader, "previous")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, "hello", response["cache"].(map[string]any)["value"])
+	assert.Zero(t, reader.group.Info().Errors)
+}
+
+func TestPeerFillHotCopyExpires(t *testing.T) {
+	nodes := startPeers(t, 2, 200*time.Millisecond)
+
+	owner, reader := ownerAndReader(nodes, "counter")
+	owner.ctx.SetEntry("counter", model.CacheEntry{Value: "1", Expiration: time.Now().Add(time.Hour)})
+	assert.Equal(t, "1", reader.value(t, "counter"))
+
+	owner.ctx.SetEntry("counter", model.CacheEntry{Value: "2", Expiration: time.Now().Add(time.Hour)})
+	assert.Equal(t, "1", reader.value(t, "counter"))
+
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, "2", reader.value(t, "counter"))
+}
+
+func TestPeerFillSingleflight(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": "OK",
+			"cache":  model.CacheEntry{Value: r.URL.Query().Get("key"), Expiration: time.Now().Add(time.Hour)},
+		})
+	}))
+	defer server.Close()
+
+	group := New(newAppContext(), "http://127.0.0.1:1", []string{server.URL}, time.Minute)
+
+	key := ""
+	for i := 0; key == ""; i++ {
+		if candidate := "key-" + strconv.Itoa(i); group.Owner(candidate) == server.URL {
+			key = candidate
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := group.Fill(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, key, entry.Value)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Equal(t, uint64(1), group.Info().Loads)
+	assert.Equal(t, uint64(9), group.Info().Shared)
+}
@@ -0,0 +1,46 @@
+package peer
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"sync"
+)
+
+type call struct {
+	done  chan struct{}
+	entry model.CacheEntry
+	err   error
+}
+
+// flight makes concurrent loads of the same key share a single request
+type flight struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do runs load once for all callers asking for key at the same time,
+// shared reports whether the result came from another caller's load
+func (flight *flight) do(key string, load func() (model.CacheEntry, error)) (entry model.CacheEntry, err error, shared bool) {
+	flight.mu.Lock()
+	if flight.calls == nil {
+		flight.calls = make(map[string]*call)
+	}
+
+	if inFlight, ok := flight.calls[key]; ok {
+		flight.mu.Unlock()
+		<-inFlight.done
+		return inFlight.entry, inFlight.err, true
+	}
+
+	current := &call{done: make(chan struct{})}
+	flight.calls[key] = current
+	flight.mu.Unlock()
+
+	current.entry, current.err = load()
+	close(current.done)
+
+	flight.mu.Lock()
+	delete(flight.calls, key)
+	flight.mu.Unlock()
+
+	return current.entry, current.err, false
+}
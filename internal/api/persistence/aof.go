@@ -0,0 +1,396 @@
+package persistence
+
+import (
+	"bufio"
+	"cache_engine_httpserver/internal/api/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	FsyncAlways   string = "always"
+	FsyncEverySec string = "everysec"
+	FsyncNo       string = "no"
+)
+
+// Record is a single line of the append-only file or of a snapshot
+type Record struct {
+	Op   string          `json:"op"`
+	Key  string          `json:"key"`
+	Data json.RawMessage `json:"data,omitempty"`
+	// RemainingMs is the TTL left when the record was written, only set by
+	// snapshots and exports so imports do not depend on the source clock
+	RemainingMs int64 `json:"remaining_ms,omitempty"`
+}
+
+// AOF appends every cache mutation to a file so the cache can be rebuilt
+// on startup. It is registered as a model.MutationObserver.
+type AOF struct {
+	path           string
+	fsync          string
+	minRewriteSize int64
+
+	mu              sync.Mutex
+	file            *os.File
+	writer          *bufio.Writer
+	size            int64
+	lastRewriteSize int64
+	rewriteBuffer   [][]byte
+	rewriting       bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenAOF opens or creates the file at path. fsync is one of FsyncAlways,
+// FsyncEverySec or FsyncNo. The log is rewritten in the background once it is
+// bigger than minRewriteSize and has doubled since the last rewrite.
+func OpenAOF(path string, fsync string, minRewriteSize int64) (*AOF, error) {
+	if fsync != FsyncAlways && fsync != FsyncEverySec && fsync != FsyncNo {
+		return nil, fmt.Errorf("invalid fsync policy `%s`, expected always, everysec or no", fsync)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &AOF{
+		path:            path,
+		fsync:           fsync,
+		minRewriteSize:  minRewriteSize,
+		file:            file,
+		writer:          bufio.NewWriter(file),
+		size:            info.Size(),
+		lastRewriteSize: info.Size(),
+	}, nil
+}
+
+// Replay applies every record of the file to the cache and returns how many
+// records were applied. Must be called before the AOF is added as an observer.
+// A truncated last record, left by a crash during a write, is cut off the
+// file so the next record is not appended to the same line.
+func (aof *AOF) Replay(ctx *model.CacheAppContext) (int, error) {
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+
+	if _, err := aof.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	offset := int64(0)
+	reader := bufio.NewReader(aof.file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("Dropping truncated record at the end of `%s`", aof.path)
+				if err := aof.truncate(offset); err != nil {
+					return applied, err
+				}
+			}
+			break
+		}
+
+		if err != nil {
+			return applied, err
+		}
+
+		record := Record{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return applied, fmt.Errorf("corrupted record %d in `%s` : %w", applied+1, aof.path, err)
+		}
+
+		if err := ApplyRecord(ctx, record); err != nil {
+			return applied, err
+		}
+		applied++
+		offset += int64(len(line))
+	}
+
+	return applied, nil
+}
+
+// truncate cuts the file to its first size bytes, appends
+// still go to the end since the file is opened with O_APPEND
+func (aof *AOF) truncate(size int64) error {
+	if err := aof.file.Truncate(size); err != nil {
+		return err
+	}
+	if err := aof.file.Sync(); err != nil {
+		return err
+	}
+
+	aof.size = size
+	aof.lastRewriteSize = size
+	return nil
+}
+
+// ApplyRecord writes a single record to the cache, entries that expired
+// while the server was down are skipped
+func ApplyRecord(ctx *model.CacheAppContext, record Record) error {
+	if record.Op != model.MutationSet {
+		return ctx.DeleteEntry(record.Key)
+	}
+
+	entry := model.CacheEntry{}
+	if err := json.Unmarshal(record.Data, &entry); err != nil {
+		return err
+	}
+
+	if time.Now().After(entry.Expiration) {
+		return ctx.DeleteEntry(record.Key)
+	}
+
+	if err := ctx.SetRaw(record.Key, record.Data); err != nil {
+		return err
+	}
+
+	return seedFencingToken(ctx, record.Key)
+}
+
+// seedFencingToken raises the fencing token counter, which only lives in
+// memory, when key is the entry holding the last token handed out
+func seedFencingToken(ctx *model.CacheAppContext, key string) error {
+	if key != model.FencingTokenKey {
+		return nil
+	}
+
+	return ctx.SeedFencingToken()
+}
+
+func (aof *AOF) OnMutation(mutation model.Mutation) {
+	line, err := encodeRecord(Record{Op: mutation.Op, Key: mutation.Key, Data: mutation.Data})
+	if err != nil {
+		log.Printf("Error when encoding AOF record : %v", err.Error())
+		return
+	}
+
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+
+	if aof.rewriting {
+		aof.rewriteBuffer = append(aof.rewriteBuffer, line)
+	}
+
+	n, err := aof.writer.Write(line)
+	aof.size += int64(n)
+	if err != nil {
+		log.Printf("Error when writing AOF record : %v", err.Error())
+		return
+	}
+
+	if aof.fsync == FsyncAlways {
+		if err := aof.flush(true); err != nil {
+			log.Printf("Error when syncing AOF : %v", err.Error())
+		}
+	}
+}
+
+// Start flushes the log every second, fsyncing it with FsyncEverySec,
+// and triggers a background rewrite when the log grew too much
+func (aof *AOF) Start(ctx *model.CacheAppContext) {
+	aof.stop = make(chan struct{})
+	aof.done = make(chan struct{})
+
+	go func() {
+		defer close(aof.done)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-aof.stop:
+				return
+			case <-ticker.C:
+				aof.mu.Lock()
+				err := aof.flush(aof.fsync == FsyncEverySec)
+				needsRewrite := aof.needsRewrite()
+				aof.mu.Unlock()
+
+				if err != nil {
+					log.Printf("Error when flushing AOF : %v", err.Error())
+				}
+
+				if needsRewrite {
+					if err := aof.Rewrite(ctx); err != nil {
+						log.Printf("Error when rewriting AOF : %v", err.Error())
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Rewrite compacts the log into one set record per live key.
+// Writes keep being appended to the old file while the cache is scanned and
+// are replayed on top of the new file before it replaces the old one.
+func (aof *AOF) Rewrite(ctx *model.CacheAppContext) error {
+	aof.mu.Lock()
+	if aof.rewriting {
+		aof.mu.Unlock()
+		return errors.New("AOF rewrite already in progress")
+	}
+	aof.rewriting = true
+	aof.rewriteBuffer = nil
+	aof.mu.Unlock()
+
+	tempPath := aof.path + ".rewrite"
+	err := aof.writeLiveEntries(ctx, tempPath)
+
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+	defer func() {
+		aof.rewriting = false
+		aof.rewriteBuffer = nil
+	}()
+
+	if err == nil {
+		err = aof.swap(tempPath)
+	}
+
+	if err != nil {
+		os.Remove(tempPath)
+	}
+
+	return err
+}
+
+// Close flushes and syncs pending records and stops the background goroutine
+func (aof *AOF) Close() error {
+	if aof.stop != nil {
+		close(aof.stop)
+		<-aof.done
+	}
+
+	aof.mu.Lock()
+	defer aof.mu.Unlock()
+
+	if err := aof.flush(true); err != nil {
+		return err
+	}
+
+	return aof.file.Close()
+}
+
+func (aof *AOF) writeLiveEntries(ctx *model.CacheAppContext, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = eachLiveEntry(ctx, matchAll, func(key string, data []byte, _ model.CacheEntry) error {
+		line, err := encodeRecord(Record{Op: model.MutationSet, Key: key, Data: data})
+		if err != nil {
+			return err
+		}
+
+		_, err = writer.Write(line)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// swap appends the records buffered during the rewrite to the new file and
+// replaces the current log with it. Must be called while holding aof.mu.
+func (aof *AOF) swap(tempPath string) error {
+	if err := aof.flush(true); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range aof.rewriteBuffer {
+		if _, err := file.Write(line); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	if err := os.Rename(tempPath, aof.path); err != nil {
+		return err
+	}
+
+	newFile, err := os.OpenFile(aof.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := newFile.Stat()
+	if err != nil {
+		newFile.Close()
+		return err
+	}
+
+	aof.file.Close()
+	aof.file = newFile
+	aof.writer = bufio.NewWriter(newFile)
+	aof.size = info.Size()
+	aof.lastRewriteSize = info.Size()
+
+	return nil
+}
+
+// flush must be called while holding aof.mu
+func (aof *AOF) flush(sync bool) error {
+	if err := aof.writer.Flush(); err != nil {
+		return err
+	}
+
+	if sync {
+		return aof.file.Sync()
+	}
+
+	return nil
+}
+
+// needsRewrite must be called while holding aof.mu
+func (aof *AOF) needsRewrite() bool {
+	return !aof.rewriting && aof.size >= aof.minRewriteSize && aof.size >= 2*aof.lastRewriteSize
+}
+
+func matchAll(string) bool {
+	return true
+}
+
+func encodeRecord(record Record) ([]byte, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func newAppContext() *model.CacheAppContext {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	return &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestAOFReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	aof, err := OpenAOF(path, FsyncAlways, 1024*1024)
+	assert.NoError(t, err)
+
+	ctx := newAppContext()
+	ctx.AddObserver(aof)
+	assert.NoError(t, ctx.SetEntry("kept", model.CacheEntry{Value: "v1", Expiration: time.Now().Add(time.Minute)}))
+	assert.NoError(t, ctx.SetEntry("deleted", model.CacheEntry{Value: "v2", Expiration: time.Now().Add(time.Minute)}))
+	assert.NoError(t, ctx.SetEntry("expired", model.CacheEntry{Value: "v3", Expiration: time.Now().Add(-time.Second)}))
+	assert.NoError(t, ctx.DeleteEntry("deleted"))
+	assert.NoError(t, aof.Close())
+
+	// Simulate a crash in the middle of a write
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.WriteString(`{"op":"set","key":"partial"`)
+	file.Close()
+
+	aof, err = OpenAOF(path, FsyncAlways, 1024*1024)
+	assert.NoError(t, err)
+
+	restored := newAppContext()
+	applied, err := aof.Replay(restored)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, applied)
+
+	entry, err := restored.GetEntry("kept")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", entry.Value)
+
+	_, err = restored.GetEntry("deleted")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+
+	_, err = restored.GetEntry("expired")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+
+	// The truncated record was cut off, so writes after the crash replay as well
+	restored.AddObserver(aof)
+	assert.NoError(t, restored.SetEntry("after", model.CacheEntry{Value: "v4", Expiration: time.Now().Add(time.Minute)}))
+	assert.NoError(t, aof.Close())
+
+	aof, err = OpenAOF(path, FsyncAlways, 1024*1024)
+	assert.NoError(t, err)
+	defer aof.Close()
+
+	restored = newAppContext()
+	applied, err = aof.Replay(restored)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, applied)
+
+	entry, err = restored.GetEntry("after")
+	assert.NoError(t, err)
+	assert.Equal(t, "v4", entry.Value)
+}
+
+func TestAOFRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	aof, err := OpenAOF(path, FsyncNo, 1024*1024)
+	assert.NoError(t, err)
+
+	ctx := newAppContext()
+	ctx.AddObserver(aof)
+	for i := 0; i < 50; i++ {
+		assert.NoError(t, ctx.SetEntry("counter", model.CacheEntry{Value: float64(i), Expiration: time.Now().Add(time.Minute)}))
+	}
+	assert.NoError(t, ctx.SetEntry("other", model.CacheEntry{Value: "x", Expiration: time.Now().Add(time.Minute)}))
+
+	assert.NoError(t, aof.Rewrite(ctx))
+	assert.NoError(t, ctx.DeleteEntry("other"))
+	assert.NoError(t, aof.Close())
+
+	assert.Equal(t, 3, countLines(t, path))
+
+	aof, err = OpenAOF(path, FsyncNo, 1024*1024)
+	assert.NoError(t, err)
+	defer aof.Close()
+
+	restored := newAppContext()
+	_, err = aof.Replay(restored)
+	assert.NoError(t, err)
+
+	entry, err := restored.GetEntry("counter")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(49), entry.Value)
+
+	_, err = restored.GetEntry("other")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+}
+
+func TestOpenAOFWithInvalidFsync(t *testing.T) {
+	_, err := OpenAOF(filepath.Join(t.TempDir(), "appendonly.aof"), "sometimes", 0)
+	assert.Error(t, err)
+}
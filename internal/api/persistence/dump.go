@@ -0,0 +1,204 @@
+package persistence
+
+import (
+	"bufio"
+	"bytes"
+	"cache_engine_httpserver/internal/api/model"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatNDJSON string = "ndjson"
+	FormatBinary string = "binary"
+
+	ConflictOverwrite string = "overwrite"
+	ConflictSkip      string = "skip"
+)
+
+// BigCache stores key length in 2 bytes, values are capped to keep a corrupted
+// length from allocating the whole memory
+const (
+	maxBinaryKeyLength  = 1<<16 - 1
+	maxBinaryDataLength = 1 << 30
+)
+
+// binaryMagic starts every binary dump, the last byte is the format version
+var binaryMagic = []byte("CEHSDMP\x01")
+
+// ImportResult counts what happened to every record of an import
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	Expired  int `json:"expired"`
+	// Keys lists the imported keys, only filled when asked for
+	Keys []string `json:"keys,omitempty"`
+}
+
+// Export streams every live entry whose key starts with prefix to w
+func Export(ctx *model.CacheAppContext, w io.Writer, format string, prefix string) (int, error) {
+	switch format {
+	case FormatNDJSON:
+		return WriteEntries(ctx, w, prefix)
+	case FormatBinary:
+		return writeBinary(ctx, w, prefix)
+	default:
+		return 0, fmt.Errorf("unsupported export format `%s`", format)
+	}
+}
+
+// Import loads a dump written by Export. Remaining TTLs are applied from the
+// time of import, records that expired in transit are skipped. With
+// ConflictSkip, keys that already hold a live entry are left untouched.
+// With listKeys the result names every imported key.
+func Import(ctx *model.CacheAppContext, r io.Reader, format string, conflict string, listKeys bool) (ImportResult, error) {
+	result := ImportResult{}
+	if conflict != ConflictOverwrite && conflict != ConflictSkip {
+		return result, fmt.Errorf("unsupported conflict policy `%s`", conflict)
+	}
+
+	apply := func(record Record) error {
+		imported, err := importRecord(ctx, record, conflict, &result)
+		if imported && listKeys {
+			result.Keys = append(result.Keys, record.Key)
+		}
+		return err
+	}
+
+	switch format {
+	case FormatNDJSON:
+		return result, eachRecord(r, apply)
+	case FormatBinary:
+		return result, eachBinaryRecord(r, apply)
+	default:
+		return result, fmt.Errorf("unsupported import format `%s`", format)
+	}
+}
+
+func importRecord(ctx *model.CacheAppContext, record Record, conflict string, result *ImportResult) (bool, error) {
+	if record.RemainingMs <= 0 {
+		result.Expired++
+		return false, nil
+	}
+
+	entry := model.CacheEntry{}
+	if err := json.Unmarshal(record.Data, &entry); err != nil {
+		return false, err
+	}
+
+	// Held like commands on typed entries do, so no write lands between
+	// the existence check and the import of the key
+	ctx.Lock()
+	defer ctx.Unlock()
+
+	if conflict == ConflictSkip {
+		if _, err := ctx.GetEntry(record.Key); err == nil {
+			result.Skipped++
+			return false, nil
+		}
+	}
+
+	entry.Expiration = time.Now().Add(time.Duration(record.RemainingMs) * time.Millisecond)
+	if err := ctx.SetEntry(record.Key, entry); err != nil {
+		return false, err
+	}
+	if err := seedFencingToken(ctx, record.Key); err != nil {
+		return false, err
+	}
+	// Locks are moved between cluster members through imports
+	if entry.Type == model.EntryTypeLock {
+		if err := ctx.SeedFencingTokenFromLock(entry); err != nil {
+			return false, err
+		}
+	}
+
+	result.Imported++
+	return true, nil
+}
+
+// writeBinary writes binaryMagic followed by length prefixed records:
+// key length, key, remaining ms, data length, data
+func writeBinary(ctx *model.CacheAppContext, w io.Writer, prefix string) (int, error) {
+	writer := bufio.NewWriter(w)
+	if _, err := writer.Write(binaryMagic); err != nil {
+		return 0, err
+	}
+
+	entries := 0
+	buffer := make([]byte, binary.MaxVarintLen64)
+	match := func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+
+	err := eachLiveEntry(ctx, match, func(key string, data []byte, entry model.CacheEntry) error {
+		writer.Write(buffer[:binary.PutUvarint(buffer, uint64(len(key)))])
+		writer.WriteString(key)
+		writer.Write(buffer[:binary.PutVarint(buffer, time.Until(entry.Expiration).Milliseconds())])
+		writer.Write(buffer[:binary.PutUvarint(buffer, uint64(len(data)))])
+		_, err := writer.Write(data)
+
+		entries++
+		return err
+	})
+	if err != nil {
+		return entries, err
+	}
+
+	return entries, writer.Flush()
+}
+
+func eachBinaryRecord(r io.Reader, fn func(record Record) error) error {
+	reader := bufio.NewReader(r)
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, binaryMagic) {
+		return errors.New("unsupported binary dump format")
+	}
+
+	for {
+		keyLength, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if keyLength > maxBinaryKeyLength {
+			return errors.New("corrupted binary dump, key is too long")
+		}
+
+		key := make([]byte, keyLength)
+		if _, err := io.ReadFull(reader, key); err != nil {
+			return err
+		}
+
+		remainingMs, err := binary.ReadVarint(reader)
+		if err != nil {
+			return err
+		}
+
+		dataLength, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+
+		if dataLength > maxBinaryDataLength {
+			return errors.New("corrupted binary dump, value is too long")
+		}
+
+		data := make([]byte, dataLength)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return err
+		}
+
+		record := Record{Op: model.MutationSet, Key: string(key), Data: data, RemainingMs: remainingMs}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
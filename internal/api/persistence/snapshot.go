@@ -0,0 +1,353 @@
+package persistence
+
+import (
+	"bufio"
+	"cache_engine_httpserver/internal/api/model"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// snapshotHeader is the first line of every snapshot file
+type snapshotHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const snapshotVersion = 1
+
+// Snapshotter writes gzip compressed point-in-time copies of the cache.
+// The cache is scanned with the BigCache iterator, so requests keep being
+// served while a snapshot is written.
+type Snapshotter struct {
+	ctx  *model.CacheAppContext
+	path string
+
+	mu     sync.Mutex
+	status model.SnapshotStatus
+
+	stop chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewSnapshotter(ctx *model.CacheAppContext, path string) *Snapshotter {
+	return &Snapshotter{
+		ctx:    ctx,
+		path:   path,
+		status: model.SnapshotStatus{Path: path},
+	}
+}
+
+// Save writes a snapshot synchronously
+func (snapshotter *Snapshotter) Save() error {
+	snapshotter.mu.Lock()
+	if snapshotter.status.InProgress {
+		snapshotter.mu.Unlock()
+		return fmt.Errorf("snapshot already in progress")
+	}
+	snapshotter.status.InProgress = true
+	snapshotter.status.LastStartedAt = time.Now()
+	snapshotter.mu.Unlock()
+
+	return snapshotter.save()
+}
+
+func (snapshotter *Snapshotter) Trigger() bool {
+	snapshotter.mu.Lock()
+	defer snapshotter.mu.Unlock()
+
+	if snapshotter.status.InProgress {
+		return false
+	}
+	snapshotter.status.InProgress = true
+	snapshotter.status.LastStartedAt = time.Now()
+
+	snapshotter.wg.Add(1)
+	go func() {
+		defer snapshotter.wg.Done()
+		if err := snapshotter.save(); err != nil {
+			log.Printf("Error when writing snapshot : %v", err.Error())
+		}
+	}()
+
+	return true
+}
+
+func (snapshotter *Snapshotter) Status() model.SnapshotStatus {
+	snapshotter.mu.Lock()
+	defer snapshotter.mu.Unlock()
+
+	return snapshotter.status
+}
+
+// Start writes a snapshot every interval until Close is called
+func (snapshotter *Snapshotter) Start(interval time.Duration) {
+	snapshotter.stop = make(chan struct{})
+	snapshotter.done = make(chan struct{})
+
+	go func() {
+		defer close(snapshotter.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-snapshotter.stop:
+				return
+			case <-ticker.C:
+				snapshotter.Trigger()
+			}
+		}
+	}()
+}
+
+// Close stops periodic snapshots and waits for a running one to finish
+func (snapshotter *Snapshotter) Close() {
+	if snapshotter.stop != nil {
+		close(snapshotter.stop)
+		<-snapshotter.done
+	}
+
+	snapshotter.wg.Wait()
+}
+
+func (snapshotter *Snapshotter) save() error {
+	started := time.Now()
+	entries, err := writeSnapshot(snapshotter.ctx, snapshotter.path)
+
+	snapshotter.mu.Lock()
+	defer snapshotter.mu.Unlock()
+
+	snapshotter.status.InProgress = false
+	snapshotter.status.LastDurationMs = time.Since(started).Milliseconds()
+	if err != nil {
+		snapshotter.status.LastError = err.Error()
+		return err
+	}
+
+	snapshotter.status.LastError = ""
+	snapshotter.status.LastSuccessAt = time.Now()
+	snapshotter.status.LastEntries = entries
+
+	return nil
+}
+
+// writeSnapshot writes to a temporary file that replaces path once complete,
+// so a crash never leaves a half written snapshot behind
+func writeSnapshot(ctx *model.CacheAppContext, path string) (int, error) {
+	tempPath := path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tempPath)
+	defer file.Close()
+
+	compressor := gzip.NewWriter(file)
+	entries, err := WriteEntries(ctx, compressor, "")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return 0, err
+	}
+
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	return entries, os.Rename(tempPath, path)
+}
+
+// WriteEntries writes a header line and one set record per live entry whose
+// key starts with prefix, expired entries are skipped
+func WriteEntries(ctx *model.CacheAppContext, w io.Writer, prefix string) (int, error) {
+	matchKey := func(key string) bool {
+		return strings.HasPrefix(key, prefix)
+	}
+
+	return writeEntries(ctx, w, matchKey, func(string, []byte, model.CacheEntry) bool { return true })
+}
+
+// WriteEntriesMatching is WriteEntries for the live entries match accepts
+func WriteEntriesMatching(ctx *model.CacheAppContext, w io.Writer, match func(key string, data []byte, entry model.CacheEntry) bool) (int, error) {
+	return writeEntries(ctx, w, matchAll, match)
+}
+
+// writeEntries filters on the key with matchKey before decoding the entry for matchEntry
+func writeEntries(ctx *model.CacheAppContext, w io.Writer, matchKey func(key string) bool, matchEntry func(key string, data []byte, entry model.CacheEntry) bool) (int, error) {
+	writer := bufio.NewWriter(w)
+	header, err := json.Marshal(snapshotHeader{Version: snapshotVersion, CreatedAt: time.Now()})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := writer.Write(append(header, '\n')); err != nil {
+		return 0, err
+	}
+
+	entries := 0
+	err = eachLiveEntry(ctx, matchKey, func(key string, data []byte, entry model.CacheEntry) error {
+		if !matchEntry(key, data, entry) {
+			return nil
+		}
+
+		line, err := encodeRecord(Record{Op: model.MutationSet, Key: key, Data: data, RemainingMs: time.Until(entry.Expiration).Milliseconds()})
+		if err != nil {
+			return err
+		}
+
+		entries++
+		_, err = writer.Write(line)
+		return err
+	})
+	if err != nil {
+		return entries, err
+	}
+
+	return entries, writer.Flush()
+}
+
+// LoadSnapshot restores the cache from the snapshot at path.
+// A missing file is not an error, the cache simply starts empty.
+func LoadSnapshot(ctx *model.CacheAppContext, path string) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	decompressor, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	defer decompressor.Close()
+
+	return ReadEntries(ctx, decompressor)
+}
+
+// ReadEntries applies records written by WriteEntries and returns
+// how many entries were loaded
+func ReadEntries(ctx *model.CacheAppContext, r io.Reader) (int, error) {
+	loaded := 0
+	err := eachRecord(r, func(record Record) error {
+		if err := ApplyRecord(ctx, record); err != nil {
+			return err
+		}
+
+		loaded++
+		return nil
+	})
+
+	return loaded, err
+}
+
+// eachRecord validates the header line and calls fn for every record
+func eachRecord(r io.Reader, fn func(record Record) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		return scanner.Err()
+	}
+
+	header := snapshotHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot format")
+	}
+
+	for scanner.Scan() {
+		record := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return err
+		}
+
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// eachLiveEntry iterates over BigCache, then over the disk tier for the
+// entries only held there, and calls fn for entries that are not expired
+// and whose key matches, with the decoded entry
+func eachLiveEntry(ctx *model.CacheAppContext, match func(key string) bool, fn func(key string, data []byte, entry model.CacheEntry) error) error {
+	// The last fencing token handed out is written from memory, BigCache
+	// drops its entry at the end of the life window like any other
+	fencingToken, handedOut := ctx.FencingTokenEntry()
+	if handedOut && match(model.FencingTokenKey) {
+		data, err := json.Marshal(fencingToken)
+		if err != nil {
+			return err
+		}
+		if err := fn(model.FencingTokenKey, data, fencingToken); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	visit := func(key string, data []byte) error {
+		if !match(key) || (handedOut && key == model.FencingTokenKey) {
+			return nil
+		}
+
+		entry := model.CacheEntry{}
+		// Hot copies belong to the peer owning them
+		if err := json.Unmarshal(data, &entry); err != nil || entry.Hot || now.After(entry.Expiration) {
+			return nil
+		}
+
+		return fn(key, data, entry)
+	}
+
+	// A key can be in both tiers while its demotion is being written
+	var seen map[string]struct{}
+	if ctx.SecondTier != nil {
+		seen = map[string]struct{}{}
+	}
+
+	iterator := ctx.Cache.Iterator()
+	for iterator.SetNext() {
+		info, err := iterator.Value()
+		if err != nil {
+			continue
+		}
+
+		if seen != nil {
+			seen[info.Key()] = struct{}{}
+		}
+		if err := visit(info.Key(), info.Value()); err != nil {
+			return err
+		}
+	}
+
+	if ctx.SecondTier == nil {
+		return nil
+	}
+
+	return ctx.SecondTier.Each(func(key string, data []byte) error {
+		if _, ok := seen[key]; ok {
+			return nil
+		}
+
+		return visit(key, data)
+	})
+}
@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"bytes"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/tier"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.ndjson.gz")
+
+	ctx := newAppContext()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, ctx.SetEntry("key-"+strconv.Itoa(i), model.CacheEntry{Value: "value", Expiration: time.Now().Add(time.Minute)}))
+	}
+	assert.NoError(t, ctx.SetEntry("expired", model.CacheEntry{Value: "value", Expiration: time.Now().Add(-time.Second)}))
+	assert.NoError(t, ctx.SetHotCopy("hot", model.CacheEntry{Value: "value", Expiration: time.Now().Add(time.Minute)}))
+
+	snapshotter := NewSnapshotter(ctx, path)
+	assert.NoError(t, snapshotter.Save())
+	assert.Equal(t, 100, snapshotter.Status().LastEntries)
+
+	restored := newAppContext()
+	loaded, err := LoadSnapshot(restored, path)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, loaded)
+
+	entry, err := restored.GetEntry("key-42")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", entry.Value)
+
+	_, err = restored.GetEntry("expired")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+
+	_, err = restored.GetEntry("hot")
+	assert.ErrorIs(t, err, bigcache.ErrEntryNotFound)
+}
+
+func TestSnapshotIncludesSecondTier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.ndjson.gz")
+	store, err := tier.Open(t.TempDir(), 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	ctx := newAppContext()
+	ctx.SecondTier = store
+	assert.NoError(t, ctx.SetEntry("resident", model.CacheEntry{Value: "new", Expiration: time.Now().Add(time.Minute)}))
+
+	// BigCache handed these to the disk tier, one of them is resident again
+	for key, value := range map[string]string{"demoted": "disk", "resident": "old"} {
+		data, _ := json.Marshal(model.CacheEntry{Value: value, Expiration: time.Now().Add(time.Minute)})
+		store.OnRemove(key, data, bigcache.NoSpace)
+	}
+
+	snapshotter := NewSnapshotter(ctx, path)
+	assert.NoError(t, snapshotter.Save())
+	assert.Equal(t, 2, snapshotter.Status().LastEntries)
+
+	restored := newAppContext()
+	_, err = LoadSnapshot(restored, path)
+	assert.NoError(t, err)
+
+	entry, err := restored.GetEntry("demoted")
+	assert.NoError(t, err)
+	assert.Equal(t, "disk", entry.Value)
+
+	entry, err = restored.GetEntry("resident")
+	assert.NoError(t, err)
+	assert.Equal(t, "new", entry.Value)
+}
+
+func TestSnapshotRestoresFencingToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.ndjson.gz")
+
+	ctx := newAppContext()
+	for i := 0; i < 41; i++ {
+		_, err := ctx.NextFencingToken(context.Background())
+		assert.NoError(t, err)
+	}
+	// As BigCache does at the end of the life window, no lock is left either
+	assert.NoError(t, ctx.DeleteEntry(model.FencingTokenKey))
+	assert.NoError(t, NewSnapshotter(ctx, path).Save())
+
+	restored := newAppContext()
+	_, err := LoadSnapshot(restored, path)
+	assert.NoError(t, err)
+	assert.NoError(t, restored.SeedFencingToken())
+	token, err := restored.NextFencingToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), token)
+}
+
+func TestImportRaisesFencingTokenOfLocks(t *testing.T) {
+	ctx := newAppContext()
+	lock := model.CacheEntry{
+		Type:       model.EntryTypeLock,
+		Value:      model.LockValue{Owner: "worker", FencingToken: 41},
+		Expiration: time.Now().Add(time.Minute),
+	}
+	assert.NoError(t, ctx.SetEntry("job", lock))
+
+	dump := new(bytes.Buffer)
+	_, err := Export(ctx, dump, FormatNDJSON, "job")
+	assert.NoError(t, err)
+
+	// As a cluster member receiving the lock from another one
+	imported := newAppContext()
+	result, err := Import(imported, dump, FormatNDJSON, ConflictSkip, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job"}, result.Keys)
+
+	token, err := imported.NextFencingToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), token)
+}
+
+func TestSnapshotTrigger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.ndjson.gz")
+
+	ctx := newAppContext()
+	assert.NoError(t, ctx.SetEntry("key", model.CacheEntry{Value: "value", Expiration: time.Now().Add(time.Minute)}))
+
+	snapshotter := NewSnapshotter(ctx, path)
+	assert.True(t, snapshotter.Trigger())
+	snapshotter.Close()
+
+	status := snapshotter.Status()
+	assert.False(t, status.InProgress)
+	assert.Equal(t, 1, status.LastEntries)
+	assert.Empty(t, status.LastError)
+
+	loaded, err := LoadSnapshot(newAppContext(), filepath.Join(t.TempDir(), "missing.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, loaded)
+}
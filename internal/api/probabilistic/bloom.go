@@ -0,0 +1,202 @@
+package probabilistic
+
+import (
+	"encoding/base64"
+	"errors"
+	"math"
+)
+
+const (
+	// bloomGrowth is how much bigger every new layer is than the previous one
+	bloomGrowth = 2
+	// bloomTightening is the ratio applied to the error rate of every new layer,
+	// which keeps the compound error rate below the requested one
+	bloomTightening = 0.5
+	// MaxBloomLayerSize is the biggest bit array of a single layer in bytes
+	MaxBloomLayerSize = 64 * 1024 * 1024
+	// maxBloomLayers bounds how many times a filter grows
+	maxBloomLayers = 16
+	// bloomEncodingOverhead bounds what the JSON encoding of a filter or
+	// a layer adds to the base64 encoded bit arrays
+	bloomEncodingOverhead = 128
+)
+
+var (
+	ErrInvalidBloomFilter  = errors.New("bloom filter error rate should be between 0 and 1 and capacity should be > 0")
+	ErrBloomFilterTooLarge = errors.New("bloom filter capacity and error rate need more memory than allowed")
+	ErrBloomFilterFull     = errors.New("bloom filter cannot grow anymore")
+	ErrBloomFilterMaxSize  = errors.New("bloom filter cannot grow past its max size")
+)
+
+// BloomLayer is a fixed size bloom filter
+type BloomLayer struct {
+	Capacity int    `json:"capacity"`
+	Count    int    `json:"count"`
+	Hashes   int    `json:"hashes"`
+	Bits     []byte `json:"bits"`
+}
+
+// ScalableBloomFilter adds a new, bigger layer whenever the current one
+// is full, so it can grow past its initial capacity without exceeding ErrorRate
+type ScalableBloomFilter struct {
+	ErrorRate float64      `json:"error_rate"`
+	Capacity  int          `json:"capacity"`
+	Layers    []BloomLayer `json:"layers"`
+	// MaxSize bounds EncodedSize when the filter grows, 0 means unbounded.
+	// It is not encoded, the store it is kept in decides it.
+	MaxSize int `json:"-"`
+}
+
+func NewScalableBloomFilter(errorRate float64, capacity int) (*ScalableBloomFilter, error) {
+	if errorRate <= 0 || errorRate >= 1 || capacity < 1 {
+		return nil, ErrInvalidBloomFilter
+	}
+
+	filter := &ScalableBloomFilter{
+		ErrorRate: errorRate,
+		Capacity:  capacity,
+	}
+	if err := filter.addLayer(); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// BloomFilterSize returns the size in bytes of the first layer of a filter
+// created with errorRate and capacity, without allocating it
+func BloomFilterSize(errorRate float64, capacity int) float64 {
+	return math.Ceil(layerBits(errorRate*(1-bloomTightening), float64(capacity)) / 8)
+}
+
+// Add inserts item and reports whether it was not present before,
+// ErrBloomFilterFull is returned when the filter cannot take a new layer
+// and ErrBloomFilterMaxSize when the new layer would not fit in MaxSize
+func (filter *ScalableBloomFilter) Add(item string) (bool, error) {
+	if filter.Exists(item) {
+		return false, nil
+	}
+
+	layer := &filter.Layers[len(filter.Layers)-1]
+	if layer.Count >= layer.Capacity {
+		if err := filter.addLayer(); err != nil {
+			return false, err
+		}
+		layer = &filter.Layers[len(filter.Layers)-1]
+	}
+
+	layer.add(item)
+	return true, nil
+}
+
+// Exists reports whether item may have been added, false positives
+// happen with probability ErrorRate, false negatives never happen
+func (filter *ScalableBloomFilter) Exists(item string) bool {
+	for i := range filter.Layers {
+		if filter.Layers[i].exists(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Count is the number of items added
+func (filter *ScalableBloomFilter) Count() int {
+	count := 0
+	for _, layer := range filter.Layers {
+		count += layer.Count
+	}
+
+	return count
+}
+
+// EncodedSize is an upper bound of the size of the filter encoded as JSON
+func (filter *ScalableBloomFilter) EncodedSize() int {
+	size := bloomEncodingOverhead
+	for _, layer := range filter.Layers {
+		size += layerEncodedSize(len(layer.Bits))
+	}
+
+	return size
+}
+
+func layerEncodedSize(bytes int) int {
+	return bloomEncodingOverhead + base64.StdEncoding.EncodedLen(bytes)
+}
+
+// addLayer sizes are computed as floats first so huge capacities are
+// rejected instead of overflowing or exhausting the memory
+func (filter *ScalableBloomFilter) addLayer() error {
+	index := len(filter.Layers)
+	if index >= maxBloomLayers {
+		return ErrBloomFilterFull
+	}
+
+	capacity := float64(filter.Capacity) * math.Pow(bloomGrowth, float64(index))
+	errorRate := filter.ErrorRate * (1 - bloomTightening) * math.Pow(bloomTightening, float64(index))
+
+	bits := math.Ceil(layerBits(errorRate, capacity))
+	if bits/8 > MaxBloomLayerSize {
+		if index == 0 {
+			return ErrBloomFilterTooLarge
+		}
+		return ErrBloomFilterFull
+	}
+
+	bitCount := int(bits)
+	if filter.MaxSize > 0 && filter.EncodedSize()+layerEncodedSize((bitCount+7)/8) > filter.MaxSize {
+		if index == 0 {
+			return ErrBloomFilterTooLarge
+		}
+		return ErrBloomFilterMaxSize
+	}
+
+	hashes := int(math.Ceil(bits / capacity * math.Ln2))
+
+	filter.Layers = append(filter.Layers, BloomLayer{
+		Capacity: int(capacity),
+		Hashes:   hashes,
+		Bits:     make([]byte, (bitCount+7)/8),
+	})
+
+	return nil
+}
+
+// layerBits is the optimal number of bits to hold capacity items at errorRate
+func layerBits(errorRate float64, capacity float64) float64 {
+	return -capacity * math.Log(errorRate) / (math.Ln2 * math.Ln2)
+}
+
+func (layer *BloomLayer) add(item string) {
+	for _, position := range layer.positions(item) {
+		layer.Bits[position/8] |= 1 << (position % 8)
+	}
+	layer.Count++
+}
+
+func (layer *BloomLayer) exists(item string) bool {
+	for _, position := range layer.positions(item) {
+		if layer.Bits[position/8]&(1<<(position%8)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// positions uses double hashing (Kirsch-Mitzenmacher) to derive
+// all bit positions from a single 64 bit hash
+func (layer *BloomLayer) positions(item string) []uint64 {
+	hash := hash64(item)
+	low := hash & 0xffffffff
+	high := hash >> 32
+	bitCount := uint64(len(layer.Bits) * 8)
+
+	positions := make([]uint64, layer.Hashes)
+	for i := range positions {
+		positions[i] = (low + uint64(i)*high) % bitCount
+	}
+
+	return positions
+}
@@ -0,0 +1,96 @@
+package probabilistic
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// HyperLogLogPrecision is the number of index bits, giving 2^14 registers
+// and a standard error of about 0.81% (same as Redis)
+const HyperLogLogPrecision = 14
+
+const hyperLogLogRegisters = 1 << HyperLogLogPrecision
+
+var ErrInvalidHyperLogLog = errors.New("invalid HyperLogLog registers")
+
+// HyperLogLog estimates the number of distinct elements using one byte per register
+type HyperLogLog struct {
+	Registers []byte
+}
+
+func NewHyperLogLog() *HyperLogLog {
+	return &HyperLogLog{Registers: make([]byte, hyperLogLogRegisters)}
+}
+
+// LoadHyperLogLog wraps registers previously returned by HyperLogLog.Registers
+func LoadHyperLogLog(registers []byte) (*HyperLogLog, error) {
+	if len(registers) != hyperLogLogRegisters {
+		return nil, ErrInvalidHyperLogLog
+	}
+
+	return &HyperLogLog{Registers: registers}, nil
+}
+
+// Add records element and reports whether any register was updated
+func (hll *HyperLogLog) Add(element string) bool {
+	hash := hash64(element)
+	index := hash >> (64 - HyperLogLogPrecision)
+	rank := byte(bits.LeadingZeros64(hash<<HyperLogLogPrecision|1<<(HyperLogLogPrecision-1)) + 1)
+
+	if rank > hll.Registers[index] {
+		hll.Registers[index] = rank
+		return true
+	}
+
+	return false
+}
+
+// Merge folds other into hll by keeping the max of every register
+func (hll *HyperLogLog) Merge(other *HyperLogLog) {
+	for i, rank := range other.Registers {
+		if rank > hll.Registers[i] {
+			hll.Registers[i] = rank
+		}
+	}
+}
+
+// Count returns the estimated cardinality
+func (hll *HyperLogLog) Count() uint64 {
+	m := float64(hyperLogLogRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum := 0.0
+	zeros := 0
+	for _, rank := range hll.Registers {
+		sum += 1 / float64(uint64(1)<<rank)
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+	// Small range correction, linear counting is more accurate here
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
+
+// hash64 is FNV-1a followed by a splitmix64 finalizer, FNV alone
+// does not spread short keys well enough over the high bits
+func hash64(value string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(value))
+
+	hash := hasher.Sum64()
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return hash
+}
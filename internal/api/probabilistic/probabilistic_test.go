@@ -0,0 +1,91 @@
+package probabilistic
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHyperLogLogCount(t *testing.T) {
+	hll := NewHyperLogLog()
+	for i := 0; i < 100000; i++ {
+		hll.Add("visitor-" + strconv.Itoa(i))
+		hll.Add("visitor-" + strconv.Itoa(i))
+	}
+
+	assert.InEpsilon(t, 100000, hll.Count(), 0.02)
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	first := NewHyperLogLog()
+	second := NewHyperLogLog()
+	for i := 0; i < 5000; i++ {
+		first.Add(strconv.Itoa(i))
+		second.Add(strconv.Itoa(i + 2500))
+	}
+
+	first.Merge(second)
+	assert.InEpsilon(t, 7500, first.Count(), 0.02)
+
+	_, err := LoadHyperLogLog([]byte("short"))
+	assert.ErrorIs(t, err, ErrInvalidHyperLogLog)
+}
+
+func TestScalableBloomFilter(t *testing.T) {
+	filter, err := NewScalableBloomFilter(0.01, 1000)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10000; i++ {
+		_, err := filter.Add("seen-" + strconv.Itoa(i))
+		assert.NoError(t, err)
+	}
+	assert.Greater(t, len(filter.Layers), 1)
+
+	for i := 0; i < 10000; i++ {
+		assert.True(t, filter.Exists("seen-"+strconv.Itoa(i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.Exists("unseen-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, float64(falsePositives)/10000, 0.01)
+
+	_, err = NewScalableBloomFilter(1.5, 10)
+	assert.ErrorIs(t, err, ErrInvalidBloomFilter)
+
+	_, err = NewScalableBloomFilter(0.01, math.MaxInt)
+	assert.ErrorIs(t, err, ErrBloomFilterTooLarge)
+}
+
+func TestScalableBloomFilterStopsGrowing(t *testing.T) {
+	filter, err := NewScalableBloomFilter(0.01, 1)
+	assert.NoError(t, err)
+
+	for i := 0; err == nil; i++ {
+		_, err = filter.Add("seen-" + strconv.Itoa(i))
+	}
+	assert.ErrorIs(t, err, ErrBloomFilterFull)
+	assert.Len(t, filter.Layers, maxBloomLayers)
+}
+
+func TestScalableBloomFilterStaysUnderMaxSize(t *testing.T) {
+	filter, err := NewScalableBloomFilter(0.01, 100)
+	assert.NoError(t, err)
+	filter.MaxSize = 4096
+
+	for i := 0; err == nil; i++ {
+		_, err = filter.Add("seen-" + strconv.Itoa(i))
+	}
+	assert.ErrorIs(t, err, ErrBloomFilterMaxSize)
+
+	data, err := json.Marshal(filter)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(data), filter.MaxSize)
+	assert.LessOrEqual(t, filter.EncodedSize(), filter.MaxSize)
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	AlgorithmTokenBucket string = "token_bucket"
+	AlgorithmFixedWindow string = "fixed_window"
+	AlgorithmSlidingLog  string = "sliding_window_log"
+)
+
+// Rule allows Limit requests per Window.
+// For token_bucket Limit is the bucket capacity, refilled evenly over Window.
+type Rule struct {
+	Name            string `json:"name"`
+	Algorithm       string `json:"algorithm"`
+	Limit           int    `json:"limit"`
+	WindowInSeconds int    `json:"window_in_seconds"`
+}
+
+// State is the per client counter, only the fields of the rule algorithm are used
+type State struct {
+	Tokens      float64 `json:"tokens,omitempty"`
+	UpdatedAt   int64   `json:"updated_at,omitempty"`
+	WindowStart int64   `json:"window_start,omitempty"`
+	Count       int     `json:"count,omitempty"`
+	Timestamps  []int64 `json:"timestamps,omitempty"`
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration
+}
+
+func (rule Rule) Window() time.Duration {
+	return time.Duration(rule.WindowInSeconds) * time.Second
+}
+
+// Validate returns a validation message per invalid field
+func (rule Rule) Validate() map[string]any {
+	validationErr := make(map[string]any)
+	if rule.Name == "" {
+		validationErr["name"] = "Rule `name` cannot be empty"
+	}
+
+	switch rule.Algorithm {
+	case AlgorithmTokenBucket, AlgorithmFixedWindow, AlgorithmSlidingLog:
+	default:
+		validationErr["algorithm"] = "Rule `algorithm` should be one of token_bucket, fixed_window, sliding_window_log"
+	}
+
+	if rule.Limit < 1 {
+		validationErr["limit"] = "Value `limit` should be >= 1"
+	}
+
+	if rule.WindowInSeconds < 1 {
+		validationErr["window_in_seconds"] = "Value `window_in_seconds` should be >= 1"
+	}
+
+	if len(validationErr) < 1 {
+		return nil
+	}
+
+	return validationErr
+}
+
+// Take consumes cost units from state at time now
+func (rule Rule) Take(state *State, now time.Time, cost int) Result {
+	switch rule.Algorithm {
+	case AlgorithmTokenBucket:
+		return rule.takeTokenBucket(state, now, cost)
+	case AlgorithmFixedWindow:
+		return rule.takeFixedWindow(state, now, cost)
+	default:
+		return rule.takeSlidingLog(state, now, cost)
+	}
+}
+
+func (rule Rule) takeTokenBucket(state *State, now time.Time, cost int) Result {
+	capacity := float64(rule.Limit)
+	refillPerNano := capacity / float64(rule.Window())
+
+	if state.UpdatedAt == 0 {
+		state.Tokens = capacity
+	} else {
+		elapsed := float64(now.UnixNano() - state.UpdatedAt)
+		state.Tokens = math.Min(capacity, state.Tokens+elapsed*refillPerNano)
+	}
+	state.UpdatedAt = now.UnixNano()
+
+	allowed := state.Tokens >= float64(cost)
+	if allowed {
+		state.Tokens -= float64(cost)
+	}
+
+	return Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  int(state.Tokens),
+		ResetAfter: time.Duration((capacity - state.Tokens) / refillPerNano),
+	}
+}
+
+func (rule Rule) takeFixedWindow(state *State, now time.Time, cost int) Result {
+	windowStart := now.Truncate(rule.Window()).UnixNano()
+	if state.WindowStart != windowStart {
+		state.WindowStart = windowStart
+		state.Count = 0
+	}
+
+	allowed := state.Count+cost <= rule.Limit
+	if allowed {
+		state.Count += cost
+	}
+
+	return Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  rule.Limit - state.Count,
+		ResetAfter: time.Unix(0, windowStart).Add(rule.Window()).Sub(now),
+	}
+}
+
+func (rule Rule) takeSlidingLog(state *State, now time.Time, cost int) Result {
+	windowStart := now.Add(-rule.Window()).UnixNano()
+	timestamps := state.Timestamps[:0]
+	for _, timestamp := range state.Timestamps {
+		if timestamp > windowStart {
+			timestamps = append(timestamps, timestamp)
+		}
+	}
+
+	allowed := len(timestamps)+cost <= rule.Limit
+	if allowed {
+		for i := 0; i < cost; i++ {
+			timestamps = append(timestamps, now.UnixNano())
+		}
+	}
+	state.Timestamps = timestamps
+
+	resetAfter := time.Duration(0)
+	if len(timestamps) > 0 {
+		resetAfter = time.Unix(0, timestamps[0]).Add(rule.Window()).Sub(now)
+	}
+
+	return Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  rule.Limit - len(timestamps),
+		ResetAfter: resetAfter,
+	}
+}
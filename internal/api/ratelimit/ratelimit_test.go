@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	rule := Rule{Name: "api", Algorithm: AlgorithmTokenBucket, Limit: 10, WindowInSeconds: 10}
+	state := &State{}
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, rule.Take(state, now, 1).Allowed)
+	}
+	assert.False(t, rule.Take(state, now, 1).Allowed)
+
+	// One token is refilled every second
+	result := rule.Take(state, now.Add(time.Second), 1)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestFixedWindow(t *testing.T) {
+	rule := Rule{Name: "login", Algorithm: AlgorithmFixedWindow, Limit: 3, WindowInSeconds: 60}
+	state := &State{}
+	now := time.Unix(6000, 0)
+
+	assert.True(t, rule.Take(state, now, 2).Allowed)
+	result := rule.Take(state, now.Add(10*time.Second), 2)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 50*time.Second, result.ResetAfter)
+
+	assert.True(t, rule.Take(state, now.Add(60*time.Second), 3).Allowed)
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	rule := Rule{Name: "search", Algorithm: AlgorithmSlidingLog, Limit: 2, WindowInSeconds: 10}
+	state := &State{}
+	now := time.Unix(1000, 0)
+
+	assert.True(t, rule.Take(state, now, 1).Allowed)
+	assert.True(t, rule.Take(state, now.Add(5*time.Second), 1).Allowed)
+	assert.False(t, rule.Take(state, now.Add(9*time.Second), 1).Allowed)
+
+	// The first request left the window
+	assert.True(t, rule.Take(state, now.Add(11*time.Second), 1).Allowed)
+}
+
+func TestRuleValidate(t *testing.T) {
+	assert.Nil(t, Rule{Name: "ok", Algorithm: AlgorithmFixedWindow, Limit: 1, WindowInSeconds: 1}.Validate())
+	assert.Len(t, Rule{Algorithm: "leaky"}.Validate(), 4)
+}
@@ -0,0 +1,92 @@
+package replication
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"sync"
+	"time"
+)
+
+// backlog keeps the latest mutations in memory so replicas that fall
+// behind can catch up without a full resync
+type backlog struct {
+	mu     sync.Mutex
+	offset uint64
+	// records is a ring, the record at offset o is kept at o % len(records)
+	records []model.ReplicationRecord
+	// count is the number of records kept, the oldest is at offset-count+1
+	count int
+	// appended is closed and replaced on every append to wake up waiters
+	appended chan struct{}
+}
+
+func newBacklog(size int, offset uint64) *backlog {
+	return &backlog{
+		offset:   offset,
+		records:  make([]model.ReplicationRecord, size),
+		appended: make(chan struct{}),
+	}
+}
+
+func (backlog *backlog) append(mutation model.Mutation) {
+	backlog.mu.Lock()
+	defer backlog.mu.Unlock()
+
+	backlog.offset++
+	backlog.records[backlog.offset%uint64(len(backlog.records))] = model.ReplicationRecord{
+		Offset: backlog.offset,
+		Op:     mutation.Op,
+		Key:    mutation.Key,
+		Data:   mutation.Data,
+	}
+	backlog.count = min(backlog.count+1, len(backlog.records))
+
+	close(backlog.appended)
+	backlog.appended = make(chan struct{})
+}
+
+func (backlog *backlog) currentOffset() uint64 {
+	backlog.mu.Lock()
+	defer backlog.mu.Unlock()
+
+	return backlog.offset
+}
+
+// since returns records after offset, ok is false when offset is ahead of the
+// backlog or older than its first record
+func (backlog *backlog) since(offset uint64, limit int, wait time.Duration) ([]model.ReplicationRecord, bool) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		backlog.mu.Lock()
+		if offset > backlog.offset {
+			backlog.mu.Unlock()
+			return nil, false
+		}
+
+		if offset < backlog.offset {
+			first := backlog.offset - uint64(backlog.count) + 1
+			if offset+1 < first {
+				backlog.mu.Unlock()
+				return nil, false
+			}
+
+			last := min(offset+uint64(limit), backlog.offset)
+			records := make([]model.ReplicationRecord, 0, last-offset)
+			for next := offset + 1; next <= last; next++ {
+				records = append(records, backlog.records[next%uint64(len(backlog.records))])
+			}
+			backlog.mu.Unlock()
+			return records, true
+		}
+
+		appended := backlog.appended
+		backlog.mu.Unlock()
+
+		select {
+		case <-appended:
+		case <-timer.C:
+			return []model.ReplicationRecord{}, true
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package replication
+
+import (
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/persistence"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// pollWait is how long the primary holds a psync request without changes
+	pollWait = 5 * time.Second
+	// retryDelay is how long the follower waits after a failed request
+	retryDelay = time.Second
+	batchSize  = 1000
+)
+
+var errFullResync = errors.New("full resync required")
+
+type psyncResponse struct {
+	Status string `json:"status"`
+	Cache  struct {
+		Records []model.ReplicationRecord `json:"records"`
+	} `json:"cache"`
+}
+
+// follower pulls mutations from the primary with long-polling requests
+type follower struct {
+	ctx        *model.CacheAppContext
+	primaryURL string
+	replicaID  string
+	client     *http.Client
+
+	mu            sync.Mutex
+	replicationID string
+	offset        uint64
+	primaryOffset uint64
+	linkUp        bool
+	lastSyncAt    time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newFollower(ctx *model.CacheAppContext, primaryURL string, replicaID string) *follower {
+	return &follower{
+		ctx:        ctx,
+		primaryURL: strings.TrimRight(primaryURL, "/"),
+		replicaID:  replicaID,
+		client:     &http.Client{Timeout: pollWait + 10*time.Second, Transport: ctx.NodeTransport()},
+	}
+}
+
+func (follower *follower) start() {
+	runContext, cancel := context.WithCancel(context.Background())
+	follower.cancel = cancel
+	follower.done = make(chan struct{})
+
+	go func() {
+		defer close(follower.done)
+
+		for runContext.Err() == nil {
+			err := follower.sync(runContext)
+			if err == nil {
+				continue
+			}
+
+			if err == errFullResync {
+				follower.setPosition("", 0)
+				continue
+			}
+
+			if runContext.Err() == nil {
+				log.Printf("Replication link with `%s` failed : %v", follower.primaryURL, err.Error())
+				follower.setLink(false)
+				select {
+				case <-runContext.Done():
+				case <-time.After(retryDelay):
+				}
+			}
+		}
+	}()
+}
+
+func (follower *follower) stop() {
+	follower.cancel()
+	<-follower.done
+}
+
+func (follower *follower) position() (string, uint64) {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	return follower.replicationID, follower.offset
+}
+
+func (follower *follower) link() (bool, time.Time) {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	return follower.linkUp, follower.lastSyncAt
+}
+
+// lag returns how many records the primary had that were not applied yet
+// when it last answered
+func (follower *follower) lag() uint64 {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	if follower.primaryOffset > follower.offset {
+		return follower.primaryOffset - follower.offset
+	}
+	return 0
+}
+
+func (follower *follower) setPrimaryOffset(offset uint64) {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	follower.primaryOffset = offset
+}
+
+func (follower *follower) setPosition(replicationID string, offset uint64) {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	follower.replicationID = replicationID
+	follower.offset = offset
+}
+
+func (follower *follower) setLink(up bool) {
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+
+	follower.linkUp = up
+	if up {
+		follower.lastSyncAt = time.Now()
+	}
+}
+
+// sync runs a single full or partial resync step
+func (follower *follower) sync(runContext context.Context) error {
+	replicationID, offset := follower.position()
+	if replicationID == "" {
+		return follower.fullSync(runContext)
+	}
+
+	query := url.Values{}
+	query.Set("replication_id", replicationID)
+	query.Set("offset", strconv.FormatUint(offset, 10))
+	query.Set("replica_id", follower.replicaID)
+	query.Set("wait_ms", strconv.FormatInt(pollWait.Milliseconds(), 10))
+	query.Set("limit", strconv.Itoa(batchSize))
+
+	resp, err := follower.get(runContext, "/replication/psync?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	psync := psyncResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&psync); err != nil {
+		return err
+	}
+
+	if psync.Status == "FULLRESYNC" {
+		return errFullResync
+	}
+
+	if psync.Status != "OK" {
+		return fmt.Errorf("unexpected psync status `%s`", psync.Status)
+	}
+
+	// Older primaries do not send their offset, the replica is then never lagging
+	if primaryOffset, err := strconv.ParseUint(resp.Header.Get("X-Replication-Offset"), 10, 64); err == nil {
+		follower.setPrimaryOffset(primaryOffset)
+	}
+
+	for _, record := range psync.Cache.Records {
+		err := persistence.ApplyRecord(follower.ctx, persistence.Record{Op: record.Op, Key: record.Key, Data: record.Data})
+		if err != nil {
+			return err
+		}
+		follower.setPosition(replicationID, record.Offset)
+	}
+	follower.setLink(true)
+
+	return nil
+}
+
+// fullSync replaces the local cache with a snapshot of the primary
+func (follower *follower) fullSync(runContext context.Context) error {
+	resp, err := follower.get(runContext, "/replication/snapshot")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	replicationID := resp.Header.Get("X-Replication-Id")
+	offset, err := strconv.ParseUint(resp.Header.Get("X-Replication-Offset"), 10, 64)
+	if err != nil || replicationID == "" {
+		return errors.New("primary did not send its replication position")
+	}
+
+	if err := follower.ctx.Cache.Reset(); err != nil {
+		return err
+	}
+	if follower.ctx.SecondTier != nil {
+		if err := follower.ctx.SecondTier.Reset(); err != nil {
+			return err
+		}
+	}
+	if follower.ctx.Eviction != nil {
+		follower.ctx.Eviction.Reset()
+	}
+
+	loaded, err := persistence.ReadEntries(follower.ctx, resp.Body)
+	if err != nil {
+		return err
+	}
+
+	follower.setPosition(replicationID, offset)
+	follower.setPrimaryOffset(offset)
+	follower.setLink(true)
+	log.Printf("Full resync with `%s` loaded %d entries at offset %d", follower.primaryURL, loaded, offset)
+
+	return nil
+}
+
+func (follower *follower) get(runContext context.Context, path string) (*http.Response, error) {
+	target := follower.primaryURL + "/" + config.BASE_URL_NAME + path
+	req, err := http.NewRequestWithContext(runContext, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := follower.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("primary answered %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,174 @@
+package replication
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Manager implements model.Replication. As a primary it records every
+// mutation in a backlog served to replicas, as a replica it follows a
+// primary and applies its mutations to the local cache.
+type Manager struct {
+	ctx         *model.CacheAppContext
+	backlogSize int
+
+	mu            sync.Mutex
+	role          string
+	replicationID string
+	backlog       *backlog
+	replicas      map[string]model.ReplicaInfo
+	follower      *follower
+}
+
+// NewManager creates a primary, call ReplicaOf to follow another node.
+// backlogSize is the number of mutations kept for partial resyncs.
+func NewManager(ctx *model.CacheAppContext, backlogSize int) *Manager {
+	return &Manager{
+		ctx:           ctx,
+		backlogSize:   backlogSize,
+		role:          model.RolePrimary,
+		replicationID: newReplicationID(),
+		backlog:       newBacklog(backlogSize, 0),
+		replicas:      make(map[string]model.ReplicaInfo),
+	}
+}
+
+// OnMutation records local mutations while acting as a primary
+func (manager *Manager) OnMutation(mutation model.Mutation) {
+	manager.mu.Lock()
+	role := manager.role
+	backlog := manager.backlog
+	manager.mu.Unlock()
+
+	if role == model.RolePrimary {
+		backlog.append(mutation)
+	}
+}
+
+func (manager *Manager) Role() string {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return manager.role
+}
+
+func (manager *Manager) Position() (string, uint64) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.follower != nil {
+		return manager.follower.position()
+	}
+
+	return manager.replicationID, manager.backlog.currentOffset()
+}
+
+func (manager *Manager) Info() model.ReplicationInfo {
+	replicationID, offset := manager.Position()
+
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	info := model.ReplicationInfo{
+		Role:          manager.role,
+		ReplicationID: replicationID,
+		Offset:        offset,
+		Replicas:      []model.ReplicaInfo{},
+	}
+
+	if manager.follower != nil {
+		info.PrimaryURL = manager.follower.primaryURL
+		info.LinkUp, info.LastSyncAt = manager.follower.link()
+		info.Lag = manager.follower.lag()
+	}
+
+	for _, replica := range manager.replicas {
+		if offset > replica.Offset {
+			replica.Lag = offset - replica.Offset
+		}
+		info.Replicas = append(info.Replicas, replica)
+	}
+	sort.Slice(info.Replicas, func(i, j int) bool {
+		return info.Replicas[i].ID < info.Replicas[j].ID
+	})
+
+	return info
+}
+
+func (manager *Manager) Changes(replicationID string, offset uint64, replicaID string, limit int, wait time.Duration) ([]model.ReplicationRecord, bool) {
+	manager.mu.Lock()
+	if manager.role != model.RolePrimary || replicationID != manager.replicationID {
+		manager.mu.Unlock()
+		return nil, false
+	}
+
+	if replicaID != "" {
+		manager.replicas[replicaID] = model.ReplicaInfo{
+			ID:       replicaID,
+			Offset:   offset,
+			LastSeen: time.Now(),
+		}
+	}
+	backlog := manager.backlog
+	manager.mu.Unlock()
+
+	return backlog.since(offset, limit, wait)
+}
+
+// ReplicaOf starts following the primary at primaryURL, the local cache is
+// replaced by the primary content on the first sync
+func (manager *Manager) ReplicaOf(primaryURL string, replicaID string) {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.follower != nil {
+		manager.follower.stop()
+	}
+
+	manager.role = model.RoleReplica
+	manager.replicas = make(map[string]model.ReplicaInfo)
+	manager.follower = newFollower(manager.ctx, primaryURL, replicaID)
+	manager.follower.start()
+}
+
+// Promote stops following the primary. A new replication ID is generated so
+// other replicas of the old primary full resync against this node.
+func (manager *Manager) Promote() error {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.role == model.RolePrimary {
+		return errors.New("node is already a primary")
+	}
+
+	_, offset := manager.follower.position()
+	manager.follower.stop()
+	manager.follower = nil
+
+	manager.role = model.RolePrimary
+	manager.replicationID = newReplicationID()
+	manager.backlog = newBacklog(manager.backlogSize, offset)
+
+	return nil
+}
+
+// Close stops following the primary
+func (manager *Manager) Close() {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	if manager.follower != nil {
+		manager.follower.stop()
+	}
+}
+
+func newReplicationID() string {
+	id := make([]byte, 20)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
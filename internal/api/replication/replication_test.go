@@ -0,0 +1,186 @@
+package replication
+
+import (
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/router"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	ctx     *model.CacheAppContext
+	manager *Manager
+	url     string
+}
+
+// startNode serves the whole API on a loopback port
+func startNode(t *testing.T) *node {
+	cache, _ := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	ctx := &model.CacheAppContext{
+		Cache:             cache,
+		DefaultExpiration: time.Minute,
+	}
+
+	manager := NewManager(ctx, 100)
+	ctx.AddObserver(manager)
+	ctx.Replication = manager
+
+	app := fiber.New()
+	app.Use(middleware.ReadOnlyReplicaMiddleware(ctx))
+	router.HandleRoute(app, ctx)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go app.Listener(listener, fiber.ListenConfig{DisableStartupMessage: true})
+
+	t.Cleanup(func() {
+		app.ShutdownWithTimeout(time.Second)
+	})
+	t.Cleanup(manager.Close)
+
+	return &node{ctx: ctx, manager: manager, url: "http://" + listener.Addr().String()}
+}
+
+func (node *node) request(t *testing.T, method string, path string, body string) (int, map[string]any) {
+	req, _ := http.NewRequest(method, node.url+"/cache-engine-api"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response
+}
+
+func (node *node) value(t *testing.T, key string) any {
+	_, response := node.request(t, http.MethodGet, "/get?key="+key, "")
+	cache, ok := response["cache"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return cache["value"]
+}
+
+func TestReplication(t *testing.T) {
+	primary := startNode(t)
+	replica := startNode(t)
+
+	// Written before the replica connects, transferred by the full sync
+	primary.request(t, http.MethodPost, "/create", `{"key":"before","value":"1","duration_in_seconds":60}`)
+	replica.request(t, http.MethodPost, "/create", `{"key":"stale","value":"x","duration_in_seconds":60}`)
+
+	replica.manager.ReplicaOf(primary.url, "replica-1")
+	assert.Eventually(t, func() bool { return replica.value(t, "before") == "1" }, 5*time.Second, 20*time.Millisecond)
+	assert.Nil(t, replica.value(t, "stale"))
+
+	// Written after, streamed by partial syncs
+	primary.request(t, http.MethodPost, "/create", `{"key":"after","value":"2","duration_in_seconds":60}`)
+	primary.request(t, http.MethodPost, "/list/rpush", `{"key":"queue","values":["a","b"]}`)
+	primary.request(t, http.MethodDelete, "/delete/before", "")
+	assert.Eventually(t, func() bool {
+		return replica.value(t, "after") == "2" && replica.value(t, "before") == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, response := replica.request(t, http.MethodGet, "/list/lrange?key=queue", "")
+	assert.Equal(t, []any{"a", "b"}, response["cache"].(map[string]any)["values"])
+
+	status, response := replica.request(t, http.MethodPost, "/create", `{"key":"write","value":"3","duration_in_seconds":60}`)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, "Writes are not allowed against a read only replica", response["message"])
+
+	// Admin routes are only allowed when they do not write to the cache
+	status, _ = replica.request(t, http.MethodPost, "/admin/import", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	for _, path := range []string{"/admin/slowlog/reset", "/admin/gossip/join", "/cluster/members", "/ratelimit/check"} {
+		status, _ = replica.request(t, http.MethodPost, path, "{}")
+		assert.NotEqual(t, http.StatusForbidden, status, path)
+	}
+
+	_, primaryOffset := primary.manager.Position()
+	_, replicaOffset := replica.manager.Position()
+	assert.Equal(t, primaryOffset, replicaOffset)
+
+	assert.Eventually(t, func() bool {
+		info := primary.manager.Info()
+		return len(info.Replicas) == 1 && info.Replicas[0].ID == "replica-1" && info.Replicas[0].Offset == primaryOffset
+	}, 10*time.Second, 50*time.Millisecond)
+
+	info := replica.manager.Info()
+	assert.True(t, info.LinkUp)
+	assert.Equal(t, uint64(0), info.Lag)
+}
+
+func TestPromotion(t *testing.T) {
+	primary := startNode(t)
+	replica := startNode(t)
+
+	primary.request(t, http.MethodPost, "/create", `{"key":"key","value":"1","duration_in_seconds":60}`)
+	replica.manager.ReplicaOf(primary.url, "replica-1")
+	assert.Eventually(t, func() bool { return replica.value(t, "key") == "1" }, 5*time.Second, 20*time.Millisecond)
+
+	_, response := replica.request(t, http.MethodPost, "/replication/promote", "")
+	assert.Equal(t, "OK", response["status"])
+	assert.Equal(t, model.RolePrimary, replica.manager.Role())
+
+	status, _ := replica.request(t, http.MethodPost, "/create", `{"key":"key","value":"2","duration_in_seconds":60}`)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "2", replica.value(t, "key"))
+
+	// The old primary can follow the promoted node
+	primary.manager.ReplicaOf(replica.url, "old-primary")
+	assert.Eventually(t, func() bool { return primary.value(t, "key") == "2" }, 5*time.Second, 20*time.Millisecond)
+
+	_, response = primary.request(t, http.MethodPost, "/replication/promote", "")
+	assert.Equal(t, "OK", response["status"])
+	_, response = primary.request(t, http.MethodPost, "/replication/promote", "")
+	assert.Equal(t, "ERROR", response["status"])
+}
+
+func TestBacklog(t *testing.T) {
+	backlog := newBacklog(3, 0)
+	for i := 0; i < 5; i++ {
+		backlog.append(model.Mutation{Op: model.MutationDelete, Key: "key"})
+	}
+
+	records, ok := backlog.since(2, 10, 0)
+	assert.True(t, ok)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []uint64{3, 4, 5}, []uint64{records[0].Offset, records[1].Offset, records[2].Offset})
+
+	records, ok = backlog.since(3, 1, 0)
+	assert.True(t, ok)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint64(4), records[0].Offset)
+
+	_, ok = backlog.since(1, 10, 0)
+	assert.False(t, ok)
+
+	_, ok = backlog.since(6, 10, 0)
+	assert.False(t, ok)
+
+	records, ok = backlog.since(5, 10, 10*time.Millisecond)
+	assert.True(t, ok)
+	assert.Empty(t, records)
+
+	// A promoted replica starts its backlog at its replication offset
+	backlog = newBacklog(3, 7)
+	backlog.append(model.Mutation{Op: model.MutationDelete, Key: "key"})
+	records, ok = backlog.since(7, 10, 0)
+	assert.True(t, ok)
+	assert.Len(t, records, 1)
+	assert.Equal(t, uint64(8), records[0].Offset)
+	_, ok = backlog.since(6, 10, 0)
+	assert.False(t, ok)
+}
@@ -24,4 +24,332 @@ func HandleRoute(app *fiber.App, ctx *model.CacheAppContext) {
 	app.Get(config.BASE_URL_NAME+"/exists/:key", func(c fiber.Ctx) error {
 		return http.IsCacheExists(c, ctx)
 	})
+
+	app.Delete(config.BASE_URL_NAME+"/tag/:tag", func(c fiber.Ctx) error {
+		return http.PurgeTag(c, ctx)
+	})
+
+	app.Delete(config.BASE_URL_NAME+"/flush", func(c fiber.Ctx) error {
+		return http.FlushCache(c, ctx)
+	})
+
+	app.Get(config.BASE_URL_NAME+"/memory/usage", func(c fiber.Ctx) error {
+		return http.MemoryUsage(c, ctx)
+	})
+
+	// Prometheus scrapes /metrics by default
+	app.Get("/metrics", func(c fiber.Ctx) error {
+		return http.GetMetrics(c, ctx)
+	})
+
+	// Probe paths of Kubernetes, answered without auth
+	app.Get("/healthz", func(c fiber.Ctx) error {
+		return http.Liveness(c, ctx)
+	})
+
+	app.Get("/readyz", func(c fiber.Ctx) error {
+		return http.Readiness(c, ctx)
+	})
+
+	handleListRoute(app, ctx)
+	handleSetRoute(app, ctx)
+	handleSortedSetRoute(app, ctx)
+	handleProbabilisticRoute(app, ctx)
+	handleLockRoute(app, ctx)
+	handleRateLimitRoute(app, ctx)
+	handleAdminRoute(app, ctx)
+	handleReplicationRoute(app, ctx)
+	handleClusterRoute(app, ctx)
+	handlePeerRoute(app, ctx)
+	handleInvalidationRoute(app, ctx)
+	handleGossipRoute(app, ctx)
+}
+
+func handleListRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	list := app.Group(config.BASE_URL_NAME + "/list")
+
+	list.Post("/lpush", func(c fiber.Ctx) error {
+		return http.LeftPushList(c, ctx)
+	})
+
+	list.Post("/rpush", func(c fiber.Ctx) error {
+		return http.RightPushList(c, ctx)
+	})
+
+	list.Post("/lpop", func(c fiber.Ctx) error {
+		return http.LeftPopList(c, ctx)
+	})
+
+	list.Post("/rpop", func(c fiber.Ctx) error {
+		return http.RightPopList(c, ctx)
+	})
+
+	list.Post("/blpop", func(c fiber.Ctx) error {
+		return http.BlockingLeftPopList(c, ctx)
+	})
+
+	list.Post("/brpop", func(c fiber.Ctx) error {
+		return http.BlockingRightPopList(c, ctx)
+	})
+
+	list.Get("/lrange", func(c fiber.Ctx) error {
+		return http.RangeList(c, ctx)
+	})
+
+	list.Post("/ltrim", func(c fiber.Ctx) error {
+		return http.TrimList(c, ctx)
+	})
+
+	list.Get("/llen", func(c fiber.Ctx) error {
+		return http.LengthList(c, ctx)
+	})
+}
+
+func handleSetRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	set := app.Group(config.BASE_URL_NAME + "/set")
+
+	set.Post("/sadd", func(c fiber.Ctx) error {
+		return http.AddSetMembers(c, ctx)
+	})
+
+	set.Post("/srem", func(c fiber.Ctx) error {
+		return http.RemoveSetMembers(c, ctx)
+	})
+
+	set.Get("/sismember", func(c fiber.Ctx) error {
+		return http.IsSetMember(c, ctx)
+	})
+
+	set.Get("/smembers", func(c fiber.Ctx) error {
+		return http.GetSetMembers(c, ctx)
+	})
+
+	set.Get("/sinter", func(c fiber.Ctx) error {
+		return http.IntersectSets(c, ctx)
+	})
+
+	set.Get("/sunion", func(c fiber.Ctx) error {
+		return http.UnionSets(c, ctx)
+	})
+}
+
+func handleSortedSetRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	zset := app.Group(config.BASE_URL_NAME + "/zset")
+
+	zset.Post("/zadd", func(c fiber.Ctx) error {
+		return http.AddSortedSetMembers(c, ctx)
+	})
+
+	zset.Post("/zincrby", func(c fiber.Ctx) error {
+		return http.IncrementSortedSetMember(c, ctx)
+	})
+
+	zset.Get("/zrange", func(c fiber.Ctx) error {
+		return http.RangeSortedSet(c, ctx)
+	})
+
+	zset.Get("/zrangebyscore", func(c fiber.Ctx) error {
+		return http.RangeSortedSetByScore(c, ctx)
+	})
+
+	zset.Get("/zrank", func(c fiber.Ctx) error {
+		return http.RankSortedSetMember(c, ctx)
+	})
+
+	zset.Post("/zrem", func(c fiber.Ctx) error {
+		return http.RemoveSortedSetMembers(c, ctx)
+	})
+}
+
+func handleProbabilisticRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	hll := app.Group(config.BASE_URL_NAME + "/hll")
+
+	hll.Post("/pfadd", func(c fiber.Ctx) error {
+		return http.AddHyperLogLog(c, ctx)
+	})
+
+	hll.Get("/pfcount", func(c fiber.Ctx) error {
+		return http.CountHyperLogLog(c, ctx)
+	})
+
+	hll.Post("/pfmerge", func(c fiber.Ctx) error {
+		return http.MergeHyperLogLog(c, ctx)
+	})
+
+	bloom := app.Group(config.BASE_URL_NAME + "/bloom")
+
+	bloom.Post("/create", func(c fiber.Ctx) error {
+		return http.CreateBloomFilter(c, ctx)
+	})
+
+	bloom.Post("/add", func(c fiber.Ctx) error {
+		return http.AddBloomFilter(c, ctx)
+	})
+
+	bloom.Get("/exists", func(c fiber.Ctx) error {
+		return http.ExistsBloomFilter(c, ctx)
+	})
+
+	bloom.Post("/mexists", func(c fiber.Ctx) error {
+		return http.MultiExistsBloomFilter(c, ctx)
+	})
+}
+
+func handleLockRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	lock := app.Group(config.BASE_URL_NAME + "/lock")
+
+	lock.Post("/acquire", func(c fiber.Ctx) error {
+		return http.AcquireLock(c, ctx)
+	})
+
+	lock.Post("/renew", func(c fiber.Ctx) error {
+		return http.RenewLock(c, ctx)
+	})
+
+	lock.Post("/release", func(c fiber.Ctx) error {
+		return http.ReleaseLock(c, ctx)
+	})
+
+	lock.Get("/status", func(c fiber.Ctx) error {
+		return http.GetLock(c, ctx)
+	})
+}
+
+func handleRateLimitRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	rateLimit := app.Group(config.BASE_URL_NAME + "/ratelimit")
+
+	rateLimit.Post("/rules", func(c fiber.Ctx) error {
+		return http.SetRateLimitRule(c, ctx)
+	})
+
+	rateLimit.Get("/rules", func(c fiber.Ctx) error {
+		return http.GetRateLimitRules(c, ctx)
+	})
+
+	rateLimit.Delete("/rules/:name", func(c fiber.Ctx) error {
+		return http.DeleteRateLimitRule(c, ctx)
+	})
+
+	rateLimit.Post("/check", func(c fiber.Ctx) error {
+		return http.CheckRateLimit(c, ctx)
+	})
+}
+
+func handleAdminRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	admin := app.Group(config.BASE_URL_NAME + "/admin")
+
+	admin.Post("/snapshot", func(c fiber.Ctx) error {
+		return http.TriggerSnapshot(c, ctx)
+	})
+
+	admin.Get("/snapshot", func(c fiber.Ctx) error {
+		return http.GetSnapshotStatus(c, ctx)
+	})
+
+	admin.Get("/export", func(c fiber.Ctx) error {
+		return http.ExportCache(c, ctx)
+	})
+
+	admin.Post("/import", func(c fiber.Ctx) error {
+		return http.ImportCache(c, ctx)
+	})
+
+	admin.Get("/tier", func(c fiber.Ctx) error {
+		return http.GetTierInfo(c, ctx)
+	})
+
+	admin.Get("/config", func(c fiber.Ctx) error {
+		return http.GetConfig(c, ctx)
+	})
+
+	admin.Post("/config/reload", func(c fiber.Ctx) error {
+		return http.ReloadConfig(c, ctx)
+	})
+
+	admin.Get("/memory", func(c fiber.Ctx) error {
+		return http.GetMemoryInfo(c, ctx)
+	})
+
+	admin.Get("/info", func(c fiber.Ctx) error {
+		return http.GetInfo(c, ctx)
+	})
+
+	admin.Get("/slowlog", func(c fiber.Ctx) error {
+		return http.GetSlowLog(c, ctx)
+	})
+
+	admin.Post("/slowlog/reset", func(c fiber.Ctx) error {
+		return http.ResetSlowLog(c, ctx)
+	})
+}
+
+func handleReplicationRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	replication := app.Group(config.BASE_URL_NAME + "/replication")
+
+	replication.Get("/psync", func(c fiber.Ctx) error {
+		return http.PartialSync(c, ctx)
+	})
+
+	replication.Get("/snapshot", func(c fiber.Ctx) error {
+		return http.FullSync(c, ctx)
+	})
+
+	replication.Get("/info", func(c fiber.Ctx) error {
+		return http.GetReplicationInfo(c, ctx)
+	})
+
+	replication.Post("/promote", func(c fiber.Ctx) error {
+		return http.PromoteReplica(c, ctx)
+	})
+}
+
+func handleClusterRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	cluster := app.Group(config.BASE_URL_NAME + "/cluster")
+
+	cluster.Get("/members", func(c fiber.Ctx) error {
+		return http.GetClusterInfo(c, ctx)
+	})
+
+	cluster.Post("/members", func(c fiber.Ctx) error {
+		return http.SetClusterMembers(c, ctx)
+	})
+
+	cluster.Get("/owner", func(c fiber.Ctx) error {
+		return http.GetKeyOwner(c, ctx)
+	})
+}
+
+// handlePeerRoute registers the internal routes peers fill their misses from
+func handlePeerRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	peer := app.Group(config.BASE_URL_NAME + "/internal/peer")
+
+	peer.Get("/get", func(c fiber.Ctx) error {
+		return http.GetPeerEntry(c, ctx)
+	})
+
+	peer.Get("/info", func(c fiber.Ctx) error {
+		return http.GetPeerInfo(c, ctx)
+	})
+}
+
+func handleInvalidationRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	app.Post(config.BASE_URL_NAME+"/internal/invalidation", func(c fiber.Ctx) error {
+		return http.ReceiveInvalidation(c, ctx)
+	})
+
+	app.Get(config.BASE_URL_NAME+"/admin/invalidation", func(c fiber.Ctx) error {
+		return http.GetInvalidationInfo(c, ctx)
+	})
+}
+
+func handleGossipRoute(app *fiber.App, ctx *model.CacheAppContext) {
+	gossip := app.Group(config.BASE_URL_NAME + "/admin/gossip")
+
+	gossip.Get("/members", func(c fiber.Ctx) error {
+		return http.GetGossipMembers(c, ctx)
+	})
+
+	gossip.Post("/join", func(c fiber.Ctx) error {
+		return http.JoinGossip(c, ctx)
+	})
 }
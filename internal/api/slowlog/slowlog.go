@@ -0,0 +1,130 @@
+package slowlog
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log implements model.SlowLog with a ring buffer of maxLen entries
+type Log struct {
+	threshold atomic.Int64
+
+	mu      sync.Mutex
+	entries []model.SlowLogEntry
+	// next is where the following entry goes, once the buffer is full
+	// it is also the oldest entry
+	next   int
+	nextID uint64
+	stats  map[string]*model.OperationStats
+}
+
+// New creates a log of the latest maxLen requests slower than threshold
+func New(maxLen int, threshold time.Duration) *Log {
+	log := &Log{
+		entries: make([]model.SlowLogEntry, 0, maxLen),
+		stats:   map[string]*model.OperationStats{},
+	}
+	log.SetThreshold(threshold)
+
+	return log
+}
+
+func (log *Log) SetThreshold(threshold time.Duration) {
+	log.threshold.Store(int64(threshold))
+}
+
+func (log *Log) Info() model.SlowLogInfo {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	return model.SlowLogInfo{
+		ThresholdInMicroseconds: time.Duration(log.threshold.Load()).Microseconds(),
+		MaxLen:                  cap(log.entries),
+		Len:                     len(log.entries),
+		Logged:                  log.nextID,
+	}
+}
+
+func (log *Log) Observe(operation string, duration time.Duration) bool {
+	threshold := time.Duration(log.threshold.Load())
+	slow := threshold >= 0 && duration >= threshold
+	microseconds := duration.Microseconds()
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	stats, ok := log.stats[operation]
+	if !ok {
+		stats = &model.OperationStats{}
+		log.stats[operation] = stats
+	}
+	stats.Calls++
+	stats.TotalInMicroseconds += microseconds
+	stats.MaxInMicroseconds = max(stats.MaxInMicroseconds, microseconds)
+	if slow {
+		stats.SlowCalls++
+	}
+
+	return slow
+}
+
+func (log *Log) Add(entry model.SlowLogEntry) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if cap(log.entries) == 0 {
+		return
+	}
+
+	entry.ID = log.nextID
+	entry.Time = time.Now()
+	log.nextID++
+
+	if len(log.entries) < cap(log.entries) {
+		log.entries = append(log.entries, entry)
+	} else {
+		log.entries[log.next] = entry
+	}
+	log.next = (log.next + 1) % cap(log.entries)
+}
+
+func (log *Log) Entries(count int) []model.SlowLogEntry {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if count < 0 || count > len(log.entries) {
+		count = len(log.entries)
+	}
+
+	entries := make([]model.SlowLogEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		index := (log.next - i + len(log.entries)) % len(log.entries)
+		entries = append(entries, log.entries[index])
+	}
+
+	return entries
+}
+
+func (log *Log) Stats() map[string]model.OperationStats {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	stats := make(map[string]model.OperationStats, len(log.stats))
+	for operation, operationStats := range log.stats {
+		copied := *operationStats
+		copied.AverageInMicroseconds = float64(copied.TotalInMicroseconds) / float64(copied.Calls)
+		stats[operation] = copied
+	}
+
+	return stats
+}
+
+func (log *Log) Reset() {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	log.entries = log.entries[:0]
+	log.next = 0
+}
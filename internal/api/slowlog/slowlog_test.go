@@ -0,0 +1,59 @@
+package slowlog
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func operations(entries []model.SlowLogEntry) []string {
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Operation)
+	}
+	return names
+}
+
+func TestLogKeepsLatestEntries(t *testing.T) {
+	log := New(3, time.Millisecond)
+
+	for _, operation := range []string{"a", "b", "c", "d", "e"} {
+		log.Add(model.SlowLogEntry{Operation: operation})
+	}
+
+	assert.Equal(t, []string{"e", "d", "c"}, operations(log.Entries(-1)))
+	assert.Equal(t, []string{"e", "d"}, operations(log.Entries(2)))
+	assert.Equal(t, uint64(4), log.Entries(1)[0].ID)
+	assert.Equal(t, model.SlowLogInfo{ThresholdInMicroseconds: 1000, MaxLen: 3, Len: 3, Logged: 5}, log.Info())
+
+	log.Reset()
+	assert.Empty(t, log.Entries(-1))
+	log.Add(model.SlowLogEntry{Operation: "f"})
+	assert.Equal(t, []string{"f"}, operations(log.Entries(10)))
+	// IDs keep growing across resets like Redis
+	assert.Equal(t, uint64(5), log.Entries(1)[0].ID)
+}
+
+func TestLogObservesThreshold(t *testing.T) {
+	log := New(3, time.Millisecond)
+
+	assert.False(t, log.Observe("GET /get", 500*time.Microsecond))
+	assert.True(t, log.Observe("GET /get", 1500*time.Microsecond))
+	assert.True(t, log.Observe("POST /create", time.Millisecond))
+
+	assert.Equal(t, model.OperationStats{
+		Calls:                 2,
+		TotalInMicroseconds:   2000,
+		AverageInMicroseconds: 1000,
+		MaxInMicroseconds:     1500,
+		SlowCalls:             1,
+	}, log.Stats()["GET /get"])
+	assert.Len(t, log.Stats(), 2)
+
+	log.SetThreshold(0)
+	assert.True(t, log.Observe("GET /get", 0))
+	log.SetThreshold(-1)
+	assert.False(t, log.Observe("GET /get", time.Hour))
+}
@@ -0,0 +1,591 @@
+package tier
+
+import (
+	"bufio"
+	"cache_engine_httpserver/internal/api/model"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+)
+
+const (
+	// recordHeaderSize is crc32 (4 bytes), op (1 byte), expiration (8 bytes),
+	// key length (4 bytes) and value length (4 bytes)
+	recordHeaderSize = 21
+
+	opPut    byte = 1
+	opDelete byte = 2
+
+	// maxKeyLength mirrors the 2 bytes BigCache stores key lengths in, values
+	// are capped so a corrupted length cannot allocate the whole memory
+	maxKeyLength   = 1<<16 - 1
+	maxValueLength = 1 << 30
+
+	logFileName = "l2.log"
+	// minCompactSize avoids rewriting logs that are small anyway
+	minCompactSize = 1 << 20
+)
+
+// location is where the value of a live key is in the log
+type location struct {
+	valueOffset int64
+	valueLength int
+	recordSize  int64
+	expiration  time.Time
+	seq         uint64
+}
+
+// DiskStore implements model.SecondTier with a log-structured file: puts and
+// deletes are appended and an in-memory index points at the latest value of
+// every key. Once the log is mostly dead records it is rewritten, and when
+// the live entries exceed maxBytes the oldest ones are evicted.
+//
+// Entries evicted by BigCache are received by OnRemove while BigCache holds a
+// shard lock, so they are only queued there and written by a goroutine.
+type DiskStore struct {
+	dir      string
+	maxBytes int64
+
+	mu        sync.Mutex
+	file      *os.File
+	fileBytes int64
+	liveBytes int64
+	index     map[string]location
+	seq       uint64
+
+	pendingMu sync.Mutex
+	pending   map[string][]byte
+
+	demoted atomic.Uint64
+	evicted atomic.Uint64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open loads the log in dir, truncating a record left half-written by a crash
+func Open(dir string, maxBytes int64) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &DiskStore{
+		dir:      dir,
+		maxBytes: maxBytes,
+		file:     file,
+		index:    make(map[string]location),
+		pending:  make(map[string][]byte),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := store.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	go store.writeLoop()
+	return store, nil
+}
+
+// OnRemove is the BigCache OnRemoveWithReason callback. Deleted entries are
+// handled by CacheAppContext, entries whose own expiration passed are dropped
+// by the writer.
+func (store *DiskStore) OnRemove(key string, entry []byte, reason bigcache.RemoveReason) {
+	if reason == bigcache.Deleted {
+		return
+	}
+
+	store.Demote(key, entry)
+}
+
+// Demote queues entry to be written by the write loop, Get finds it meanwhile
+func (store *DiskStore) Demote(key string, entry []byte) {
+	store.pendingMu.Lock()
+	store.pending[key] = entry
+	store.pendingMu.Unlock()
+
+	select {
+	case store.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (store *DiskStore) Get(key string) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, err := store.readLocked(key)
+	if err == bigcache.ErrEntryNotFound {
+		store.misses.Add(1)
+	} else if err == nil {
+		store.hits.Add(1)
+	}
+
+	return data, err
+}
+
+// Each calls fn with every live entry, without counting them as hits. The
+// keys are listed first, so fn may run other operations on the store and
+// entries stored while it runs may be missed.
+func (store *DiskStore) Each(fn func(key string, data []byte) error) error {
+	store.mu.Lock()
+	keys := make([]string, 0, len(store.index))
+	for key := range store.index {
+		keys = append(keys, key)
+	}
+	store.pendingMu.Lock()
+	for key := range store.pending {
+		if _, ok := store.index[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	store.pendingMu.Unlock()
+	store.mu.Unlock()
+
+	for _, key := range keys {
+		store.mu.Lock()
+		data, err := store.readLocked(key)
+		store.mu.Unlock()
+
+		if err == bigcache.ErrEntryNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(key, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readLocked returns the live entry of key, pending or on disk
+func (store *DiskStore) readLocked(key string) ([]byte, error) {
+	store.pendingMu.Lock()
+	data, pending := store.pending[key]
+	store.pendingMu.Unlock()
+	if pending && time.Now().Before(expirationOf(data)) {
+		return data, nil
+	}
+
+	loc, ok := store.index[key]
+	if !ok || time.Now().After(loc.expiration) {
+		return nil, bigcache.ErrEntryNotFound
+	}
+
+	data = make([]byte, loc.valueLength)
+	if _, err := store.file.ReadAt(data, loc.valueOffset); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (store *DiskStore) Delete(key string) (bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.pendingMu.Lock()
+	_, found := store.pending[key]
+	delete(store.pending, key)
+	store.pendingMu.Unlock()
+
+	if _, ok := store.index[key]; ok {
+		found = true
+		if err := store.deleteLocked(key); err != nil {
+			return found, err
+		}
+	}
+
+	return found, nil
+}
+
+func (store *DiskStore) Keys(match func(data []byte) bool) ([]string, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	keys := []string{}
+	store.pendingMu.Lock()
+	for key, data := range store.pending {
+		if match(data) {
+			keys = append(keys, key)
+		}
+	}
+	store.pendingMu.Unlock()
+
+	for key, loc := range store.index {
+		data := make([]byte, loc.valueLength)
+		if _, err := store.file.ReadAt(data, loc.valueOffset); err != nil {
+			return nil, err
+		}
+
+		if match(data) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (store *DiskStore) Reset() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.pendingMu.Lock()
+	store.pending = make(map[string][]byte)
+	store.pendingMu.Unlock()
+
+	if err := store.file.Truncate(0); err != nil {
+		return err
+	}
+
+	store.index = make(map[string]location)
+	store.fileBytes = 0
+	store.liveBytes = 0
+	return nil
+}
+
+func (store *DiskStore) Info() model.TierInfo {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.pendingMu.Lock()
+	pending := len(store.pending)
+	store.pendingMu.Unlock()
+
+	return model.TierInfo{
+		Dir:       store.dir,
+		Entries:   len(store.index),
+		LiveBytes: store.liveBytes,
+		FileBytes: store.fileBytes,
+		MaxBytes:  store.maxBytes,
+		Pending:   pending,
+		Demoted:   store.demoted.Load(),
+		Evicted:   store.evicted.Load(),
+		Hits:      store.hits.Load(),
+		Misses:    store.misses.Load(),
+	}
+}
+
+// Close writes the queued entries and closes the log
+func (store *DiskStore) Close() error {
+	close(store.stop)
+	<-store.done
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := store.file.Sync(); err != nil {
+		return err
+	}
+	return store.file.Close()
+}
+
+func (store *DiskStore) writeLoop() {
+	defer close(store.done)
+
+	for {
+		select {
+		case <-store.stop:
+			store.writePending()
+			return
+		case <-store.wake:
+			store.writePending()
+		}
+	}
+}
+
+// writePending moves the queued entries to the log. The batch is written
+// under mu so a Delete cannot land between taking and writing an entry.
+func (store *DiskStore) writePending() {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.pendingMu.Lock()
+	batch := store.pending
+	store.pending = make(map[string][]byte)
+	store.pendingMu.Unlock()
+
+	now := time.Now()
+	for key, data := range batch {
+		// Hot copies belong to the peer owning them, they are not kept
+		entry := metadataOf(data)
+		if entry.Hot || now.After(entry.Expiration) {
+			continue
+		}
+
+		if err := store.putLocked(key, data, entry.Expiration); err != nil {
+			log.Printf("Error when writing `%s` to the disk tier : %v", key, err.Error())
+			continue
+		}
+		store.demoted.Add(1)
+	}
+
+	if err := store.evictLocked(); err != nil {
+		log.Printf("Error when evicting from the disk tier : %v", err.Error())
+	}
+
+	if store.fileBytes > minCompactSize && store.fileBytes > 2*store.liveBytes {
+		if err := store.compactLocked(); err != nil {
+			log.Printf("Error when compacting the disk tier : %v", err.Error())
+		}
+	}
+}
+
+func (store *DiskStore) putLocked(key string, data []byte, expiration time.Time) error {
+	record := encodeRecord(opPut, key, data, expiration)
+	if _, err := store.file.WriteAt(record, store.fileBytes); err != nil {
+		return err
+	}
+
+	if previous, ok := store.index[key]; ok {
+		store.liveBytes -= previous.recordSize
+	}
+
+	store.seq++
+	store.index[key] = location{
+		valueOffset: store.fileBytes + recordHeaderSize + int64(len(key)),
+		valueLength: len(data),
+		recordSize:  int64(len(record)),
+		expiration:  expiration,
+		seq:         store.seq,
+	}
+	store.fileBytes += int64(len(record))
+	store.liveBytes += int64(len(record))
+	return nil
+}
+
+// deleteLocked appends a tombstone so the key is not loaded again on restart
+func (store *DiskStore) deleteLocked(key string) error {
+	record := encodeRecord(opDelete, key, nil, time.Time{})
+	if _, err := store.file.WriteAt(record, store.fileBytes); err != nil {
+		return err
+	}
+
+	store.fileBytes += int64(len(record))
+	store.liveBytes -= store.index[key].recordSize
+	delete(store.index, key)
+	return nil
+}
+
+// evictLocked drops the oldest entries until the live entries are 10% under maxBytes
+func (store *DiskStore) evictLocked() error {
+	if store.maxBytes <= 0 || store.liveBytes <= store.maxBytes {
+		return nil
+	}
+
+	keys := make([]string, 0, len(store.index))
+	for key := range store.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return store.index[keys[i]].seq < store.index[keys[j]].seq
+	})
+
+	target := store.maxBytes * 9 / 10
+	for _, key := range keys {
+		if store.liveBytes <= target {
+			break
+		}
+
+		if err := store.deleteLocked(key); err != nil {
+			return err
+		}
+		store.evicted.Add(1)
+	}
+
+	return nil
+}
+
+// compactLocked rewrites the live entries into a new log and swaps it in
+func (store *DiskStore) compactLocked() error {
+	path := filepath.Join(store.dir, logFileName)
+	compacted, err := os.OpenFile(path+".compact", os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(store.index))
+	for key := range store.index {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return store.index[keys[i]].seq < store.index[keys[j]].seq
+	})
+
+	now := time.Now()
+	index := make(map[string]location, len(keys))
+	offset := int64(0)
+	writer := bufio.NewWriter(compacted)
+	for _, key := range keys {
+		loc := store.index[key]
+		if now.After(loc.expiration) {
+			continue
+		}
+
+		data := make([]byte, loc.valueLength)
+		if _, err := store.file.ReadAt(data, loc.valueOffset); err != nil {
+			compacted.Close()
+			return err
+		}
+
+		record := encodeRecord(opPut, key, data, loc.expiration)
+		if _, err := writer.Write(record); err != nil {
+			compacted.Close()
+			return err
+		}
+
+		loc.valueOffset = offset + recordHeaderSize + int64(len(key))
+		index[key] = loc
+		offset += int64(len(record))
+	}
+
+	if err := writer.Flush(); err != nil {
+		compacted.Close()
+		return err
+	}
+	if err := compacted.Sync(); err != nil {
+		compacted.Close()
+		return err
+	}
+	if err := os.Rename(path+".compact", path); err != nil {
+		compacted.Close()
+		return err
+	}
+
+	store.file.Close()
+	store.file = compacted
+	store.index = index
+	store.fileBytes = offset
+	store.liveBytes = offset
+	return nil
+}
+
+// load rebuilds the index from the log
+func (store *DiskStore) load() error {
+	stat, err := store.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(store.file)
+	header := make([]byte, recordHeaderSize)
+	offset := int64(0)
+
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+
+		op := header[4]
+		expiration := int64(binary.LittleEndian.Uint64(header[5:]))
+		keyLength := int(binary.LittleEndian.Uint32(header[13:]))
+		valueLength := int(binary.LittleEndian.Uint32(header[17:]))
+
+		// Lengths are checked before allocating, the checksum is only known once
+		// the body is read. A record running past the end is a crashed write.
+		if keyLength > maxKeyLength || valueLength > maxValueLength ||
+			offset+int64(recordHeaderSize+keyLength+valueLength) > stat.Size() {
+			break
+		}
+
+		body := make([]byte, keyLength+valueLength)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			break
+		}
+
+		checksum := crc32.NewIEEE()
+		checksum.Write(header[4:])
+		checksum.Write(body)
+		if checksum.Sum32() != binary.LittleEndian.Uint32(header) {
+			break
+		}
+
+		key := string(body[:keyLength])
+		recordSize := int64(recordHeaderSize + len(body))
+		if previous, ok := store.index[key]; ok {
+			store.liveBytes -= previous.recordSize
+			delete(store.index, key)
+		}
+
+		// Hot copies written before they were left out of the tier are dropped
+		if op == opPut && !metadataOf(body[keyLength:]).Hot {
+			store.seq++
+			store.index[key] = location{
+				valueOffset: offset + recordHeaderSize + int64(keyLength),
+				valueLength: valueLength,
+				recordSize:  recordSize,
+				expiration:  time.Unix(0, expiration),
+				seq:         store.seq,
+			}
+			store.liveBytes += recordSize
+		}
+		offset += recordSize
+	}
+
+	// Everything after the last complete record is a crashed write
+	if err := store.file.Truncate(offset); err != nil {
+		return err
+	}
+	store.fileBytes = offset
+
+	return nil
+}
+
+func encodeRecord(op byte, key string, value []byte, expiration time.Time) []byte {
+	record := make([]byte, recordHeaderSize+len(key)+len(value))
+	record[4] = op
+	if !expiration.IsZero() {
+		binary.LittleEndian.PutUint64(record[5:], uint64(expiration.UnixNano()))
+	}
+	binary.LittleEndian.PutUint32(record[13:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(record[17:], uint32(len(value)))
+	copy(record[recordHeaderSize:], key)
+	copy(record[recordHeaderSize+len(key):], value)
+	binary.LittleEndian.PutUint32(record, crc32.ChecksumIEEE(record[4:]))
+
+	return record
+}
+
+// metadata is the part of an encoded model.CacheEntry the store reads
+type metadata struct {
+	Expiration time.Time `json:"expiration"`
+	Hot        bool      `json:"hot"`
+}
+
+// metadataOf decodes the metadata of an encoded model.CacheEntry,
+// entries that cannot be decoded are treated as expired
+func metadataOf(data []byte) metadata {
+	entry := metadata{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return metadata{}
+	}
+
+	return entry
+}
+
+func expirationOf(data []byte) time.Time {
+	return metadataOf(data).Expiration
+}
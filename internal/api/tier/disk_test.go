@@ -0,0 +1,224 @@
+package tier
+
+import (
+	"cache_engine_httpserver/internal/api/model"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodedEntry(value string, ttl time.Duration) []byte {
+	data, _ := json.Marshal(model.CacheEntry{Value: value, Expiration: time.Now().Add(ttl)})
+	return data
+}
+
+func hotEntry(value string, ttl time.Duration) []byte {
+	data, _ := json.Marshal(model.CacheEntry{Value: value, Expiration: time.Now().Add(ttl), Hot: true})
+	return data
+}
+
+func valueOf(t *testing.T, data []byte) any {
+	entry := model.CacheEntry{}
+	assert.NoError(t, json.Unmarshal(data, &entry))
+	return entry.Value
+}
+
+func TestDiskStoreSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, 0)
+	assert.NoError(t, err)
+
+	store.OnRemove("kept", encodedEntry("value", time.Hour), bigcache.NoSpace)
+	store.OnRemove("deleted", encodedEntry("value", time.Hour), bigcache.Expired)
+	store.OnRemove("expired", encodedEntry("value", -time.Second), bigcache.Expired)
+	store.OnRemove("ignored", encodedEntry("value", time.Hour), bigcache.Deleted)
+	store.OnRemove("hot", hotEntry("value", time.Hour), bigcache.NoSpace)
+
+	// Readable before being written
+	data, err := store.Get("kept")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", valueOf(t, data))
+
+	store.writePending()
+	found, err := store.Delete("deleted")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.NoError(t, store.Close())
+
+	store, err = Open(dir, 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	data, err = store.Get("kept")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", valueOf(t, data))
+
+	for _, key := range []string{"deleted", "expired", "ignored", "hot"} {
+		_, err := store.Get(key)
+		assert.Equal(t, bigcache.ErrEntryNotFound, err, key)
+	}
+	assert.Equal(t, 1, store.Info().Entries)
+}
+
+func TestDiskStoreTruncatesPartialRecord(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, 0)
+	assert.NoError(t, err)
+
+	store.OnRemove("key", encodedEntry("value", time.Hour), bigcache.NoSpace)
+	assert.NoError(t, store.Close())
+
+	path := filepath.Join(dir, logFileName)
+	info, _ := os.Stat(path)
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.Write(encodeRecord(opPut, "torn", []byte("value"), time.Now().Add(time.Hour))[:10])
+	file.Close()
+
+	store, err = Open(dir, 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), store.Info().FileBytes)
+}
+
+func TestDiskStoreRejectsCorruptedLengths(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, 0)
+	assert.NoError(t, err)
+
+	store.OnRemove("key", encodedEntry("value", time.Hour), bigcache.NoSpace)
+	assert.NoError(t, store.Close())
+
+	path := filepath.Join(dir, logFileName)
+	info, _ := os.Stat(path)
+
+	// A value length past the end of the file is not allocated
+	record := encodeRecord(opPut, "huge", []byte("value"), time.Now().Add(time.Hour))
+	record[17], record[18], record[19], record[20] = 0xff, 0xff, 0xff, 0x3f
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	file.Write(record)
+	file.Close()
+
+	store, err = Open(dir, 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), store.Info().FileBytes)
+}
+
+func TestDiskStoreDropsLoggedHotCopies(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, logFileName)
+	file, _ := os.Create(path)
+	file.Write(encodeRecord(opPut, "hot", hotEntry("value", time.Hour), time.Now().Add(time.Hour)))
+	file.Write(encodeRecord(opPut, "key", encodedEntry("value", time.Hour), time.Now().Add(time.Hour)))
+	file.Close()
+
+	store, err := Open(dir, 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	_, err = store.Get("hot")
+	assert.Equal(t, bigcache.ErrEntryNotFound, err)
+	_, err = store.Get("key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, store.Info().Entries)
+}
+
+func TestDiskStoreEvictsOldestOverMaxBytes(t *testing.T) {
+	store, err := Open(t.TempDir(), 20*1024)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	value := strings.Repeat("x", 500)
+	for i := 0; i < 100; i++ {
+		store.OnRemove("key-"+strconv.Itoa(i), encodedEntry(value, time.Hour), bigcache.NoSpace)
+		store.writePending()
+	}
+
+	info := store.Info()
+	assert.LessOrEqual(t, info.LiveBytes, int64(20*1024))
+	assert.NotZero(t, info.Evicted)
+
+	_, err = store.Get("key-0")
+	assert.Equal(t, bigcache.ErrEntryNotFound, err)
+	_, err = store.Get("key-99")
+	assert.NoError(t, err)
+}
+
+func TestDiskStoreCompacts(t *testing.T) {
+	store, err := Open(t.TempDir(), 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	value := strings.Repeat("x", 1024)
+	for i := 0; i < 3000; i++ {
+		store.OnRemove("key-"+strconv.Itoa(i%10), encodedEntry(value+strconv.Itoa(i), time.Hour), bigcache.NoSpace)
+		store.writePending()
+	}
+
+	info := store.Info()
+	assert.Equal(t, 10, info.Entries)
+	assert.Less(t, info.FileBytes, int64(minCompactSize))
+
+	data, err := store.Get("key-9")
+	assert.NoError(t, err)
+	assert.Equal(t, value+"2999", valueOf(t, data))
+}
+
+func TestTwoTierCache(t *testing.T) {
+	store, err := Open(t.TempDir(), 0)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	config := bigcache.DefaultConfig(time.Hour)
+	config.Shards = 1
+	config.HardMaxCacheSize = 1
+	config.MaxEntrySize = 600
+	config.OnRemoveWithReason = store.OnRemove
+	cache, err := bigcache.New(context.Background(), config)
+	assert.NoError(t, err)
+
+	ctx := &model.CacheAppContext{Cache: cache, SecondTier: store}
+
+	value := strings.Repeat("x", 500)
+	for i := 0; i < 4000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		assert.NoError(t, ctx.SetEntry(key, model.CacheEntry{Value: value + key, Expiration: time.Now().Add(time.Hour)}))
+	}
+
+	// The first keys only survive on disk and are promoted back on read
+	_, err = cache.Get("key-0")
+	assert.Equal(t, bigcache.ErrEntryNotFound, err)
+	_, err = ctx.GetEntry("key-0")
+	assert.NoError(t, err)
+	_, err = cache.Get("key-0")
+	assert.NoError(t, err)
+
+	for i := 0; i < 4000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		entry, err := ctx.GetEntry(key)
+		assert.NoError(t, err, key)
+		assert.Equal(t, value+key, entry.Value)
+	}
+	assert.NotZero(t, store.Info().Hits)
+
+	// Deleting a key evicted to disk removes it from both tiers
+	ctx.Flush()
+	for i := 0; i < 4000; i += 100 {
+		_, err := ctx.GetEntry("key-" + strconv.Itoa(i))
+		assert.Equal(t, bigcache.ErrEntryNotFound, err)
+	}
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TracerName names the spans of this server
+const TracerName = "cache_engine_httpserver"
+
+// New creates a provider batching spans to the OTLP/HTTP exporter. The
+// exporter is set up by the OTEL_EXPORTER_OTLP_* variables, it sends to
+// http://localhost:4318 without them, and OTEL_TRACES_SAMPLER picks the sampler.
+func New(serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithExporter(serviceName, exporter)
+}
+
+// NewWithExporter creates a provider batching spans to exporter
+func NewWithExporter(serviceName string, exporter sdktrace.SpanExporter) (*sdktrace.TracerProvider, error) {
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override serviceName
+	service, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+	service, err = resource.Merge(service, resource.Environment())
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(service),
+	), nil
+}
@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"cache_engine_httpserver/internal/api/http"
+	"cache_engine_httpserver/internal/api/middleware"
+	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/peer"
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	traceID      = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID = "00f067aa0ba902b7"
+)
+
+func setUpTracing(t *testing.T) (*fiber.App, *model.CacheAppContext, func() tracetest.SpanStubs) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider, err := NewWithExporter("cache-engine-test", exporter)
+	assert.NoError(t, err)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(10*time.Minute))
+	assert.NoError(t, err)
+	ctx := &model.CacheAppContext{Cache: cache, DefaultExpiration: time.Minute, Tracer: provider.Tracer(TracerName)}
+
+	app := fiber.New()
+	app.Use(middleware.TracingMiddleware(ctx))
+	app.Get("/get", func(c fiber.Ctx) error { return http.GetCache(c, ctx) })
+
+	spans := func() tracetest.SpanStubs {
+		assert.NoError(t, provider.ForceFlush(context.Background()))
+		return exporter.GetSpans()
+	}
+	return app, ctx, spans
+}
+
+func get(t *testing.T, app *fiber.App, target string) map[string]any {
+	req := httptest.NewRequest(fiber.MethodGet, target, nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+
+	response := map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	return response
+}
+
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("no `%s` span in %d spans", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	app, ctx, spans := setUpTracing(t)
+	assert.NoError(t, ctx.SetEntry("user:1", model.CacheEntry{Value: "v", Expiration: time.Now().Add(time.Minute)}))
+
+	response := get(t, app, "/get?key=user:1")
+	assert.Equal(t, "OK", response["status"])
+
+	// Setting the entry outside of a request was not traced
+	recorded := spans()
+	assert.Len(t, recorded, 2)
+
+	server := spanNamed(t, recorded, "GET /get")
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, traceID, server.SpanContext.TraceID().String())
+	assert.Equal(t, parentSpanID, server.Parent.SpanID().String())
+	assert.True(t, server.Parent.IsRemote())
+
+	get := spanNamed(t, recorded, "cache.get")
+	assert.Equal(t, server.SpanContext.SpanID(), get.Parent.SpanID())
+	attributes := map[string]string{}
+	for _, attribute := range get.Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	assert.Equal(t, "user", attributes["cache.namespace"])
+	assert.Equal(t, model.KeyHash("user:1"), attributes["cache.key_hash"])
+	assert.Equal(t, "true", attributes["cache.hit"])
+	assert.NotContains(t, attributes, "user:1")
+}
+
+func TestTracingPropagatesToPeerLoads(t *testing.T) {
+	app, ctx, spans := setUpTracing(t)
+
+	traceparents := make(chan string, 1)
+	owner := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(map[string]any{
+			"status": "OK",
+			"cache":  model.CacheEntry{Value: "remote", Expiration: time.Now().Add(time.Minute)},
+		})
+	}))
+	defer owner.Close()
+
+	group := peer.New(ctx, "http://127.0.0.1:1", []string{owner.URL}, time.Minute)
+	ctx.Peers = group
+	key := ""
+	for i := 0; key == ""; i++ {
+		if candidate := "remote:" + strconv.Itoa(i); group.Owner(candidate) == owner.URL {
+			key = candidate
+		}
+	}
+
+	response := get(t, app, "/get?key="+key)
+	assert.Equal(t, "OK", response["status"])
+	assert.Equal(t, "remote", response["cache"].(map[string]any)["value"])
+
+	recorded := spans()
+	server := spanNamed(t, recorded, "GET /get")
+	load := spanNamed(t, recorded, "peer.load")
+	assert.Equal(t, server.SpanContext.SpanID(), load.Parent.SpanID())
+
+	// The owner continues the trace under the peer.load span
+	assert.Equal(t, "00-"+traceID+"-"+load.SpanContext.SpanID().String()+"-01", <-traceparents)
+}
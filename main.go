@@ -1,34 +1,392 @@
 package main
 
 import (
+	"cache_engine_httpserver/internal/api/cluster"
+	"cache_engine_httpserver/internal/api/config"
+	"cache_engine_httpserver/internal/api/eviction"
+	"cache_engine_httpserver/internal/api/gossip"
+	"cache_engine_httpserver/internal/api/invalidation"
+	"cache_engine_httpserver/internal/api/logging"
+	"cache_engine_httpserver/internal/api/metrics"
 	"cache_engine_httpserver/internal/api/middleware"
 	"cache_engine_httpserver/internal/api/model"
+	"cache_engine_httpserver/internal/api/peer"
+	"cache_engine_httpserver/internal/api/persistence"
+	"cache_engine_httpserver/internal/api/replication"
 	"cache_engine_httpserver/internal/api/router"
+	"cache_engine_httpserver/internal/api/slowlog"
+	"cache_engine_httpserver/internal/api/tier"
+	"cache_engine_httpserver/internal/api/tracing"
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/allegro/bigcache/v3"
 	"github.com/gofiber/fiber/v3"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-func getDefaultCacheDuration() time.Duration {
-	defaultCacheDurationInSeconds, err := strconv.Atoi(os.Getenv("DEFAULT_CACHE_DURATION_IN_SECONDS"))
+// setUpSecondTier keeps the entries BigCache evicts before they expire
+// in `l2.dir` when it is set, up to `l2.max_size_in_mb`
+func setUpSecondTier(cacheConfig *bigcache.Config, tierConfig config.SecondTierConfig) *tier.DiskStore {
+	if tierConfig.Dir == "" {
+		return nil
+	}
+
+	diskStore, err := tier.Open(tierConfig.Dir, int64(tierConfig.MaxSizeInMB)*1024*1024)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	cacheConfig.OnRemoveWithReason = diskStore.OnRemove
+
+	return diskStore
+}
+
+// setUpMaxMemory keeps the entries under `max_memory.in_mb` when it is set.
+// `max_memory.policy` is `evict` to evict keys picked by `max_memory.eviction_policy`,
+// `reject` to reject the writes going over it or `noeviction` to only report usage.
+func setUpMaxMemory(cacheConfig *bigcache.Config, maxMemoryConfig config.MaxMemoryConfig) *eviction.Manager {
+	if maxMemoryConfig.InMB == 0 {
+		return nil
+	}
+
+	manager, err := eviction.New(maxMemoryConfig.Policy, maxMemoryConfig.EvictionPolicy, int64(maxMemoryConfig.InMB)*1024*1024)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	// Entries BigCache drops on its own are no longer resident either
+	onRemove := cacheConfig.OnRemoveWithReason
+	cacheConfig.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+		if reason != bigcache.Deleted {
+			manager.Forget(key)
+		}
+		if onRemove != nil {
+			onRemove(key, entry, reason)
+		}
+	}
+
+	return manager
+}
+
+// setUpMetrics records the metrics exported on `/metrics`, when `metrics.enabled`
+// is set. It only counts what BigCache evicts, the rest is wired by watchMetrics.
+func setUpMetrics(cacheConfig *bigcache.Config, metricsConfig config.MetricsConfig) *metrics.Metrics {
+	if !metricsConfig.Enabled {
+		return nil
+	}
+
+	collector := metrics.New(metricsConfig.MaxNamespaces)
+	onRemove := cacheConfig.OnRemoveWithReason
+	cacheConfig.OnRemoveWithReason = func(key string, entry []byte, reason bigcache.RemoveReason) {
+		collector.OnRemove(key, entry, reason)
+		if onRemove != nil {
+			onRemove(key, entry, reason)
+		}
+	}
+
+	return collector
+}
+
+// watchMetrics starts recording the reads and mutations once the cache is restored,
+// so the entries loaded on startup are not counted as sets
+func watchMetrics(appContext *model.CacheAppContext, collector *metrics.Metrics, rateLimiter *middleware.RateLimiter) {
+	if collector == nil {
+		return
+	}
+
+	collector.Register(appContext, rateLimiter.Rejected)
+	appContext.AddObserver(collector)
+	appContext.Metrics = collector
+}
+
+// setUpTracing exports the spans of requests, store operations and peer loads
+// when `tracing.enabled` is set. The W3C trace context is read from incoming
+// requests and sent on the requests to other nodes either way.
+func setUpTracing(appContext *model.CacheAppContext, tracingConfig config.TracingConfig) *sdktrace.TracerProvider {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if !tracingConfig.Enabled {
+		return nil
+	}
+
+	provider, err := tracing.New(tracingConfig.ServiceName)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	otel.SetTracerProvider(provider)
+	appContext.Tracer = provider.Tracer(tracing.TracerName)
+
+	return provider
+}
+
+// setUpSnapshot loads the last snapshot into the cache and schedules
+// periodic snapshots, when `snapshot.enabled` is set
+func setUpSnapshot(appContext *model.CacheAppContext, snapshotConfig config.SnapshotConfig) *persistence.Snapshotter {
+	if !snapshotConfig.Enabled {
+		return nil
+	}
+
+	path := snapshotConfig.File
+	loaded, err := persistence.LoadSnapshot(appContext, path)
 	if err != nil {
 		log.Fatalln(err.Error())
 	}
+	slog.Info("Loaded snapshot", "entries", loaded, "path", path)
 
-	return time.Duration(defaultCacheDurationInSeconds) * time.Second
+	snapshotter := persistence.NewSnapshotter(appContext, path)
+	if snapshotConfig.IntervalInSeconds > 0 {
+		snapshotter.Start(time.Duration(snapshotConfig.IntervalInSeconds) * time.Second)
+	}
+	appContext.Snapshotter = snapshotter
+
+	return snapshotter
 }
 
-// Define a separate function for the middleware
-func firstHandler(c fiber.Ctx) error {
-	fmt.Println("🥇 First handler")
-	return c.Next()
+// setUpAppendOnlyFile replays the append-only file into the cache and starts
+// recording every following mutation, when `append_only.enabled` is set
+func setUpAppendOnlyFile(appContext *model.CacheAppContext, aofConfig config.AppendOnlyConfig) *persistence.AOF {
+	if !aofConfig.Enabled {
+		return nil
+	}
+
+	path := aofConfig.File
+	aof, err := persistence.OpenAOF(path, aofConfig.Fsync, int64(aofConfig.RewriteMinSizeInMB)*1024*1024)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+
+	applied, err := aof.Replay(appContext)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	slog.Info("Replayed append-only file", "records", applied, "path", path)
+
+	appContext.AddObserver(aof)
+	aof.Start(appContext)
+
+	return aof
+}
+
+// setUpReplication records mutations for replicas when `replication.role` is set and,
+// when `replication.replica_of` is set, follows that primary as a read-only replica
+func setUpReplication(appContext *model.CacheAppContext, replicationConfig config.ReplicationConfig) *replication.Manager {
+	if replicationConfig.Role == "" && replicationConfig.ReplicaOf == "" {
+		return nil
+	}
+
+	manager := replication.NewManager(appContext, replicationConfig.BacklogSize)
+	appContext.AddObserver(manager)
+	appContext.Replication = manager
+
+	if replicationConfig.ReplicaOf != "" {
+		manager.ReplicaOf(replicationConfig.ReplicaOf, replicationConfig.ReplicaID)
+	}
+
+	return manager
+}
+
+// setUpCluster partitions keys between `cluster.members`, or the addresses
+// `cluster.discovery_dns` resolves to, when `cluster.self` is set
+func setUpCluster(appContext *model.CacheAppContext, clusterConfig config.ClusterConfig) *cluster.Cluster {
+	if clusterConfig.Self == "" {
+		return nil
+	}
+
+	clusterNode := cluster.New(appContext, clusterConfig.Self, clusterConfig.Members, clusterConfig.VirtualNodes)
+	if clusterConfig.DiscoveryDNS != "" {
+		interval := time.Duration(clusterConfig.DiscoveryIntervalInSeconds) * time.Second
+		clusterNode.StartDNSDiscovery(clusterConfig.DiscoveryDNS, "http", clusterConfig.DiscoveryPort, interval)
+	}
+	appContext.Cluster = clusterNode
+
+	return clusterNode
+}
+
+// setUpPeers fills local misses from the owner among `peers.peers` when `peers.self` is set
+func setUpPeers(appContext *model.CacheAppContext, peersConfig config.PeersConfig) *peer.Group {
+	if peersConfig.Self == "" {
+		return nil
+	}
+
+	hotTTL := time.Duration(peersConfig.HotTTLInSeconds) * time.Second
+	group := peer.New(appContext, peersConfig.Self, peersConfig.Peers, hotTTL)
+	appContext.Peers = group
+
+	return group
+}
+
+// setUpInvalidation broadcasts deletes, tag purges and flushes to
+// `invalidation.peers` and/or the `invalidation.multicast` UDP group
+func setUpInvalidation(appContext *model.CacheAppContext, invalidationConfig config.InvalidationConfig) *invalidation.Broadcaster {
+	if len(invalidationConfig.Peers) == 0 && invalidationConfig.Multicast == "" {
+		return nil
+	}
+
+	broadcaster, err := invalidation.New(appContext, invalidationConfig.NodeID, invalidationConfig.Peers,
+		invalidationConfig.Multicast, invalidationConfig.Retries)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	appContext.Invalidator = broadcaster
+
+	return broadcaster
+}
+
+// setUpGossip maintains the member list with SWIM gossip when `gossip.bind`
+// is set, in cluster mode the active members become the cluster members
+func setUpGossip(appContext *model.CacheAppContext, appConfig *config.Config) *gossip.Gossip {
+	if appConfig.Gossip.Bind == "" {
+		return nil
+	}
+
+	url := appConfig.Gossip.URL
+	if url == "" {
+		url = appConfig.Cluster.Self
+	}
+	gossipConfig := gossip.DefaultConfig(appConfig.Gossip.Name, appConfig.Gossip.Bind, url)
+	gossipConfig.AdvertiseAddress = appConfig.Gossip.Advertise
+	if appContext.Cluster != nil {
+		gossipConfig.OnChange = appContext.Cluster.SetMembers
+	}
+
+	member, err := gossip.New(gossipConfig)
+	if err != nil {
+		log.Fatalln(err.Error())
+	}
+	appContext.Membership = member
+
+	// The first member has nobody to join yet
+	if len(appConfig.Gossip.Seeds) > 0 {
+		if _, err := member.Join(appConfig.Gossip.Seeds); err != nil {
+			slog.Error("Error when joining gossip seeds", "seeds", appConfig.Gossip.Seeds, "error", err)
+		}
+	}
+
+	return member
+}
+
+// applySettings switches every setting tagged `reload` in config.Config
+func applySettings(appContext *model.CacheAppContext, rateLimiter *middleware.RateLimiter, appConfig *config.Config) {
+	level, _ := logging.ParseLevel(appConfig.LogLevel)
+	logging.Level.Set(level)
+	appContext.SetDefaultExpiration(appConfig.DefaultExpiration())
+	appContext.SetAuthKeys(appConfig.Auth.Keys)
+	rateLimiter.SetLimits(appConfig.RateLimit.Max, time.Duration(appConfig.RateLimit.WindowInSeconds)*time.Second)
+	appContext.SlowLog.SetThreshold(appConfig.SlowLogThreshold())
+}
+
+// setUpReload reloads the configuration on SIGHUP and `POST /admin/config/reload`
+func setUpReload(appContext *model.CacheAppContext, rateLimiter *middleware.RateLimiter, appConfig *config.Config) *config.Reloader {
+	reloader := config.NewReloader(appConfig, os.Args[1:], func(appConfig *config.Config) {
+		applySettings(appContext, rateLimiter, appConfig)
+	})
+	appContext.Config = reloader
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		for range hangups {
+			result, err := reloader.Reload()
+			if err != nil {
+				slog.Error("Invalid configuration, nothing was applied", "error", err)
+				continue
+			}
+			slog.Info("Reloaded configuration", "applied", result.Applied, "requires_restart", result.RequiresRestart)
+		}
+	}()
+
+	return reloader
+}
+
+// services are the subsystems stopped on shutdown, nil when disabled
+type services struct {
+	cache       *bigcache.BigCache
+	diskStore   *tier.DiskStore
+	snapshotter *persistence.Snapshotter
+	aof         *persistence.AOF
+	replication *replication.Manager
+	cluster     *cluster.Cluster
+	broadcaster *invalidation.Broadcaster
+	gossip      *gossip.Gossip
+	tracing     *sdktrace.TracerProvider
+}
+
+// shutDown fails readiness for delay so load balancers stop sending requests,
+// then stops accepting connections and gives in-flight requests until
+// timeout to finish, then stops the subsystems and flushes persistence.
+// It returns 0 when everything was drained and flushed, 1 otherwise.
+func shutDown(app *fiber.App, appContext *model.CacheAppContext, services services, delay time.Duration, timeout time.Duration) int {
+	code := 0
+
+	appContext.SetShuttingDown()
+	time.Sleep(delay)
+
+	if err := app.ShutdownWithTimeout(timeout); err != nil {
+		slog.Error("Error when draining requests, the remaining ones were dropped", "error", err)
+		code = 1
+	}
+
+	// Nothing mutates the cache past this point except the background
+	// subsystems, stopped before persistence is flushed
+	if services.gossip != nil {
+		services.gossip.Leave()
+	}
+	if services.cluster != nil {
+		services.cluster.Close()
+	}
+	if services.broadcaster != nil {
+		services.broadcaster.Close()
+	}
+	if services.replication != nil {
+		services.replication.Close()
+	}
+
+	if services.snapshotter != nil {
+		services.snapshotter.Close()
+		if err := services.snapshotter.Save(); err != nil {
+			slog.Error("Error when writing the final snapshot", "error", err)
+			code = 1
+		}
+	}
+	if services.aof != nil {
+		if err := services.aof.Close(); err != nil {
+			slog.Error("Error when flushing the append-only file", "error", err)
+			code = 1
+		}
+	}
+
+	// The spans of the drained requests are still buffered
+	if services.tracing != nil {
+		flushContext, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := services.tracing.Shutdown(flushContext); err != nil {
+			slog.Error("Error when flushing the spans", "error", err)
+			code = 1
+		}
+	}
+
+	// BigCache hands its evictions to the disk tier until it is closed
+	if err := services.cache.Close(); err != nil {
+		slog.Error("Error when closing the cache", "error", err)
+		code = 1
+	}
+	if services.diskStore != nil {
+		if err := services.diskStore.Close(); err != nil {
+			slog.Error("Error when closing the disk tier", "error", err)
+			code = 1
+		}
+	}
+
+	return code
 }
 
 func main() {
@@ -38,24 +396,99 @@ func main() {
 		log.Println(err.Error())
 	}
 
+	appConfig, err := config.Load(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration :\n%s\n", err.Error())
+		os.Exit(2)
+	}
+	logging.SetUp(appConfig.LogFormat)
+
 	// Initiliaze cache
-	cache, err := bigcache.New(context.Background(), bigcache.DefaultConfig(getDefaultCacheDuration()))
+	cacheConfig := appConfig.BigCache()
+	diskStore := setUpSecondTier(&cacheConfig, appConfig.SecondTier)
+	evictionManager := setUpMaxMemory(&cacheConfig, appConfig.MaxMemory)
+	collector := setUpMetrics(&cacheConfig, appConfig.Metrics)
+
+	cache, err := bigcache.New(context.Background(), cacheConfig)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
 	// Create AppContext to share dependencies
 	appContext := &model.CacheAppContext{
-		Cache: cache,
+		Cache:             cache,
+		DefaultExpiration: appConfig.DefaultExpiration(),
+		MaxEntrySize:      model.MaxEntrySizeFor(cacheConfig),
+		StartedAt:         time.Now(),
+		MaxReplicationLag: uint64(appConfig.Replication.MaxLag),
+		SlowLog:           slowlog.New(appConfig.SlowLog.MaxLen, appConfig.SlowLogThreshold()),
+	}
+	if diskStore != nil {
+		appContext.SecondTier = diskStore
 	}
+	if evictionManager != nil {
+		appContext.Eviction = evictionManager
+	}
+
+	// Restore the cache before accepting requests,
+	// the append-only file is newer than the snapshot so it is replayed last
+	services := services{cache: cache, diskStore: diskStore}
+	services.tracing = setUpTracing(appContext, appConfig.Tracing)
+	services.snapshotter = setUpSnapshot(appContext, appConfig.Snapshot)
+	services.aof = setUpAppendOnlyFile(appContext, appConfig.AppendOnly)
+	appContext.SetRestored()
+	services.replication = setUpReplication(appContext, appConfig.Replication)
+	services.cluster = setUpCluster(appContext, appConfig.Cluster)
+	setUpPeers(appContext, appConfig.Peers)
+	services.broadcaster = setUpInvalidation(appContext, appConfig.Invalidation)
+	services.gossip = setUpGossip(appContext, appConfig)
+
+	rateLimiter := middleware.RateLimiterMiddleware(appConfig.RateLimit.Max, time.Duration(appConfig.RateLimit.WindowInSeconds)*time.Second)
+	applySettings(appContext, rateLimiter, appConfig)
+	watchMetrics(appContext, collector, rateLimiter)
+	setUpReload(appContext, rateLimiter, appConfig)
 
 	// Initialize Fiber app
-	app := fiber.New()
-	app.Use(firstHandler)
+	// Stream request bodies so `/admin/import` is not bound by the body limit
+	app := fiber.New(fiber.Config{
+		StreamRequestBody: true,
+	})
+	app.Use(middleware.RequestIDMiddleware())
+	app.Use(middleware.AccessLogMiddleware())
+	app.Use(middleware.MetricsMiddleware(appContext))
+	app.Use(middleware.TracingMiddleware(appContext))
+	app.Use(middleware.SlowLogMiddleware(appContext))
+	app.Use(middleware.AuthMiddleware(appContext))
 	// Or extend your config for customization
-	app.Use(middleware.RateLimiterMiddleware())
+	app.Use(rateLimiter.Handler())
+	app.Use(middleware.ReadOnlyReplicaMiddleware(appContext))
+	app.Use(middleware.ClusterRoutingMiddleware(appContext))
 	router.HandleRoute(app, appContext)
 
-	port := os.Getenv("PORT")
-	log.Fatal(app.Listen(port))
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	listened := make(chan error, 1)
+	go func() {
+		listened <- app.Listen(appConfig.ListenAddress())
+	}()
+
+	select {
+	case err := <-listened:
+		log.Fatal(err)
+	case received := <-signals:
+		slog.Info("Shutting down", "signal", received.String(), "within", appConfig.ShutdownDelay()+appConfig.ShutdownTimeout())
+	}
+
+	// A second signal does not wait for the drain
+	go func() {
+		received := <-signals
+		slog.Info("Received the signal again, exiting now", "signal", received.String())
+		os.Exit(1)
+	}()
+
+	os.Exit(shutDown(app, appContext, services, appConfig.ShutdownDelay(), appConfig.ShutdownTimeout()))
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runMainEnv makes the test binary run main instead of the tests,
+// so the server can be signaled as a separate process
+const runMainEnv = "CACHE_ENGINE_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		os.Args = os.Args[:1]
+		main()
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+// startServer runs main in dir, listening on address, until it answers
+func startServer(t *testing.T, dir string, address string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0])
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		runMainEnv+"=1",
+		"PORT="+address,
+		"SNAPSHOT=true",
+		"SNAPSHOT_INTERVAL_IN_SECONDS=0",
+		"APPEND_ONLY=true",
+		"SHUTDOWN_TIMEOUT_IN_SECONDS=5",
+		"SHUTDOWN_DELAY_IN_SECONDS=1",
+	)
+	cmd.Stderr = os.Stderr
+	assert.NoError(t, cmd.Start())
+
+	for i := 0; i < 100; i++ {
+		resp, err := http.Get("http://" + address + "/cache-engine-api/get?key=ping")
+		if err == nil {
+			resp.Body.Close()
+			return cmd
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cmd.Process.Kill()
+	t.Fatal("server did not start")
+	return nil
+}
+
+func post(address string, path string, body map[string]any) (map[string]any, error) {
+	data, _ := json.Marshal(body)
+	resp, err := http.Post("http://"+address+"/cache-engine-api"+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := map[string]any{}
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	return response, err
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	dir := t.TempDir()
+	address := freeAddress(t)
+	server := startServer(t, dir, address)
+
+	response, err := post(address, "/create", map[string]any{"key": "kept", "value": "value", "duration_in_seconds": 600})
+	assert.NoError(t, err)
+	assert.Equal(t, "OK", response["status"])
+
+	// A long-poll is still in flight when the signal arrives
+	popped := make(chan map[string]any, 1)
+	go func() {
+		response, err := post(address, "/list/blpop", map[string]any{"key": "queue", "timeout_in_seconds": 30})
+		assert.NoError(t, err)
+		popped <- response
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	resp, err := http.Get("http://" + address + "/readyz")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Readiness fails while requests are still served, before the drain
+	assert.NoError(t, server.Process.Signal(syscall.SIGTERM))
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + address + "/readyz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 50*time.Millisecond)
+	assert.NoError(t, server.Wait())
+	assert.Equal(t, 0, server.ProcessState.ExitCode())
+
+	select {
+	case response := <-popped:
+		assert.Equal(t, "Server is shutting down", response["message"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight request was not answered")
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "dump.ndjson.gz"))
+	assert.NoError(t, err)
+
+	// The final snapshot and the append-only file bring the key back
+	server = startServer(t, dir, address)
+	resp, err = http.Get("http://" + address + "/cache-engine-api/get?key=kept")
+	assert.NoError(t, err)
+	response = map[string]any{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	resp.Body.Close()
+	assert.Equal(t, "OK", response["status"])
+
+	assert.NoError(t, server.Process.Signal(syscall.SIGINT))
+	assert.NoError(t, server.Wait())
+	assert.Equal(t, 0, server.ProcessState.ExitCode())
+}